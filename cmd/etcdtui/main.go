@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/alex-dev-master/etcdtui/internal/app/layouts"
+	"github.com/alex-dev-master/etcdtui/internal/cli"
+	"github.com/alex-dev-master/etcdtui/internal/config"
 	"github.com/spf13/pflag"
 )
 
@@ -22,7 +25,22 @@ var (
 	showVersion = pflag.BoolP("version", "v", false, "Show version")
 )
 
+// connection holds the flags to connect without a saved profile
+var connection config.ConnectionFlags
+
+func init() {
+	connection.Register(pflag.CommandLine)
+}
+
 func main() {
+	// Subcommands run without the TUI and parse their own flags
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		code := cli.Run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	}
+
 	pflag.Parse()
 
 	if *showVersion {
@@ -41,6 +59,7 @@ func main() {
 	if *profileName != "" {
 		m.SetProfileName(*profileName)
 	}
+	m.SetConnectionFlags(&connection)
 
 	ctx := context.Background()
 	if err := m.Render(ctx); err != nil {
@@ -60,12 +79,27 @@ func printUsage() {
 	fmt.Print(`
 Usage:
   etcdtui [flags]
+  etcdtui <command> [flags] [args]
 
 Flags:
   -p, --profile string   Profile name to use for connection
   -h, --help             Show help message
   -v, --version          Show version
 
+Connection flags (also accepted by commands):
+`)
+	fmt.Print(connectionUsage())
+	fmt.Print(`
+Without --profile the ETCDCTL_ENDPOINTS, ETCDCTL_USER, ETCDCTL_PASSWORD,
+ETCDCTL_CACERT, ETCDCTL_CERT, ETCDCTL_KEY, ETCDCTL_INSECURE_SKIP_TLS_VERIFY,
+ETCDCTL_DIAL_TIMEOUT and ETCDCTL_COMMAND_TIMEOUT variables are read too.
+
+Commands:
+`)
+	fmt.Print(cli.Usage())
+	fmt.Print(`
+Run 'etcdtui <command> --help' for command flags.
+
 Config file: ~/.config/etcdtui/config.yaml
 
 Example config:
@@ -86,3 +120,11 @@ profiles:
       key_file: "/path/to/client.key"
 `)
 }
+
+// connectionUsage returns the help of the connection flags
+func connectionUsage() string {
+	fs := pflag.NewFlagSet("connection", pflag.ContinueOnError)
+	var flags config.ConnectionFlags
+	flags.Register(fs)
+	return fs.FlagUsages()
+}
@@ -0,0 +1,114 @@
+package general
+
+import (
+	"context"
+
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+)
+
+// keyAccess returns what the connected user may do with a single key. When
+// the user's roles could not be read the key is probed first.
+func (s *State) keyAccess(ctx context.Context, key string) client.Access {
+	id := s.connManager.GetIdentity()
+	cli := s.connManager.GetClient()
+	if id == nil || cli == nil {
+		return client.Access{}
+	}
+	if id.Probed {
+		return cli.ProbeAccess(ctx, id, key, "")
+	}
+	return id.Access(key, "")
+}
+
+// detailsAccess returns what the connected user may do with key without
+// waiting for the cluster. A key that must be probed first is shown as
+// writable and probed in the background, and onProbed is called on the UI
+// goroutine once its access is known.
+func (s *State) detailsAccess(ctx context.Context, key string, onProbed func()) client.Access {
+	id := s.connManager.GetIdentity()
+	cli := s.connManager.GetClient()
+	if id == nil || cli == nil {
+		return client.Access{}
+	}
+	if id.NeedsProbe(key, "") {
+		go func() {
+			cli.ProbeAccess(ctx, id, key, "")
+			s.app.QueueUpdateDraw(func() {
+				if ctx.Err() == nil && s.connManager.GetIdentity() == id {
+					onProbed()
+				}
+			})
+		}()
+	}
+	return id.Access(key, "")
+}
+
+// treeAccess returns the access of a key range as the keys tree shows it;
+// ranges that were not probed are shown as writable
+func (s *State) treeAccess(key, rangeEnd string) client.Access {
+	id := s.connManager.GetIdentity()
+	if id == nil {
+		return client.Access{}
+	}
+	return id.Access(key, rangeEnd)
+}
+
+// probeChildren probes the access of directories about to be added to the
+// tree when the user's permissions are unknown. Keys are probed when they
+// are selected. It runs off the UI goroutine.
+func (s *State) probeChildren(ctx context.Context, cli *client.Client, children []*client.Child) {
+	id := s.connManager.GetIdentity()
+	if id == nil || !id.Probed {
+		return
+	}
+	for _, child := range children {
+		if ctx.Err() != nil {
+			return
+		}
+		if child.IsDir() {
+			cli.ProbeAccess(ctx, id, child.Prefix, client.PrefixRangeEnd(child.Prefix))
+		}
+	}
+}
+
+// listChildren lists the part of prefix the connected user can read at
+// revision, probing the access of the children if needed
+func (s *State) listChildren(ctx context.Context, cli *client.Client, prefix string, revision int64) ([]*client.Child, int64, error) {
+	children, revision, err := cli.ListReadableChildren(ctx, s.connManager.GetIdentity(), prefix, revision)
+	if err != nil {
+		return nil, revision, err
+	}
+	s.probeChildren(ctx, cli, children)
+	return children, revision, nil
+}
+
+// checkWritable returns false and explains why in the status bar if the
+// connected user may not write key
+func (s *State) checkWritable(ctx context.Context, key string) bool {
+	if s.keyAccess(ctx, key).CanWrite() {
+		return true
+	}
+	s.SetStatusBarText("[yellow]Read-only:[white] no write permission for " + details.EscapeText(key))
+	s.debugPanel.LogWarn("Write refused without permission: %s", key)
+	return false
+}
+
+// canCreateKeys returns false and explains why in the status bar if the
+// connected user may not write any key
+func (s *State) canCreateKeys() bool {
+	if id := s.connManager.GetIdentity(); id == nil || id.HasWriteAccess() {
+		return true
+	}
+	s.SetStatusBarText("[yellow]Read-only:[white] no write permission for any key")
+	return false
+}
+
+// identityInfo describes the connected user for the status bar
+func (s *State) identityInfo() string {
+	id := s.connManager.GetIdentity()
+	if id == nil {
+		return ""
+	}
+	return "User: [cyan]" + id.Describe() + "[-] | "
+}
@@ -2,7 +2,9 @@ package general
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
 	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
@@ -19,8 +21,23 @@ func (s *State) HandleEdit(ctx context.Context) {
 		return
 	}
 
+	if kv.IsBinary() {
+		s.SetStatusBarText("[yellow]Binary values cannot be edited as text")
+		s.debugPanel.LogWarn("Edit refused for binary key: %s", kv.Key)
+		return
+	}
+
+	if !s.checkWritable(ctx, kv.Key) || !s.checkProtection(kv.Key, false) {
+		return
+	}
+
 	s.debugPanel.LogInfo("Opening edit form for key: %s", kv.Key)
+	s.showEditForm(ctx, kv, string(kv.Value))
+}
 
+// showEditForm shows the edit form with value for the key read as base.
+// Saving fails with a conflict view if the key changed since base.
+func (s *State) showEditForm(ctx context.Context, base *client.KeyValue, value string) {
 	// Enable edit mode to bypass global input capture
 	s.SetEditMode(true)
 
@@ -33,33 +50,20 @@ func (s *State) HandleEdit(ctx context.Context) {
 	// Create form for editing
 	form := tview.NewForm()
 
-	form.AddTextView("Key", kv.Key, 50, 5, true, false)
+	form.AddTextView("Key", base.Key, 50, 5, true, false)
 
 	// Add Value text area
-	form.AddTextArea("Value", kv.Value, 50, 0, 0, nil)
+	form.AddTextArea("Value", value, 50, 0, 0, nil)
 
 	form.AddButton("Save", func() {
 		newValue := form.GetFormItemByLabel("Value").(*tview.TextArea).GetText()
-		s.debugPanel.LogDebug("Save button clicked - Key: %s, Value length: %d", kv.Key, len(newValue))
-
-		if err := s.PutKey(ctx, kv.Key, newValue); err != nil {
-			s.SetStatusBarText("[red]Failed to save:[white] " + err.Error())
-			s.debugPanel.LogError("Failed to save key '%s': %v", kv.Key, err)
-			closeForm()
-			return
-		}
-
-		s.debugPanel.LogInfo("Successfully saved key: %s", kv.Key)
-
-		// Refresh details for the updated key
-		if err := s.RefreshKeyDetails(ctx, kv.Key); err != nil {
-			s.SetStatusBarText("[yellow]Saved but failed to refresh details:[white] " + err.Error())
-			s.debugPanel.LogWarn("Saved but failed to refresh details: %v", err)
-		} else {
-			s.SetStatusBarText("[green]Saved:[white] " + kv.Key)
-		}
+		s.debugPanel.LogDebug("Save button clicked - Key: %s, Value length: %d", base.Key, len(newValue))
+		s.saveKey(ctx, base.Key, newValue, base, base, form, WriteOptions{})
+	})
 
-		closeForm()
+	form.AddButton("Editor", func() {
+		value := form.GetFormItemByLabel("Value").(*tview.TextArea).GetText()
+		s.editExternally(ctx, base, value)
 	})
 
 	form.AddButton("Cancel", func() {
@@ -75,7 +79,7 @@ func (s *State) HandleEdit(ctx context.Context) {
 		return event
 	})
 
-	form.SetBorder(true).SetTitle(" Edit Key (Tab to navigate, ESC cancel) ").SetTitleAlign(tview.AlignLeft)
+	form.SetBorder(true).SetTitle(fmt.Sprintf(" Edit Key at revision %d (Tab to navigate, ESC cancel) ", base.ModRevision)).SetTitleAlign(tview.AlignLeft)
 	form.SetCancelFunc(closeForm)
 
 	// Set root and focus on first form field
@@ -83,7 +87,8 @@ func (s *State) HandleEdit(ctx context.Context) {
 	form.SetFocus(0)
 }
 
-// HandleDelete shows confirmation modal and deletes the selected key.
+// HandleDelete shows confirmation modal and deletes the selected key. Keys
+// protected with confirmation have to be typed instead.
 func (s *State) HandleDelete(ctx context.Context) {
 	kv := s.GetCurrentKey()
 	if kv == nil {
@@ -91,121 +96,47 @@ func (s *State) HandleDelete(ctx context.Context) {
 		return
 	}
 
-	// Enable edit mode to bypass global input capture
-	s.SetEditMode(true)
-
-	modal := tview.NewModal().
-		SetText("Delete key: " + kv.Key + "?").
-		AddButtons([]string{"Delete", "Cancel"}).
-		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			s.SetEditMode(false)
-			s.app.SetRoot(s.rootFlex, true)
-			if buttonLabel == "Delete" {
-				if err := s.DeleteKey(ctx, kv.Key); err != nil {
-					s.SetStatusBarText("[red]Failed to delete:[white] " + err.Error())
-				} else {
-					s.SetStatusBarText("[green]Deleted:[white] " + kv.Key)
-				}
-			}
-		})
-
-	s.app.SetRoot(modal, true)
-}
-
-// HandleWatch shows watch mode for the selected key.
-func (s *State) HandleWatch(ctx context.Context) {
-	kv := s.GetCurrentKey()
-	if kv == nil {
-		s.SetStatusBarText("[yellow]No key selected")
+	if !s.checkWritable(ctx, kv.Key) || !s.checkProtection(kv.Key, true) {
 		return
 	}
 
-	// Cancel any existing watch
-	if s.watchCancel != nil {
-		s.watchCancel()
-	}
-
+	// Enable edit mode to bypass global input capture
 	s.SetEditMode(true)
 
-	// Create watch context with cancel
-	watchCtx, cancel := context.WithCancel(ctx)
-	s.watchCancel = cancel
-
-	// Handle close
-	closeWatch := func() {
-		cancel()
-		s.watchCancel = nil
+	closeModal := func() {
 		s.SetEditMode(false)
 		s.app.SetRoot(s.rootFlex, true)
-		s.SetStatusBarText("[yellow]Watch stopped for " + kv.Key)
 	}
-
-	// Create watch log view
-	logView := tview.NewTextView().
-		SetDynamicColors(true).
-		SetScrollable(true)
-
-	logView.SetBorder(true).
-		SetTitle(" Watch: " + kv.Key + " (Press ESC to stop) ").
-		SetTitleAlign(tview.AlignLeft).
-		SetBorderColor(tcell.ColorYellow)
-
-	// Add initial value
-	_, _ = logView.Write([]byte("[cyan]Started watching key: " + kv.Key + "[-]\n\n"))
-	_, _ = logView.Write([]byte("[yellow]Current value:[-]\n" + kv.Value + "\n\n"))
-	_, _ = logView.Write([]byte("[gray]Waiting for changes...[-]\n"))
-
-	// Center the watch window
-	flex := tview.NewFlex().
-		AddItem(nil, 0, 1, false).
-		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
-			AddItem(nil, 0, 1, false).
-			AddItem(logView, 0, 3, true).
-			AddItem(nil, 0, 1, false), 0, 2, true).
-		AddItem(nil, 0, 1, false)
-
-	// Set global InputCapture for ESC (app level, not primitive level)
-	s.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEsc {
-			closeWatch()
-			return nil
+	deleteKey := func(confirmed bool) {
+		closeModal()
+		if err := s.DeleteKey(ctx, kv.Key, confirmed); err != nil {
+			s.SetStatusBarText("[red]Failed to delete:[white] " + err.Error())
+		} else {
+			s.SetStatusBarText("[green]Deleted:[white] " + kv.Key)
 		}
-		return event
-	})
-
-	s.app.SetRoot(flex, true)
+	}
 
-	// Start watch in background
-	go func() {
-		cli := s.connManager.GetClient()
-		if cli == nil {
-			s.app.QueueUpdateDraw(func() {
-				_, _ = logView.Write([]byte("[red]Error: Not connected to etcd[-]\n"))
-			})
-			return
-		}
+	// Keys protected with confirmation are typed instead of the modal
+	var protectionErr *client.ProtectionError
+	if errors.As(s.guard().CheckDelete(kv.Key, false), &protectionErr) {
+		s.confirmProtected(protectionErr, closeModal, func() {
+			deleteKey(true)
+		})
+		return
+	}
 
-		err := cli.Watch(watchCtx, kv.Key, func(event *client.WatchEvent) {
-			s.app.QueueUpdateDraw(func() {
-				revision := event.ModRevision
-				switch event.Type {
-				case client.EventTypePut:
-					_, _ = fmt.Fprintf(logView, "\n[green]► PUT[-] [gray](rev %d)[-]\n", revision)
-					_, _ = logView.Write([]byte("[yellow]New value:[-]\n" + event.Value + "\n"))
-				case client.EventTypeDelete:
-					_, _ = fmt.Fprintf(logView, "\n[red]► DELETE[-] [gray](rev %d)[-]\n", revision)
-					_, _ = logView.Write([]byte("[gray]Key was deleted[-]\n"))
-				}
-				logView.ScrollToEnd()
-			})
+	modal := tview.NewModal().
+		SetText("Delete key: " + kv.Key + "?").
+		AddButtons([]string{"Delete", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel == "Delete" {
+				deleteKey(false)
+			} else {
+				closeModal()
+			}
 		})
 
-		if err != nil && watchCtx.Err() == nil {
-			s.app.QueueUpdateDraw(func() {
-				_, _ = logView.Write([]byte("[red]Watch error: " + err.Error() + "[-]\n"))
-			})
-		}
-	}()
+	s.app.SetRoot(modal, true)
 }
 
 // HandleDetailsAction handles actions from the details panel buttons.
@@ -220,7 +151,35 @@ func (s *State) HandleDetailsAction(ctx context.Context, action details.ActionTy
 	case details.ActionWatch:
 		s.HandleWatch(ctx)
 		s.app.SetFocus(s.keysPanel.GetTree())
+	case details.ActionView:
+		s.HandleCycleValueMode(ctx)
+	case details.ActionHistory:
+		s.HandleHistory(ctx)
+	}
+}
+
+// HandleCycleValueMode switches the value rendering of the selected key
+// between pretty, raw, hex dump and base64.
+func (s *State) HandleCycleValueMode(ctx context.Context) {
+	kv := s.GetCurrentKey()
+	if kv == nil {
+		return
 	}
+	mode := s.detailsPanel.CycleValueMode()
+	s.showKeyDetails(ctx, kv)
+	s.SetStatusBarText("[green]Value view:[white] " + mode.String())
+}
+
+// HandleTogglePretty switches the value of the selected key between its
+// pretty printed and its stored form.
+func (s *State) HandleTogglePretty(ctx context.Context) {
+	kv := s.GetCurrentKey()
+	if kv == nil {
+		return
+	}
+	mode := s.detailsPanel.TogglePretty()
+	s.showKeyDetails(ctx, kv)
+	s.SetStatusBarText("[green]Value view:[white] " + mode.String())
 }
 
 // HandleSearch shows search input for prefix search.
@@ -250,8 +209,7 @@ func (s *State) HandleSearch(ctx context.Context) {
 			s.SetStatusBarText("[red]Search failed:[white] " + err.Error())
 			s.debugPanel.LogError("Search failed: %v", err)
 		} else {
-			s.SetStatusBarText("[green]Search:[white] " + prefix)
-			s.debugPanel.LogInfo("Search completed for prefix: %s", prefix)
+			s.debugPanel.LogInfo("Search started for prefix: %s", prefix)
 		}
 
 		closeForm()
@@ -261,8 +219,6 @@ func (s *State) HandleSearch(ctx context.Context) {
 		// Reload all keys
 		if err := s.RefreshKeys(ctx); err != nil {
 			s.SetStatusBarText("[red]Failed to reload keys:[white] " + err.Error())
-		} else {
-			s.SetStatusBarText("[green]All keys loaded")
 		}
 		closeForm()
 	})
@@ -290,6 +246,10 @@ func (s *State) HandleSearch(ctx context.Context) {
 
 // HandleCreateNewKey create new key.
 func (s *State) HandleCreateNewKey(ctx context.Context) {
+	if !s.canCreateKeys() {
+		return
+	}
+
 	s.debugPanel.LogInfo("Opening form for new key")
 
 	// Enable edit mode to bypass global input capture
@@ -316,24 +276,14 @@ func (s *State) HandleCreateNewKey(ctx context.Context) {
 
 		s.debugPanel.LogDebug("Save button clicked - Key: %s, Value length: %d", newKey, len(newValue))
 
-		if err := s.PutKey(ctx, newKey, newValue); err != nil {
-			s.SetStatusBarText("[red]Failed to save:[white] " + err.Error())
-			s.debugPanel.LogError("Failed to save key '%s': %v", newKey, err)
+		if !s.checkWritable(ctx, newKey) {
 			closeForm()
 			return
 		}
 
-		s.debugPanel.LogInfo("Successfully saved new key: %s", newKey)
-
-		// Refresh details for the updated key
-		if err := s.RefreshKeyDetails(ctx, newKey); err != nil {
-			s.SetStatusBarText("[yellow]Saved but failed to refresh details:[white] " + err.Error())
-			s.debugPanel.LogWarn("Saved but failed to refresh details: %v", err)
-		} else {
-			s.SetStatusBarText("[green]Saved:[white] " + newKey)
-		}
-
-		closeForm()
+		// An existing key is not replaced without going through the
+		// conflict view
+		s.saveKey(ctx, newKey, newValue, nil, nil, form, WriteOptions{})
 	})
 
 	form.AddButton("Cancel", func() {
@@ -388,11 +338,27 @@ func (s *State) ShowHelp() {
 
 [cyan::b]Keys[-:-:-]
   [green]e[-]           Edit key/value
+  [green]E[-]           Edit value in $VISUAL/$EDITOR
   [green]d[-]           Delete key
   [green]n[-]           New key
   [green]r[-]           Refresh keys
-  [green]w[-]           Watch mode
+  [green]L[-]           Live tree updates on/off
+  [green]w[-]           Watch key or directory
+  [green]W[-]           Show/hide watches pane
+  [green]f[-]           Pretty/raw value
+  [green]v[-]           Value view (pretty/raw/hex/base64)
+  [green]H[-]           Key history, diff and restore
+  [green]X[-]           Export directory to JSON, YAML or dump
+  [green]I[-]           Import a file into a prefix (plan, diff, apply)
   [green]/[-]           Search by prefix
+  [green]ESC[-]         Cancel key loading
+
+[cyan::b]Maintenance[-:-:-]
+  [green]C[-]           Cluster status and membership changes
+  [green]M[-]           Compact, defragment, alarms, hash check
+  [green]S[-]           Save snapshot
+  [green]T[-]           Leases: TTLs, keys, keep alive, revoke
+  [green]U[-]           Users, roles, permissions and auth on/off
 
 [cyan::b]Other[-:-:-]
   [green]p[-]           Switch profile
@@ -427,7 +393,7 @@ func (s *State) ShowHelp() {
 		AddItem(nil, 0, 1, false).
 		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
 			AddItem(nil, 0, 1, false).
-			AddItem(textView, 22, 1, true).
+			AddItem(textView, strings.Count(helpText, "\n")+3, 1, true).
 			AddItem(nil, 0, 1, false), 50, 1, true).
 		AddItem(nil, 0, 1, false)
 
@@ -0,0 +1,639 @@
+package general
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alex-dev-master/etcdtui/internal/config"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// rootName is the name of the user and role etcd requires before
+// authentication can be enabled
+const rootName = "root"
+
+// HandleAuth shows the users with their roles and the roles with their
+// permissions, and offers forms to change them and to toggle authentication.
+func (s *State) HandleAuth(ctx context.Context) {
+	if s.connManager.GetClient() == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	s.debugPanel.LogInfo("Opening users and roles")
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	authCtx, cancel := context.WithCancel(ctx)
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	header := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[gray]Loading users and roles...[-]")
+
+	usersTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	usersTable.SetBorder(true).
+		SetTitle(" Users ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+
+	rolesTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	rolesTable.SetBorder(true).
+		SetTitle(" Roles ").
+		SetTitleAlign(tview.AlignLeft)
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	textView.SetBorder(true).
+		SetTitleAlign(tview.AlignLeft)
+
+	const (
+		usersHint = "[green]n[-] new user  [green]x[-] delete  [green]P[-] password  [green]g[-] grant role  [green]R[-] revoke role  [green]c[-] check access  [green]A[-] auth on/off  [green]r[-] refresh  [green]Tab[-] roles  [green]ESC[-] close"
+		rolesHint = "[green]n[-] new role  [green]x[-] delete  [green]g[-] grant permission  [green]R[-] revoke permission  [green]c[-] check access  [green]A[-] auth on/off  [green]r[-] refresh  [green]Tab[-] users  [green]ESC[-] close"
+	)
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(usersHint)
+
+	tables := tview.NewFlex().
+		AddItem(usersTable, 0, 1, true).
+		AddItem(rolesTable, 0, 2, false)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(header, 1, 0, false).
+		AddItem(tables, 0, 1, true).
+		AddItem(textView, 10, 0, false).
+		AddItem(hint, 1, 0, false)
+
+	var (
+		users   []*client.User
+		roles   []*client.Role
+		enabled bool
+		loading bool
+		result  string // outcome of the last change
+
+		// focused is the table to return to from forms and dialogs
+		focused = usersTable
+	)
+
+	back := func() {
+		s.app.SetRoot(flex, true)
+		s.app.SetFocus(focused)
+	}
+
+	selectedUser := func() *client.User {
+		row, _ := usersTable.GetSelection()
+		if row < 1 || row > len(users) {
+			return nil
+		}
+		return users[row-1]
+	}
+
+	selectedRole := func() *client.Role {
+		row, _ := rolesTable.GetSelection()
+		if row < 1 || row > len(roles) {
+			return nil
+		}
+		return roles[row-1]
+	}
+
+	// render shows the details of the selected user or role, depending on
+	// which table has focus
+	render := func() {
+		var text string
+		if rolesTable.HasFocus() {
+			if r := selectedRole(); r != nil {
+				textView.SetTitle(" Role " + tview.Escape(r.Name) + " ")
+				text = formatRoleDetails(r, users)
+			}
+		} else if u := selectedUser(); u != nil {
+			textView.SetTitle(" User " + tview.Escape(u.Name) + " ")
+			text = formatUserDetails(u, roles)
+		}
+		if result != "" {
+			text = result + "\n\n" + text
+		}
+		textView.SetText(text)
+	}
+
+	// fill lists the users and roles
+	fill := func() {
+		status := "[red]disabled[-]"
+		if enabled {
+			status = "[green]enabled[-]"
+		}
+		identity := "[gray]none[-]"
+		if s.profile != nil && s.profile.HasAuth() {
+			identity = tview.Escape(s.profile.Username)
+		}
+		header.SetText(fmt.Sprintf(" [yellow]Authentication:[-] %s  [yellow]Connected as:[-] %s", status, identity))
+
+		usersTable.Clear()
+		for col, title := range []string{"Name", "Roles"} {
+			usersTable.SetCell(0, col, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+		for i, u := range users {
+			color := tcell.ColorWhite
+			if u.Name == rootName {
+				color = tcell.ColorAqua
+			}
+			usersTable.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(u.Name)).SetTextColor(color))
+			usersTable.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(strings.Join(u.Roles, ", "))).
+				SetTextColor(color).
+				SetExpansion(1))
+		}
+		usersTable.SetTitle(fmt.Sprintf(" Users (%d) ", len(users)))
+
+		rolesTable.Clear()
+		for col, title := range []string{"Name", "Permissions"} {
+			rolesTable.SetCell(0, col, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+		for i, r := range roles {
+			color := tcell.ColorWhite
+			perms := make([]string, 0, len(r.Permissions))
+			for _, p := range r.Permissions {
+				perms = append(perms, p.PermType.String()+" "+p.Range())
+			}
+			switch {
+			case r.Name == rootName:
+				color = tcell.ColorAqua
+				perms = []string{"all keys, all operations"}
+			case len(perms) == 0:
+				color = tcell.ColorGray
+			}
+			rolesTable.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(r.Name)).SetTextColor(color))
+			rolesTable.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(strings.Join(perms, "; "))).
+				SetTextColor(color).
+				SetExpansion(1))
+		}
+		rolesTable.SetTitle(fmt.Sprintf(" Roles (%d) ", len(roles)))
+	}
+
+	// load fetches the auth status, users and roles, keeping the selection
+	load := func() {
+		cli := s.connManager.GetClient()
+		if cli == nil || loading {
+			return
+		}
+		loading = true
+
+		var userName, roleName string
+		if u := selectedUser(); u != nil {
+			userName = u.Name
+		}
+		if r := selectedRole(); r != nil {
+			roleName = r.Name
+		}
+
+		go func() {
+			on, statusErr := cli.AuthEnabled(authCtx)
+			u, usersErr := cli.ListUsers(authCtx)
+			r, rolesErr := cli.ListRoles(authCtx)
+
+			s.app.QueueUpdateDraw(func() {
+				loading = false
+				if authCtx.Err() != nil {
+					return
+				}
+
+				enabled, users, roles = on, u, r
+				fill()
+
+				for _, err := range []error{statusErr, usersErr, rolesErr} {
+					if err != nil {
+						s.debugPanel.LogError("Failed to load users and roles: %v", err)
+						header.SetText(" [red]Failed to load users and roles:[white] " + tview.Escape(err.Error()))
+						break
+					}
+				}
+
+				usersTable.Select(1, 0)
+				for i, user := range users {
+					if user.Name == userName {
+						usersTable.Select(i+1, 0)
+					}
+				}
+				rolesTable.Select(1, 0)
+				for i, role := range roles {
+					if role.Name == roleName {
+						rolesTable.Select(i+1, 0)
+					}
+				}
+				render()
+			})
+		}()
+	}
+
+	// done records the outcome of a change and reloads
+	done := func(message string, err error) {
+		if err != nil {
+			s.debugPanel.LogError("Auth change failed: %v", err)
+			result = "[red]Failed:[white] " + tview.Escape(err.Error())
+		} else {
+			s.debugPanel.LogInfo("Auth change: %s", message)
+			result = "[green]" + tview.Escape(message) + "[-]"
+		}
+		render()
+		load()
+	}
+
+	// apply runs op against the client and reports message on success
+	apply := func(message string, op func(cli *client.Client) error) {
+		cli := s.connManager.GetClient()
+		if cli == nil {
+			done("", fmt.Errorf("not connected to etcd"))
+			return
+		}
+		go func() {
+			err := op(cli)
+			s.app.QueueUpdateDraw(func() {
+				done(message, err)
+			})
+		}()
+	}
+
+	// confirm asks before a change that cannot be undone from here
+	confirm := func(text, button string, onConfirm func()) {
+		modal := tview.NewModal().
+			SetText(text).
+			AddButtons([]string{button, "Cancel"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				back()
+				if buttonLabel == button {
+					onConfirm()
+				}
+			})
+		s.app.SetRoot(modal, true)
+	}
+
+	// toggleAuth checks whether authentication can safely be switched and
+	// asks for confirmation
+	toggleAuth := func() {
+		blockers, warnings := authToggleChecks(!enabled, users, s.profile)
+
+		action, button := "Enable authentication?", "Enable"
+		if enabled {
+			action, button = "Disable authentication?", "Disable"
+		}
+
+		if len(blockers) > 0 {
+			modal := tview.NewModal().
+				SetText("Authentication cannot be enabled yet:\n\n" + strings.Join(blockers, "\n\n")).
+				AddButtons([]string{"OK"}).
+				SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+					back()
+				})
+			s.app.SetRoot(modal, true)
+			return
+		}
+
+		text := action
+		if len(warnings) > 0 {
+			text += "\n\n" + strings.Join(warnings, "\n\n")
+		}
+		confirm(text, button, func() {
+			if enabled {
+				apply("Authentication disabled", func(cli *client.Client) error {
+					return cli.DisableAuth(authCtx)
+				})
+				return
+			}
+			apply("Authentication enabled", func(cli *client.Client) error {
+				return cli.EnableAuth(authCtx)
+			})
+		})
+	}
+
+	usersTable.SetSelectionChangedFunc(func(row, column int) {
+		render()
+	})
+	rolesTable.SetSelectionChangedFunc(func(row, column int) {
+		render()
+	})
+
+	usersTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab:
+			usersTable.SetBorderColor(tcell.ColorWhite)
+			rolesTable.SetBorderColor(tcell.ColorYellow)
+			hint.SetText(rolesHint)
+			focused = rolesTable
+			s.app.SetFocus(rolesTable)
+			render()
+			return nil
+		}
+
+		u := selectedUser()
+		switch event.Rune() {
+		case 'r':
+			load()
+		case 'A':
+			toggleAuth()
+		case 'c':
+			var name string
+			if u != nil {
+				name = u.Name
+			}
+			s.showAccessCheckForm(users, roles, name, back)
+		case 'n':
+			s.showNewUserForm(back, func(name, password string) {
+				back()
+				apply("Created user "+name, func(cli *client.Client) error {
+					return cli.CreateUser(authCtx, name, password)
+				})
+			})
+		case 'x':
+			if u == nil {
+				break
+			}
+			text := fmt.Sprintf("Delete user %q?", u.Name)
+			if s.profile != nil && s.profile.Username == u.Name {
+				text += "\n\nThe current profile connects as this user and loses access once authentication is enabled."
+			}
+			confirm(text, "Delete", func() {
+				apply("Deleted user "+u.Name, func(cli *client.Client) error {
+					return cli.DeleteUser(authCtx, u.Name)
+				})
+			})
+		case 'P':
+			if u == nil {
+				break
+			}
+			s.showPasswordForm(u.Name, back, func(password string) {
+				back()
+				apply("Changed password of "+u.Name, func(cli *client.Client) error {
+					return cli.ChangePassword(authCtx, u.Name, password)
+				})
+			})
+		case 'g':
+			if u == nil {
+				break
+			}
+			var available []string
+			for _, r := range roles {
+				if !contains(u.Roles, r.Name) {
+					available = append(available, r.Name)
+				}
+			}
+			if len(available) == 0 {
+				result = "[yellow]No roles left to grant; create one in the roles table[-]"
+				render()
+				break
+			}
+			s.showChoiceForm(" Grant Role to "+u.Name+" ", "Role", available, "Grant", back, func(role string) {
+				back()
+				apply(fmt.Sprintf("Granted role %s to %s", role, u.Name), func(cli *client.Client) error {
+					return cli.GrantRole(authCtx, u.Name, role)
+				})
+			})
+		case 'R':
+			if u == nil || len(u.Roles) == 0 {
+				break
+			}
+			s.showChoiceForm(" Revoke Role from "+u.Name+" ", "Role", u.Roles, "Revoke", back, func(role string) {
+				back()
+				apply(fmt.Sprintf("Revoked role %s from %s", role, u.Name), func(cli *client.Client) error {
+					return cli.RevokeRole(authCtx, u.Name, role)
+				})
+			})
+		default:
+			return event
+		}
+		return nil
+	})
+
+	rolesTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab:
+			rolesTable.SetBorderColor(tcell.ColorWhite)
+			usersTable.SetBorderColor(tcell.ColorYellow)
+			hint.SetText(usersHint)
+			focused = usersTable
+			s.app.SetFocus(usersTable)
+			render()
+			return nil
+		}
+
+		r := selectedRole()
+		switch event.Rune() {
+		case 'r':
+			load()
+		case 'A':
+			toggleAuth()
+		case 'c':
+			var name string
+			if r != nil {
+				if holders := roleHolders(r.Name, users); len(holders) > 0 {
+					name = holders[0]
+				}
+			}
+			s.showAccessCheckForm(users, roles, name, back)
+		case 'n':
+			s.showNewRoleForm(back, func(name string) {
+				back()
+				apply("Created role "+name, func(cli *client.Client) error {
+					return cli.CreateRole(authCtx, name)
+				})
+			})
+		case 'x':
+			if r == nil {
+				break
+			}
+			text := fmt.Sprintf("Delete role %q?", r.Name)
+			if holders := roleHolders(r.Name, users); len(holders) > 0 {
+				text += fmt.Sprintf("\n\nIt is revoked from %d user(s): %s", len(holders), strings.Join(holders, ", "))
+			}
+			confirm(text, "Delete", func() {
+				apply("Deleted role "+r.Name, func(cli *client.Client) error {
+					return cli.DeleteRole(authCtx, r.Name)
+				})
+			})
+		case 'g':
+			if r == nil {
+				break
+			}
+			s.showGrantPermissionForm(r.Name, back, func(perm *client.Permission) {
+				back()
+				apply(fmt.Sprintf("Granted %s %s to %s", perm.PermType, perm.Range(), r.Name), func(cli *client.Client) error {
+					return cli.GrantPermission(authCtx, r.Name, perm.Key, perm.RangeEnd, perm.PermType)
+				})
+			})
+		case 'R':
+			if r == nil || len(r.Permissions) == 0 {
+				break
+			}
+			options := make([]string, 0, len(r.Permissions))
+			for _, p := range r.Permissions {
+				options = append(options, p.PermType.String()+" "+p.Range())
+			}
+			s.showChoiceForm(" Revoke Permission from "+r.Name+" ", "Permission", options, "Revoke", back, func(option string) {
+				back()
+				for i, o := range options {
+					if o != option {
+						continue
+					}
+					perm := r.Permissions[i]
+					apply(fmt.Sprintf("Revoked %s from %s", perm.Range(), r.Name), func(cli *client.Client) error {
+						return cli.RevokePermission(authCtx, r.Name, perm.Key, perm.RangeEnd)
+					})
+					return
+				}
+			})
+		default:
+			return event
+		}
+		return nil
+	})
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			closeView()
+			return nil
+		}
+		return event
+	})
+
+	back()
+	load()
+}
+
+// authToggleChecks returns the problems that prevent switching
+// authentication to enable, and the risks of doing so
+func authToggleChecks(enable bool, users []*client.User, profile *config.Profile) (blockers, warnings []string) {
+	if !enable {
+		warnings = append(warnings, "Anyone who can reach the cluster gets full read and write access to every key.")
+		return blockers, warnings
+	}
+
+	var root, self *client.User
+	for _, u := range users {
+		if u.Name == rootName {
+			root = u
+		}
+		if profile != nil && u.Name == profile.Username {
+			self = u
+		}
+	}
+
+	switch {
+	case root == nil:
+		blockers = append(blockers, "etcd requires a user named root. Create it and grant it the root role first.")
+	case !contains(root.Roles, rootName):
+		blockers = append(blockers, "The root user does not have the root role. Grant it first.")
+	}
+
+	switch {
+	case profile == nil || !profile.HasAuth():
+		warnings = append(warnings, "The current profile connects without credentials. Every request is rejected once authentication is on; add the root user and password to the profile and reconnect.")
+	case self == nil:
+		warnings = append(warnings, fmt.Sprintf("The profile user %s does not exist, so this connection loses access.", profile.Username))
+	case !contains(self.Roles, rootName):
+		warnings = append(warnings, fmt.Sprintf("The profile user %s is not root; it only keeps access to the keys its roles allow, and cannot undo this.", profile.Username))
+	}
+
+	return blockers, warnings
+}
+
+// roleHolders returns the names of the users that have role
+func roleHolders(role string, users []*client.User) []string {
+	var holders []string
+	for _, u := range users {
+		if contains(u.Roles, role) {
+			holders = append(holders, u.Name)
+		}
+	}
+	return holders
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// formatUserDetails lists the roles of a user with the permissions they grant
+func formatUserDetails(u *client.User, roles []*client.Role) string {
+	var b strings.Builder
+
+	if len(u.Roles) == 0 {
+		b.WriteString("[gray]No roles; the user cannot access any keys[-]\n")
+	}
+
+	byName := make(map[string]*client.Role, len(roles))
+	for _, r := range roles {
+		byName[r.Name] = r
+	}
+
+	names := append([]string(nil), u.Roles...)
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "[yellow]%s[-]\n", tview.Escape(name))
+		if r, ok := byName[name]; ok {
+			b.WriteString(formatPermissions(r))
+		}
+	}
+
+	return b.String()
+}
+
+// formatRoleDetails lists the permissions of a role and who has it
+func formatRoleDetails(r *client.Role, users []*client.User) string {
+	var b strings.Builder
+
+	b.WriteString(formatPermissions(r))
+
+	holders := roleHolders(r.Name, users)
+	if len(holders) == 0 {
+		b.WriteString("[yellow]Users:[white] none\n")
+	} else {
+		fmt.Fprintf(&b, "[yellow]Users:[white] %s\n", tview.Escape(strings.Join(holders, ", ")))
+	}
+
+	return b.String()
+}
+
+// formatPermissions lists the key ranges of a role, one per line
+func formatPermissions(r *client.Role) string {
+	if r.Name == rootName {
+		return "  [aqua]all keys, all operations, user and role management[-]\n"
+	}
+	if len(r.Permissions) == 0 {
+		return "  [gray]no permissions[-]\n"
+	}
+
+	var b strings.Builder
+	for _, p := range r.Permissions {
+		fmt.Fprintf(&b, "  %-9s %s\n", p.PermType, tview.Escape(p.Range()))
+	}
+	return b.String()
+}
@@ -0,0 +1,321 @@
+package general
+
+import (
+	"fmt"
+	"strings"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/rivo/tview"
+)
+
+// Ranges a permission can cover, as offered by the grant permission form
+var permissionRanges = []string{"Single key", "Prefix", "From key", "Range"}
+
+// permissionTypes lists the permission types in the order of their values
+var permissionTypes = []string{
+	client.PermissionRead.String(),
+	client.PermissionWrite.String(),
+	client.PermissionReadWrite.String(),
+}
+
+// showAuthForm shows form centered over the auth view
+func (s *State) showAuthForm(form *tview.Form, title string, height int, back func()) {
+	form.AddButton("Cancel", back)
+	form.SetCancelFunc(back)
+	form.SetBorder(true).
+		SetTitle(title).
+		SetTitleAlign(tview.AlignLeft)
+
+	// Center the form window
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, height, 1, true).
+			AddItem(nil, 0, 1, false), 70, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+}
+
+// showNewUserForm asks for the name and password of a new user
+func (s *State) showNewUserForm(back func(), onCreate func(name, password string)) {
+	form := tview.NewForm()
+	form.AddInputField("Name", "", 40, nil, nil)
+	form.AddPasswordField("Password", "", 40, '*', nil)
+	form.AddPasswordField("Repeat password", "", 40, '*', nil)
+
+	form.AddButton("Create", func() {
+		name := strings.TrimSpace(form.GetFormItemByLabel("Name").(*tview.InputField).GetText())
+		password := form.GetFormItemByLabel("Password").(*tview.InputField).GetText()
+		switch {
+		case name == "":
+			form.SetTitle(" New User: [red]name is required[-] ")
+		case password != form.GetFormItemByLabel("Repeat password").(*tview.InputField).GetText():
+			form.SetTitle(" New User: [red]passwords do not match[-] ")
+		default:
+			onCreate(name, password)
+		}
+	})
+
+	s.showAuthForm(form, " New User (ESC cancel) ", 11, back)
+}
+
+// showPasswordForm asks for a new password for user
+func (s *State) showPasswordForm(user string, back func(), onSave func(password string)) {
+	form := tview.NewForm()
+	form.AddPasswordField("New password", "", 40, '*', nil)
+	form.AddPasswordField("Repeat password", "", 40, '*', nil)
+
+	title := fmt.Sprintf(" Change Password of %s (ESC cancel) ", tview.Escape(user))
+	form.AddButton("Save", func() {
+		password := form.GetFormItemByLabel("New password").(*tview.InputField).GetText()
+		switch {
+		case password == "":
+			form.SetTitle(" [red]Password is required[-] ")
+		case password != form.GetFormItemByLabel("Repeat password").(*tview.InputField).GetText():
+			form.SetTitle(" [red]Passwords do not match[-] ")
+		default:
+			onSave(password)
+		}
+	})
+
+	s.showAuthForm(form, title, 9, back)
+}
+
+// showNewRoleForm asks for the name of a new role
+func (s *State) showNewRoleForm(back func(), onCreate func(name string)) {
+	form := tview.NewForm()
+	form.AddInputField("Name", "", 40, nil, nil)
+
+	form.AddButton("Create", func() {
+		name := strings.TrimSpace(form.GetFormItemByLabel("Name").(*tview.InputField).GetText())
+		if name == "" {
+			form.SetTitle(" New Role: [red]name is required[-] ")
+			return
+		}
+		onCreate(name)
+	})
+
+	s.showAuthForm(form, " New Role (ESC cancel) ", 7, back)
+}
+
+// showChoiceForm asks to pick one of options
+func (s *State) showChoiceForm(title, label string, options []string, button string, back func(), onChoose func(option string)) {
+	escaped := make([]string, len(options))
+	for i, o := range options {
+		escaped[i] = tview.Escape(o)
+	}
+
+	form := tview.NewForm()
+	form.AddDropDown(label, escaped, 0, nil)
+
+	form.AddButton(button, func() {
+		index, _ := form.GetFormItem(0).(*tview.DropDown).GetCurrentOption()
+		if index >= 0 {
+			onChoose(options[index])
+		}
+	})
+
+	s.showAuthForm(form, tview.Escape(title), 7, back)
+}
+
+// showGrantPermissionForm asks for the key range and type of a permission.
+// The range end is computed for prefixes and keys onwards, and the keys the
+// permission covers are previewed while typing.
+func (s *State) showGrantPermissionForm(role string, back func(), onGrant func(perm *client.Permission)) {
+	form := tview.NewForm()
+	info := tview.NewTextView().SetDynamicColors(true)
+
+	// permission builds the permission from the current form values
+	permission := func() (*client.Permission, error) {
+		key, rangeEnd, err := formKeyRange(form)
+		if err != nil {
+			return nil, err
+		}
+		typeIndex, _ := form.GetFormItemByLabel("Type").(*tview.DropDown).GetCurrentOption()
+		return &client.Permission{Key: key, RangeEnd: rangeEnd, PermType: client.PermissionType(typeIndex)}, nil
+	}
+
+	describe := func() {
+		// The callbacks fire while the form is still being built
+		if form.GetFormItemCount() < 4 {
+			return
+		}
+		perm, err := permission()
+		if err != nil {
+			info.SetText("[red]" + tview.Escape(err.Error()) + "[-]")
+			return
+		}
+		text := fmt.Sprintf("[yellow]Grants:[white] %s on %s", perm.PermType, tview.Escape(perm.Range()))
+		if perm.RangeEnd != "" {
+			text += fmt.Sprintf("\n[yellow]Range end:[white] %s", tview.Escape(fmt.Sprintf("%q", perm.RangeEnd)))
+		}
+		info.SetText(text)
+	}
+
+	form.AddInputField("Key", "", 40, nil, func(string) { describe() })
+	form.AddDropDown("Covers", permissionRanges, 1, func(string, int) { describe() })
+	form.AddInputField("Range end", "", 40, nil, func(string) { describe() })
+	form.AddDropDown("Type", permissionTypes, int(client.PermissionRead), func(string, int) { describe() })
+	form.AddButton("Grant", func() {
+		if perm, err := permission(); err == nil {
+			onGrant(perm)
+		}
+	})
+	form.AddButton("Cancel", back)
+	form.SetCancelFunc(back)
+	describe()
+
+	content := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 11, 0, true).
+		AddItem(info, 0, 1, false)
+	content.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Grant Permission to %s (ESC to cancel) ", tview.Escape(role))).
+		SetTitleAlign(tview.AlignLeft)
+
+	// Center the form window
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(content, 15, 1, true).
+			AddItem(nil, 0, 1, false), 70, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+}
+
+// formKeyRange returns the key and range end selected by the Key, Covers
+// and Range end items of form
+func formKeyRange(form *tview.Form) (key, rangeEnd string, err error) {
+	key = form.GetFormItemByLabel("Key").(*tview.InputField).GetText()
+	rangeIndex, _ := form.GetFormItemByLabel("Covers").(*tview.DropDown).GetCurrentOption()
+
+	switch permissionRanges[rangeIndex] {
+	case "Prefix":
+		rangeEnd = client.PrefixRangeEnd(key)
+	case "From key":
+		rangeEnd = "\x00"
+	case "Range":
+		rangeEnd = form.GetFormItemByLabel("Range end").(*tview.InputField).GetText()
+		if rangeEnd <= key {
+			return "", "", fmt.Errorf("range end must sort after the key")
+		}
+	}
+	if key == "" && rangeEnd == "" {
+		return "", "", fmt.Errorf("key is required")
+	}
+	return key, rangeEnd, nil
+}
+
+// showAccessCheckForm evaluates whether a user may read and write a key
+// range with the loaded users and roles, updating the answer while typing
+func (s *State) showAccessCheckForm(users []*client.User, roles []*client.Role, selected string, back func()) {
+	if len(users) == 0 {
+		return
+	}
+
+	evaluator := client.NewEvaluator(users, roles)
+	names := make([]string, len(users))
+	current := 0
+	for i, u := range users {
+		names[i] = tview.Escape(u.Name)
+		if u.Name == selected {
+			current = i
+		}
+	}
+
+	form := tview.NewForm()
+	info := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+
+	evaluate := func() {
+		// The callbacks fire while the form is still being built
+		if form.GetFormItemCount() < 4 {
+			return
+		}
+		key, rangeEnd, err := formKeyRange(form)
+		if err != nil {
+			info.SetText("[red]" + tview.Escape(err.Error()) + "[-]")
+			return
+		}
+		userIndex, _ := form.GetFormItemByLabel("User").(*tview.DropDown).GetCurrentOption()
+		check, err := evaluator.Check(users[userIndex].Name, key, rangeEnd)
+		if err != nil {
+			info.SetText("[red]" + tview.Escape(err.Error()) + "[-]")
+			return
+		}
+		info.SetText(formatAccessCheck(check))
+	}
+
+	form.AddDropDown("User", names, current, func(string, int) { evaluate() })
+	form.AddInputField("Key", "", 40, nil, func(string) { evaluate() })
+	form.AddDropDown("Covers", permissionRanges, 0, func(string, int) { evaluate() })
+	form.AddInputField("Range end", "", 40, nil, func(string) { evaluate() })
+	form.AddButton("Close", back)
+	form.SetCancelFunc(back)
+	evaluate()
+
+	content := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 11, 0, true).
+		AddItem(info, 0, 1, false)
+	content.SetBorder(true).
+		SetTitle(" Check Access (ESC to close) ").
+		SetTitleAlign(tview.AlignLeft)
+
+	// Center the form window
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(content, 22, 1, true).
+			AddItem(nil, 0, 1, false), 80, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+}
+
+// formatAccessCheck shows the answer of an access check with the grants
+// behind it
+func formatAccessCheck(check *client.AccessCheck) string {
+	var b strings.Builder
+
+	color := "green"
+	switch {
+	case check.Access.Read == client.CoverageNone && check.Access.Write == client.CoverageNone:
+		color = "red"
+	case !check.Access.CanRead() || !check.Access.CanWrite():
+		color = "yellow"
+	}
+	fmt.Fprintf(&b, "[yellow]%s on %s:[-] [%s]%s[-]\n", tview.Escape(check.User), tview.Escape(check.Range()), color, check.Access)
+
+	for _, op := range []struct {
+		name     string
+		coverage client.Coverage
+		grants   []*client.Grant
+	}{
+		{"Read", check.Access.Read, check.ReadGrants},
+		{"Write", check.Access.Write, check.WriteGrants},
+	} {
+		switch op.coverage {
+		case client.CoverageFull:
+			fmt.Fprintf(&b, "\n[yellow]%s:[-] [green]allowed[-]\n", op.name)
+		case client.CoveragePartial:
+			fmt.Fprintf(&b, "\n[yellow]%s:[-] [yellow]only part of the range[-]\n", op.name)
+		default:
+			fmt.Fprintf(&b, "\n[yellow]%s:[-] [red]denied[-]\n", op.name)
+		}
+		for _, g := range op.grants {
+			fmt.Fprintf(&b, "  %s\n", tview.Escape(g.String()))
+		}
+	}
+
+	if len(check.MissingRoles) > 0 {
+		fmt.Fprintf(&b, "\n[red]Missing roles:[-] %s\n", tview.Escape(strings.Join(check.MissingRoles, ", ")))
+	}
+	return b.String()
+}
@@ -0,0 +1,344 @@
+package general
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// clusterRefreshInterval is how often the cluster view reloads the status
+const clusterRefreshInterval = 5 * time.Second
+
+// HandleCluster shows the cluster members with the status of each endpoint
+// and refreshes it periodically.
+func (s *State) HandleCluster(ctx context.Context) {
+	if s.connManager.GetClient() == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	s.debugPanel.LogInfo("Opening cluster status")
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	clusterCtx, cancel := context.WithCancel(ctx)
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(" Cluster ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+	table.SetCell(0, 0, tview.NewTableCell("[gray]Loading cluster status...[-]").SetSelectable(false))
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	textView.SetBorder(true).
+		SetTitle(" Member ").
+		SetTitleAlign(tview.AlignLeft)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf("[green]a[-] add  [green]x[-] remove  [green]P[-] promote  [green]m[-] move leader  [green]r[-] refresh (auto every %s)  [green]Tab[-] scroll details  [green]ESC[-] close", clusterRefreshInterval))
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(textView, 10, 0, false).
+		AddItem(hint, 1, 0, false)
+
+	var (
+		status  *client.ClusterStatus
+		loading bool
+		updated time.Time
+		result  string // outcome of the last membership change
+	)
+
+	selected := func() *client.MemberStatus {
+		if status == nil {
+			return nil
+		}
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(status.Members) {
+			return nil
+		}
+		return status.Members[row-1]
+	}
+
+	// render shows the details of the selected member
+	render := func() {
+		m := selected()
+		if m == nil {
+			textView.SetText("")
+			return
+		}
+		textView.SetTitle(" Member " + tview.Escape(memberName(m)) + " ")
+		text := formatMemberDetails(m)
+		if result != "" {
+			text = result + "\n\n" + text
+		}
+		textView.SetText(text)
+	}
+
+	// fill lists the members with their endpoint status
+	fill := func() {
+		table.Clear()
+		for col, title := range []string{"Name", "ID", "Endpoint", "Role", "Version", "DB size", "In use", "Term", "Index", "Applied", "Errors"} {
+			table.SetCell(0, col, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+
+		for i, m := range status.Members {
+			row := i + 1
+			color := tcell.ColorWhite
+			switch m.Role() {
+			case "leader":
+				color = tcell.ColorGreen
+			case "learner":
+				color = tcell.ColorAqua
+			case "unstarted":
+				color = tcell.ColorGray
+			case "unreachable":
+				color = tcell.ColorRed
+			}
+
+			cells := []string{tview.Escape(memberName(m)), fmt.Sprintf("%x", m.ID), tview.Escape(m.Endpoint), m.Role()}
+			if st := m.Status; st != nil && st.Err == nil {
+				errorsText := "-"
+				if len(st.Errors) > 0 {
+					errorsText = tview.Escape(strings.Join(st.Errors, "; "))
+					color = tcell.ColorOrange
+				}
+				cells = append(cells,
+					st.Version,
+					client.FormatBytes(st.DBSize),
+					client.FormatBytes(st.DBSizeInUse),
+					fmt.Sprintf("%d", st.RaftTerm),
+					fmt.Sprintf("%d", st.RaftIndex),
+					fmt.Sprintf("%d", st.RaftAppliedIndex),
+					errorsText,
+				)
+			}
+
+			for col, text := range cells {
+				cell := tview.NewTableCell(text).SetTextColor(color)
+				if col >= 5 && col <= 9 {
+					cell.SetAlign(tview.AlignRight)
+				}
+				if col == 10 {
+					cell.SetExpansion(1)
+				}
+				table.SetCell(row, col, cell)
+			}
+		}
+
+		health := "[green]healthy[-]"
+		if !status.IsHealthy {
+			health = "[red]unhealthy[-]"
+		}
+		leader := status.Leader
+		if status.LeaderID == 0 {
+			leader = "none"
+		} else if leader == "" {
+			leader = fmt.Sprintf("%x", status.LeaderID)
+		}
+		table.SetTitle(fmt.Sprintf(" Cluster %x: %d members, leader %s, %s (updated %s) ",
+			status.ClusterID, len(status.Members), tview.Escape(leader), health, updated.Format("15:04:05")))
+	}
+
+	// load fetches the cluster status and keeps the selected member selected
+	load := func() {
+		cli := s.connManager.GetClient()
+		if cli == nil || loading {
+			return
+		}
+		loading = true
+
+		var selectedID uint64
+		if m := selected(); m != nil {
+			selectedID = m.ID
+		}
+
+		go func() {
+			st, err := cli.GetClusterStatus(clusterCtx)
+
+			s.app.QueueUpdateDraw(func() {
+				loading = false
+				if clusterCtx.Err() != nil {
+					return
+				}
+				if err != nil {
+					s.debugPanel.LogError("Failed to get cluster status: %v", err)
+					if status == nil {
+						table.SetCell(0, 0, tview.NewTableCell("[red]Failed to get cluster status:[white] "+tview.Escape(err.Error())).
+							SetSelectable(false))
+					} else {
+						table.SetTitle(" Cluster: [red]refresh failed:[white] " + tview.Escape(err.Error()) + " ")
+					}
+					return
+				}
+
+				status = st
+				updated = time.Now()
+				fill()
+
+				table.Select(1, 0)
+				for i, m := range status.Members {
+					if m.ID == selectedID {
+						table.Select(i+1, 0)
+					}
+				}
+				render()
+			})
+		}()
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		render()
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab:
+			s.app.SetFocus(textView)
+			return nil
+		}
+
+		// change previews a membership change and applies it when confirmed
+		change := func(c *memberChange) {
+			s.confirmMemberChange(clusterCtx, flex, c, func(message string, err error) {
+				if err != nil {
+					result = "[red]Failed:[white] " + tview.Escape(err.Error())
+				} else {
+					result = message
+				}
+				s.app.SetFocus(table)
+				render()
+				load()
+			})
+		}
+
+		m := selected()
+		switch event.Rune() {
+		case 'r':
+			load()
+			return nil
+		case 'a':
+			if status != nil {
+				s.showAddMemberForm(flex, status, change)
+			}
+			return nil
+		case 'x':
+			if m != nil {
+				change(&memberChange{action: memberRemove, status: status, target: m})
+			}
+			return nil
+		case 'P':
+			switch {
+			case m == nil:
+			case !m.IsLearner:
+				result = "[yellow]Only learners can be promoted[-]"
+				render()
+			default:
+				change(&memberChange{action: memberPromote, status: status, target: m})
+			}
+			return nil
+		case 'm':
+			switch {
+			case m == nil:
+			case m.IsLeader || m.IsLearner || !m.Started():
+				result = "[yellow]Select a started voting member that is not the leader[-]"
+				render()
+			default:
+				change(&memberChange{action: memberMoveLeader, status: status, target: m})
+			}
+			return nil
+		}
+		return event
+	})
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab:
+			s.app.SetFocus(table)
+			return nil
+		}
+		return event
+	})
+
+	s.app.SetRoot(flex, true)
+	load()
+
+	go func() {
+		ticker := time.NewTicker(clusterRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-clusterCtx.Done():
+				return
+			case <-ticker.C:
+				s.app.QueueUpdateDraw(load)
+			}
+		}
+	}()
+}
+
+// memberName returns the member's name or a placeholder for members that
+// have not started yet
+func memberName(m *client.MemberStatus) string {
+	if m.Name == "" {
+		return "(unstarted)"
+	}
+	return m.Name
+}
+
+// formatMemberDetails describes a member and its endpoint status
+func formatMemberDetails(m *client.MemberStatus) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "[yellow]ID:[white] %x  [yellow]Role:[white] %s\n", m.ID, m.Role())
+	fmt.Fprintf(&b, "[yellow]Client URLs:[white] %s\n", tview.Escape(strings.Join(m.ClientURLs, ", ")))
+	fmt.Fprintf(&b, "[yellow]Peer URLs:[white] %s\n", tview.Escape(strings.Join(m.PeerURLs, ", ")))
+
+	st := m.Status
+	switch {
+	case st == nil:
+		b.WriteString("[gray]Member has not started; no endpoint to query[-]\n")
+	case st.Err != nil:
+		fmt.Fprintf(&b, "[red]Unreachable:[white] %s\n", tview.Escape(st.Err.Error()))
+	default:
+		fmt.Fprintf(&b, "[yellow]Leader seen:[white] %x  [yellow]Learner:[white] %t\n", st.LeaderID, st.IsLearner)
+		fmt.Fprintf(&b, "[yellow]DB size:[white] %s  [yellow]In use:[white] %s", client.FormatBytes(st.DBSize), client.FormatBytes(st.DBSizeInUse))
+		if st.DBSizeQuota > 0 {
+			fmt.Fprintf(&b, "  [yellow]Quota:[white] %s", client.FormatBytes(st.DBSizeQuota))
+		}
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "[yellow]Raft term:[white] %d  [yellow]Index:[white] %d  [yellow]Applied:[white] %d\n", st.RaftTerm, st.RaftIndex, st.RaftAppliedIndex)
+		for _, e := range st.Errors {
+			fmt.Fprintf(&b, "[red]Error:[white] %s\n", tview.Escape(e))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,261 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alex-dev-master/etcdtui/internal/diff"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// saveKey writes value to key if the key is still at the revision of
+// expected, or still missing if expected is nil. original is the key as
+// editing started, nil for a new key, and form the editor to return to
+// from the conflict, validation and confirmation views. opts relaxes the
+// checks the user already agreed to skip.
+func (s *State) saveKey(ctx context.Context, key, value string, original, expected *client.KeyValue, form tview.Primitive, opts WriteOptions) {
+	closeForm := func() {
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	var modRevision, leaseID int64
+	if expected != nil {
+		modRevision, leaseID = expected.ModRevision, expected.Lease
+	}
+
+	saved, current, err := s.PutKey(ctx, key, value, modRevision, leaseID, opts)
+	var protectionErr *client.ProtectionError
+	if errors.As(err, &protectionErr) && errors.Is(err, client.ErrNotConfirmed) {
+		s.confirmProtected(protectionErr, func() {
+			s.app.SetRoot(form, true)
+		}, func() {
+			opts.Confirmed = true
+			s.saveKey(ctx, key, value, original, expected, form, opts)
+		})
+		return
+	}
+	var validationErr *client.ValidationError
+	if errors.As(err, &validationErr) {
+		s.debugPanel.LogWarn("Save of %s blocked: %v", key, err)
+		s.showValidationFailure(key, value, validationErr, form, func() {
+			s.debugPanel.LogWarn("Saving %s without validation", key)
+			opts.SkipValidation = true
+			s.saveKey(ctx, key, value, original, expected, form, opts)
+		})
+		return
+	}
+	if err != nil {
+		s.SetStatusBarText("[red]Failed to save:[white] " + err.Error())
+		s.debugPanel.LogError("Failed to save key '%s': %v", key, err)
+		closeForm()
+		return
+	}
+	if !saved {
+		s.debugPanel.LogWarn("Save of %s rejected: modified after revision %d", key, modRevision)
+		s.showEditConflict(ctx, key, value, original, current, form)
+		return
+	}
+
+	s.debugPanel.LogInfo("Successfully saved key: %s", key)
+
+	// Refresh details for the updated key
+	if err := s.RefreshKeyDetails(ctx, key); err != nil {
+		s.SetStatusBarText("[yellow]Saved but failed to refresh details:[white] " + err.Error())
+		s.debugPanel.LogWarn("Saved but failed to refresh details: %v", err)
+	} else {
+		s.SetStatusBarText("[green]Saved:[white] " + key)
+	}
+
+	closeForm()
+}
+
+// showEditConflict shows the value editing started from, the edited value
+// and the value now stored side by side, and lets the user overwrite, merge
+// or reload. current is nil if the key was deleted.
+func (s *State) showEditConflict(ctx context.Context, key, mine string, original, current *client.KeyValue, form tview.Primitive) {
+	var originalText, currentText string
+	if original != nil {
+		originalText = details.DiffText(original.Value)
+	}
+	if current != nil {
+		currentText = details.DiffText(current.Value)
+	}
+
+	escapedKey := details.EscapeText(key)
+	var message string
+	switch {
+	case original == nil:
+		message = fmt.Sprintf("[yellow]%s already exists[-] (modified at revision %d); saving would replace its value.", escapedKey, current.ModRevision)
+	case current == nil:
+		message = fmt.Sprintf("[yellow]%s was deleted[-] after you started editing at revision %d.", escapedKey, original.ModRevision)
+	default:
+		message = fmt.Sprintf("[yellow]%s was changed[-] at revision %d after you started editing at revision %d.", escapedKey, current.ModRevision, original.ModRevision)
+	}
+	header := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(message)
+
+	newPane := func(title string) *tview.TextView {
+		pane := tview.NewTextView().
+			SetDynamicColors(true).
+			SetScrollable(true)
+		pane.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft)
+		return pane
+	}
+	originalTitle, currentTitle := " Original (new key) ", " Current (deleted) "
+	if original != nil {
+		originalTitle = fmt.Sprintf(" Original (revision %d) ", original.ModRevision)
+	}
+	if current != nil {
+		currentTitle = fmt.Sprintf(" Current (revision %d) ", current.ModRevision)
+	}
+	originalView := newPane(originalTitle)
+	mineView := newPane(" Mine ")
+	currentView := newPane(currentTitle)
+	panes := []*tview.TextView{originalView, mineView, currentView}
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]o[-] overwrite  [green]m[-] merge  [green]r[-] reload  [green]d[-] values/diffs  [green]Tab[-] next pane  [green]ESC[-] back to editing")
+
+	columns := tview.NewFlex().
+		AddItem(originalView, 0, 1, false).
+		AddItem(mineView, 0, 1, true).
+		AddItem(currentView, 0, 1, false)
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(header, 2, 0, false).
+		AddItem(columns, 0, 1, true).
+		AddItem(hint, 1, 0, false)
+	flex.SetBorder(true).
+		SetTitle(" Conflict: " + escapedKey + " ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorRed)
+
+	// render shows the values, or the changes of mine and current against
+	// the original
+	showDiff := false
+	render := func() {
+		originalView.SetText(details.EscapeText(originalText))
+		if !showDiff {
+			mineView.SetText(details.EscapeText(mine))
+			currentView.SetText(details.EscapeText(currentText))
+			return
+		}
+		for _, side := range []struct {
+			view       *tview.TextView
+			name, text string
+		}{
+			{mineView, "mine", mine},
+			{currentView, "current", currentText},
+		} {
+			unified := diff.Unified("original", side.name, originalText, side.text)
+			if unified == "" {
+				side.view.SetText("[gray]Same as the original[-]")
+				continue
+			}
+			side.view.SetText(details.RenderDiff(unified))
+		}
+	}
+	render()
+
+	closeView := func() {
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	focused := 1
+	flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			s.app.SetRoot(form, true)
+			return nil
+		case tcell.KeyTab:
+			focused = (focused + 1) % len(panes)
+			s.app.SetFocus(panes[focused])
+			return nil
+		case tcell.KeyBacktab:
+			focused = (focused + len(panes) - 1) % len(panes)
+			s.app.SetFocus(panes[focused])
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'd':
+			showDiff = !showDiff
+			render()
+			return nil
+		case 'o':
+			s.confirmOverwrite(ctx, key, mine, original, current, flex, form)
+			return nil
+		case 'm':
+			if current == nil || current.IsBinary() {
+				hint.SetText("[yellow]Nothing to merge with;[-] [green]o[-] overwrite  [green]r[-] reload  [green]ESC[-] back to editing")
+				return nil
+			}
+			merged, conflicts := diff.Merge(originalText, mine, currentText, "mine", fmt.Sprintf("current (revision %d)", current.ModRevision))
+			s.debugPanel.LogInfo("Merged edit of %s with revision %d: %d conflicts", key, current.ModRevision, conflicts)
+			s.showEditForm(ctx, current, merged)
+			if conflicts > 0 {
+				s.SetStatusBarText(fmt.Sprintf("[yellow]Merged with %d conflicts:[white] resolve the marked lines, then save", conflicts))
+			} else {
+				s.SetStatusBarText("[green]Merged without conflicts:[white] review and save")
+			}
+			return nil
+		case 'r':
+			s.debugPanel.LogInfo("Discarded edit of %s", key)
+			switch {
+			case current == nil:
+				closeView()
+				if err := s.RefreshKeys(ctx); err != nil {
+					s.debugPanel.LogWarn("Failed to refresh keys: %v", err)
+				}
+				s.SetStatusBarText("[yellow]Edit discarded:[white] " + key + " was deleted")
+			case current.IsBinary():
+				closeView()
+				if err := s.RefreshKeyDetails(ctx, key); err != nil {
+					s.debugPanel.LogWarn("Failed to refresh details: %v", err)
+				}
+				s.SetStatusBarText("[yellow]Edit discarded:[white] the current value is binary")
+			default:
+				s.showEditForm(ctx, current, string(current.Value))
+				s.SetStatusBarText(fmt.Sprintf("[yellow]Reloaded %s at revision %d;[white] your edit was discarded", key, current.ModRevision))
+			}
+			return nil
+		}
+		return event
+	})
+
+	s.SetStatusBarText("[red]Not saved:[white] " + key + " was modified by someone else")
+	s.app.SetRoot(flex, true)
+	s.app.SetFocus(mineView)
+}
+
+// confirmOverwrite asks before replacing the current value with mine. The
+// write is conditional on the key still being as shown in the conflict
+// view, so a further change opens the view again.
+func (s *State) confirmOverwrite(ctx context.Context, key, mine string, original, current *client.KeyValue, conflictView, form tview.Primitive) {
+	text := fmt.Sprintf("Recreate deleted key %s with your value?", key)
+	if current != nil {
+		text = fmt.Sprintf("Replace the value of %s at revision %d with your value?", key, current.ModRevision)
+	}
+
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{"Overwrite", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel != "Overwrite" {
+				s.app.SetRoot(conflictView, true)
+				return
+			}
+			s.debugPanel.LogInfo("Overwriting %s", key)
+			s.saveKey(ctx, key, mine, original, current, form, WriteOptions{})
+		})
+
+	s.app.SetRoot(modal, true)
+}
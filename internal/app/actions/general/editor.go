@@ -0,0 +1,201 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/alex-dev-master/etcdtui/internal/diff"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// defaultEditor is run when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// HandleExternalEdit opens the value of the selected key in $VISUAL or
+// $EDITOR.
+func (s *State) HandleExternalEdit(ctx context.Context) {
+	kv := s.GetCurrentKey()
+	if kv == nil {
+		s.SetStatusBarText("[yellow]No key selected")
+		return
+	}
+
+	if kv.IsBinary() {
+		s.SetStatusBarText("[yellow]Binary values cannot be edited as text")
+		s.debugPanel.LogWarn("Edit refused for binary key: %s", kv.Key)
+		return
+	}
+
+	if !s.checkWritable(ctx, kv.Key) || !s.checkProtection(kv.Key, false) {
+		return
+	}
+
+	s.editExternally(ctx, kv, string(kv.Value))
+}
+
+// editExternally edits value in the external editor, checks that it is
+// still valid in the format detected for the key's value and shows the
+// change before saving it to the key read as base.
+func (s *State) editExternally(ctx context.Context, base *client.KeyValue, value string) {
+	closeView := func() {
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	// The file extension lets the editor highlight the value. Decoders
+	// registered without extensions edit plain text.
+	format := client.DetectValueFormat(base.Key, base.Value)
+	ext := ".txt"
+	if exts := format.Extensions(); len(exts) > 0 {
+		ext = exts[0]
+	}
+	editor := editorCommand()
+	s.debugPanel.LogInfo("Editing %s in %s as %s", base.Key, editor, format.Name())
+
+	edited, err := s.runEditor(editor, value, ext)
+	if err != nil {
+		s.SetStatusBarText("[red]Editor failed:[white] " + err.Error())
+		s.debugPanel.LogError("Editor failed for %s: %v", base.Key, err)
+		closeView()
+		return
+	}
+
+	// Editors end files with a newline the value did not have
+	if !strings.HasSuffix(value, "\n") {
+		edited = strings.TrimSuffix(edited, "\n")
+	}
+	if edited == string(base.Value) {
+		s.SetStatusBarText("[yellow]No changes:[white] " + base.Key)
+		closeView()
+		return
+	}
+
+	s.SetEditMode(true)
+	if _, err := format.Pretty([]byte(edited)); err != nil {
+		s.debugPanel.LogWarn("Edited value of %s is not valid %s: %v", base.Key, format.Name(), err)
+		s.showInvalidEdit(ctx, base, edited, format.Name(), err)
+		return
+	}
+	s.showEditPreview(ctx, base, edited)
+}
+
+// runEditor writes value to a temporary file with extension ext, runs the
+// editor on it with the application suspended and returns the file's
+// content afterwards
+func (s *State) runEditor(editor, value, ext string) (string, error) {
+	f, err := os.CreateTemp("", "etcdtui-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := f.WriteString(value); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// The editor may be a command with arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	var runErr error
+	if !s.app.Suspend(func() { runErr = cmd.Run() }) {
+		return "", errors.New("the application cannot be suspended")
+	}
+	if runErr != nil {
+		return "", fmt.Errorf("%s: %w", editor, runErr)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	return string(data), nil
+}
+
+// showInvalidEdit reports a value that no longer parses and offers to edit
+// it again, save it anyway or discard it
+func (s *State) showInvalidEdit(ctx context.Context, base *client.KeyValue, edited, format string, err error) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("The edited value is not valid %s:\n\n%v", strings.ToUpper(format), tview.Escape(err.Error()))).
+		AddButtons([]string{"Edit again", "Save anyway", "Discard"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			switch buttonLabel {
+			case "Edit again":
+				s.editExternally(ctx, base, edited)
+			case "Save anyway":
+				s.showEditPreview(ctx, base, edited)
+			default:
+				s.SetEditMode(false)
+				s.app.SetRoot(s.rootFlex, true)
+				s.SetStatusBarText("[yellow]Edit discarded:[white] " + base.Key)
+			}
+		})
+
+	s.app.SetRoot(modal, true)
+}
+
+// showEditPreview shows the change an edit makes before saving it through
+// the same conditional save as the edit form
+func (s *State) showEditPreview(ctx context.Context, base *client.KeyValue, edited string) {
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(details.RenderDiff(diff.Unified(
+			fmt.Sprintf("%s@%d", base.Key, base.ModRevision), base.Key+" (edited)",
+			string(base.Value), edited)))
+	textView.SetBorder(true).
+		SetTitle(" Save changes to " + details.EscapeText(base.Key) + "? ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]s[-] save  [green]e[-] edit again  [green]↑/↓[-] scroll  [green]ESC[-] discard")
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(textView, 0, 1, true).
+		AddItem(hint, 1, 0, false)
+
+	flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			s.SetEditMode(false)
+			s.app.SetRoot(s.rootFlex, true)
+			s.SetStatusBarText("[yellow]Edit discarded:[white] " + base.Key)
+			return nil
+		}
+		switch event.Rune() {
+		case 's':
+			s.saveKey(ctx, base.Key, edited, base, base, flex, WriteOptions{})
+			return nil
+		case 'e':
+			s.editExternally(ctx, base, edited)
+			return nil
+		}
+		return event
+	})
+
+	s.app.SetRoot(flex, true)
+}
+
+// editorCommand returns the editor to run: $VISUAL, $EDITOR or vi
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return defaultEditor
+}
@@ -2,8 +2,11 @@ package general
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/keys"
 	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
 	"github.com/rivo/tview"
 )
@@ -17,10 +20,20 @@ func (s *State) InitConnection(ctx context.Context) error {
 	// Connect using profile if available, otherwise use default
 	var err error
 	if s.profile != nil {
-		cfg := s.profile.ToClientConfig()
-		err = s.connManager.Connect(cfg)
+		var cfg *client.Config
+		cfg, err = s.profile.ToClientConfig()
+		if err == nil {
+			err = s.connManager.Connect(cfg)
+		}
 		if err == nil {
 			s.debugPanel.LogInfo("Connected using profile: %s", s.profile.Name)
+			if s.saveProfile && s.configManager != nil {
+				if err := s.configManager.SaveNewProfile(s.profile); err != nil {
+					s.debugPanel.LogError("Failed to save profile %s: %v", s.profile.Name, err)
+				} else {
+					s.debugPanel.LogInfo("Saved profile: %s", s.profile.Name)
+				}
+			}
 		}
 	} else {
 		err = s.connManager.ConnectDefault()
@@ -31,78 +44,325 @@ func (s *State) InitConnection(ctx context.Context) error {
 		return nil
 	}
 
+	if err := s.connManager.GetIdentityError(); err != nil {
+		s.debugPanel.LogWarn("Failed to read permissions, probing keys instead: %v", err)
+	}
+
+	// Mark subtrees the user cannot write and protected ones; set before
+	// the first load
+	s.keysPanel.SetAccessFunc(s.treeAccess)
+	s.keysPanel.SetProtectionFunc(s.treeProtection)
+
 	if err := s.seedingKeysData(ctx); err != nil {
 		return err
 	}
 
-	// Enter key - toggle expand/collapse
+	// Enter key - expand/collapse, fetching children on first expansion
 	s.keysPanel.GetTree().SetSelectedFunc(func(node *tview.TreeNode) {
-		children := node.GetChildren()
-		if len(children) > 0 {
-			node.SetExpanded(!node.IsExpanded())
-		}
+		s.expandNode(ctx, node)
 	})
 
 	// Navigation (arrow keys) - show details when moving to a node
 	s.keysPanel.GetTree().SetChangedFunc(func(node *tview.TreeNode) {
-		reference := node.GetReference()
-		if reference != nil {
-			if kv, ok := reference.(*client.KeyValue); ok {
-				s.showKeyDetails(ctx, kv)
-			}
-		} else {
-			// Clear details for directory-only nodes
-			s.detailsPanel.SetText("[yellow]Directory[white]\n\nSelect a key to view details")
-			s.detailsPanel.HideButtons()
-			s.currentKey = nil
-		}
+		s.showNodeDetails(ctx, node)
 	})
 
 	return nil
 }
 
-// seedingKeysData loads keys from etcd into the keys panel.
-func (s *State) seedingKeysData(ctx context.Context) error {
-	kvs, err := s.connManager.GetClient().List(ctx, "")
-	if err != nil {
-		return err
+// showNodeDetails shows the details of the selected tree node.
+func (s *State) showNodeDetails(ctx context.Context, node *tview.TreeNode) {
+	n := keys.GetNode(node)
+	switch {
+	case n != nil && n.KV != nil:
+		// Tree nodes carry keys only; show what the node knows and fetch the
+		// value in the background, so moving the cursor never waits on etcd
+		s.showKeyLoading(n.KV)
+		if err := s.RefreshKeyDetails(ctx, n.KV.Key); err != nil {
+			s.clearKeyDetails(fmt.Sprintf("[red]Failed to load key:[white] %s", details.EscapeText(err.Error())))
+		}
+	case n != nil && n.IsDir():
+		s.clearKeyDetails(fmt.Sprintf("[yellow]Directory:[white] %s\n\n[yellow]Keys:[white] %d\n\nPress [green]Enter[white] to expand, [green]X[white] to export, [green]I[white] to import", details.EscapeText(n.Prefix), n.Count))
+	default:
+		// Clear details for directory-only nodes
+		s.clearKeyDetails("[yellow]Directory[white]\n\nSelect a key to view details")
 	}
+}
 
-	if err = s.keysPanel.LoadKeys(ctx, kvs); err != nil {
-		s.SetStatusBarText(fmt.Sprintf("[yellow]Connected but failed to load keys:[white] %v", err))
-	} else {
-		s.updateStatusBar(ctx)
+// clearKeyDetails shows text instead of key details. Details of a key still
+// being fetched are dropped when they arrive.
+func (s *State) clearKeyDetails(text string) {
+	s.detailsID++
+	s.currentKey = nil
+	s.detailsPanel.SetText(text)
+	s.detailsPanel.HideButtons()
+}
+
+// showKeyLoading shows what a tree node knows of a key while its value is
+// fetched. Editing waits for the value, so no key is current meanwhile.
+func (s *State) showKeyLoading(kv *client.KeyValue) {
+	s.currentKey = nil
+
+	detailsText := fmt.Sprintf("[yellow]Key:[white] %s\n\n[gray]Loading value...[-]\n\n", details.EscapeText(kv.Key))
+	if kv.ModRevision > 0 {
+		detailsText += fmt.Sprintf("[yellow]Create Revision:[white] %d\n", kv.CreateRevision)
+		detailsText += fmt.Sprintf("[yellow]Mod Revision:[white] %d\n", kv.ModRevision)
+		detailsText += fmt.Sprintf("[yellow]Version:[white] %d\n", kv.Version)
+	}
+
+	s.detailsPanel.SetText(detailsText)
+	s.detailsPanel.HideButtons()
+}
+
+// seedingKeysData loads the top level of the key tree in the background.
+// Directories that were expanded before are fetched again so a refresh keeps
+// the tree as the user left it; deeper levels are loaded on demand.
+func (s *State) seedingKeysData(ctx context.Context) error {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return fmt.Errorf("not connected to etcd")
 	}
+
+	expanded := s.keysPanel.ExpandedPrefixes()
+	selected := s.keysPanel.SelectedPath()
+	firstLoad := len(expanded) <= 1
+
+	loadCtx, loadID := s.beginLoad(ctx)
+
+	s.SetStatusBarText("[yellow]Loading keys...[-] | [green::b]ESC[-::-] Cancel")
+
+	go func() {
+		levels := make(map[string][]*client.Child)
+		root, revision, err := s.listChildren(loadCtx, cli, "", 0)
+		if err == nil {
+			levels[""] = root
+
+			// A keyspace with a single top-level directory (usually "/")
+			// is opened right away
+			if firstLoad && len(root) == 1 && root[0].IsDir() {
+				expanded = append(expanded, root[0].Prefix)
+			}
+
+			for _, prefix := range expanded {
+				if _, ok := levels[prefix]; ok {
+					continue
+				}
+				children, _, childErr := s.listChildren(loadCtx, cli, prefix, revision)
+				if childErr != nil {
+					// The directory may be gone; it simply stays collapsed
+					continue
+				}
+				levels[prefix] = children
+			}
+		}
+
+		s.app.QueueUpdateDraw(func() {
+			if s.loadID != loadID {
+				return
+			}
+			s.CancelLoading()
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				s.SetStatusBarText("[yellow]Loading cancelled[-] | [green::b]r[-::-] Reload")
+			case err != nil:
+				s.debugPanel.LogError("Failed to load keys: %v", err)
+				s.SetStatusBarText(fmt.Sprintf("[yellow]Connected but failed to load keys:[white] %v", err))
+			default:
+				s.debugPanel.LogInfo("Loaded %d top-level entries at revision %d", len(root), revision)
+				s.keysPanel.LoadTree("", levels, revision)
+				s.keysPanel.Select(selected)
+				if s.liveEnabled {
+					s.startLive(ctx, "", revision)
+				}
+				s.updateStatusBar(ctx)
+			}
+		})
+	}()
+
 	return nil
 }
 
+// expandNode toggles a directory node, fetching its children the first
+// time it is opened.
+func (s *State) expandNode(ctx context.Context, node *tview.TreeNode) {
+	n := keys.GetNode(node)
+	if n == nil || !n.IsDir() {
+		return
+	}
+	if n.Loaded {
+		s.keysPanel.Toggle(node)
+		return
+	}
+
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return
+	}
+
+	s.keysPanel.SetLoading(node)
+	go func() {
+		children, revision, err := s.listChildren(ctx, cli, n.Prefix, 0)
+		s.app.QueueUpdateDraw(func() {
+			if err != nil {
+				s.keysPanel.ResetLabel(node)
+				s.SetStatusBarText(fmt.Sprintf("[red]Failed to load %s:[white] %v", n.Prefix, err))
+				s.debugPanel.LogError("Failed to load children of %s: %v", n.Prefix, err)
+				return
+			}
+			s.keysPanel.SetChildren(node, children, revision)
+		})
+	}()
+}
+
+// loadKeys lists keys with the given prefix page by page in the background.
+// Only key names and metadata are fetched; values are read when a key is
+// selected. Progress is shown in the status bar and the load can be cancelled
+// with CancelLoading. onDone runs on the UI goroutine with the keys loaded so
+// far and whether the listing completed.
+func (s *State) loadKeys(ctx context.Context, prefix string, onDone func(kvs []*client.KeyValue, complete bool)) {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return
+	}
+
+	loadCtx, loadID := s.beginLoad(ctx)
+
+	s.SetStatusBarText("[yellow]Loading keys...[-] | [green::b]ESC[-::-] Cancel")
+	s.debugPanel.LogInfo("Loading keys with prefix '%s'", prefix)
+
+	go func() {
+		kvs, revision, err := cli.ListAll(loadCtx, client.ListOptions{
+			Prefix:   prefix,
+			KeysOnly: true,
+		}, func(loaded, total int64) {
+			s.app.QueueUpdateDraw(func() {
+				if s.loadID != loadID {
+					return
+				}
+				s.SetStatusBarText(fmt.Sprintf("[yellow]Loading keys:[white] %d / %d (%d%%) | [green::b]ESC[-::-] Cancel",
+					loaded, total, percent(loaded, total)))
+			})
+		})
+
+		s.app.QueueUpdateDraw(func() {
+			if s.loadID != loadID {
+				return
+			}
+			s.CancelLoading()
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				s.debugPanel.LogWarn("Key loading cancelled after %d keys", len(kvs))
+				onDone(kvs, false)
+				s.SetStatusBarText(fmt.Sprintf("[yellow]Loading cancelled:[white] showing %d keys loaded so far | [green::b]r[-::-] Reload", len(kvs)))
+			case err != nil:
+				s.debugPanel.LogError("Failed to load keys: %v", err)
+				s.SetStatusBarText(fmt.Sprintf("[red]Failed to load keys:[white] %v", err))
+			default:
+				s.debugPanel.LogInfo("Loaded %d keys at revision %d", len(kvs), revision)
+				onDone(kvs, true)
+			}
+		})
+	}()
+}
+
+// beginLoad cancels any key listing in progress and returns the context
+// and id of a new one. Completion handlers compare the id with s.loadID to
+// ignore results of listings that were superseded.
+func (s *State) beginLoad(ctx context.Context) (context.Context, int) {
+	s.CancelLoading()
+	loadCtx, cancel := context.WithCancel(ctx)
+	s.loadCancel = cancel
+	s.loadID++
+	return loadCtx, s.loadID
+}
+
+// CancelLoading stops a key listing in progress. It returns false if
+// nothing was loading.
+func (s *State) CancelLoading() bool {
+	if s.loadCancel == nil {
+		return false
+	}
+	s.loadCancel()
+	s.loadCancel = nil
+	return true
+}
+
+// IsLoading returns true while keys are being listed in the background.
+func (s *State) IsLoading() bool {
+	return s.loadCancel != nil
+}
+
+// percent returns part as a percentage of total.
+func percent(part, total int64) int64 {
+	if total <= 0 {
+		return 100
+	}
+	return part * 100 / total
+}
+
 // showKeyDetails displays detailed information about a key.
 func (s *State) showKeyDetails(ctx context.Context, kv *client.KeyValue) {
+	// Pick the rendering that fits the value whenever another key is shown
+	if s.currentKey == nil || s.currentKey.Key != kv.Key {
+		s.detailsPanel.SetValueMode(details.DefaultValueMode(kv.Value))
+	}
 	s.currentKey = kv
 
-	detailsText := fmt.Sprintf("[yellow]Key:[white] %s\n\n", kv.Key)
-	detailsText += fmt.Sprintf("[yellow]Value:[white]\n%s\n\n", kv.Value)
+	mode := s.detailsPanel.GetValueMode()
+	format := client.DetectValueFormat(kv.Key, kv.Value)
+	value, err := details.RenderValue(kv.Value, mode, format)
+
+	valueLabel := fmt.Sprintf("[yellow]Value:[white] [gray](%s, %s, %d bytes)[-]", format.Name(), mode, len(kv.Value))
+	switch {
+	case kv.IsBinary():
+		valueLabel = fmt.Sprintf("[yellow]Value:[white] [red]binary[-] [gray](%s, %d bytes)[-]", mode, len(kv.Value))
+	case err != nil:
+		valueLabel += fmt.Sprintf(" [red]invalid %s:[-] %s", format.Name(), details.EscapeText(err.Error()))
+	}
+
+	detailsText := fmt.Sprintf("[yellow]Key:[white] %s\n\n", details.EscapeText(kv.Key))
+	detailsText += fmt.Sprintf("%s\n%s\n\n", valueLabel, value)
 	detailsText += fmt.Sprintf("[yellow]Create Revision:[white] %d\n", kv.CreateRevision)
 	detailsText += fmt.Sprintf("[yellow]Mod Revision:[white] %d\n", kv.ModRevision)
 	detailsText += fmt.Sprintf("[yellow]Version:[white] %d\n", kv.Version)
 
-	if kv.Lease > 0 {
-		if cli := s.connManager.GetClient(); cli != nil {
-			if leaseInfo, err := cli.GetLeaseInfo(ctx, kv.Lease); err == nil {
-				detailsText += fmt.Sprintf("[yellow]TTL:[white] %d seconds\n", leaseInfo.TTL)
-			} else {
-				detailsText += fmt.Sprintf("[yellow]Lease:[white] %d\n", kv.Lease)
-			}
+	access := s.detailsAccess(ctx, kv.Key, func() {
+		if s.currentKey == kv && !s.inEditMode {
+			s.showKeyDetails(ctx, kv)
 		}
-	} else {
+	})
+	if !access.CanWrite() {
+		detailsText += fmt.Sprintf("[yellow]Access:[white] %s\n", access)
+	}
+
+	writeErr := s.guard().CheckPut(kv.Key, true)
+	protected := s.keyProtection(kv.Key)
+	switch {
+	case writeErr != nil:
+		detailsText += fmt.Sprintf("[yellow]Protected:[white] [red]%s[-]\n", details.EscapeText(writeErr.Error()))
+	case protected.Protection != client.ProtectNone:
+		detailsText += fmt.Sprintf("[yellow]Protected:[white] %s by %s\n", protected.Protection, details.EscapeText(protected.Prefix))
+	}
+
+	switch {
+	case kv.Lease > 0 && s.currentLease != nil && s.currentLease.ID == kv.Lease:
+		detailsText += fmt.Sprintf("[yellow]TTL:[white] %d seconds\n", s.currentLease.TTL)
+	case kv.Lease > 0:
+		detailsText += fmt.Sprintf("[yellow]Lease:[white] %d\n", kv.Lease)
+	default:
 		detailsText += "[yellow]TTL:[white] ∞\n"
 	}
 
 	s.detailsPanel.SetText(detailsText)
+	s.detailsPanel.SetReadOnly(!access.CanWrite() || writeErr != nil)
 	s.detailsPanel.ShowButtons()
 }
 
-// updateStatusBar updates status bar with current stats.
+// updateStatusBar updates status bar with current stats. The key count and
+// leader are fetched in the background, as asking every member for its
+// status waits for unreachable ones.
 func (s *State) updateStatusBar(ctx context.Context) {
 	cli := s.connManager.GetClient()
 	if cli == nil {
@@ -110,25 +370,57 @@ func (s *State) updateStatusBar(ctx context.Context) {
 		return
 	}
 
-	count, err := cli.GetKeyCount(ctx)
-	if err != nil {
-		count = 0
-	}
+	s.statusID++
+	statusID := s.statusID
 
-	status, err := cli.GetClusterStatus(ctx)
-	leaderInfo := "unknown"
-	if err == nil && status != nil && status.Leader != "" {
-		leaderInfo = status.Leader
-	}
+	go func() {
+		count, err := cli.GetKeyCount(ctx)
+		if err != nil {
+			count = 0
+		}
 
-	// Include profile name if available
+		status, err := cli.GetClusterStatus(ctx)
+		leaderInfo := "unknown"
+		switch {
+		case err != nil || status == nil:
+		case status.LeaderID == 0:
+			leaderInfo = "[red]none"
+		case status.Leader != "":
+			leaderInfo = status.Leader
+		}
+
+		s.app.QueueUpdateDraw(func() {
+			if s.statusID != statusID || ctx.Err() != nil {
+				return
+			}
+			s.setStatusBarStats(leaderInfo, count)
+		})
+	}()
+}
+
+// setStatusBarStats shows the connection status with the given leader and
+// key count
+func (s *State) setStatusBarStats(leaderInfo string, count int64) {
+	// Include profile name if available, as a red badge if it restricts
+	// changes
 	profileInfo := ""
-	if s.profile != nil {
+	switch {
+	case s.profile == nil:
+	case s.profile.ReadOnly:
+		profileInfo = fmt.Sprintf("[white:red:b] %s: read-only [-:-:-] | ", tview.Escape(s.profile.Name))
+	case len(s.profile.ProtectedPrefixes) > 0:
+		profileInfo = fmt.Sprintf("[white:red:b] %s: %d protected [-:-:-] | ", tview.Escape(s.profile.Name), len(s.profile.ProtectedPrefixes))
+	default:
 		profileInfo = fmt.Sprintf("[magenta]%s[-] | ", s.profile.Name)
 	}
 
-	statusText := fmt.Sprintf("%s[green]Connected[-] | Leader: [cyan]%s[-] | Keys: [yellow]%d[-] | [green::b]p[-::-] Profiles  [green::b]/[-::-] Search  [green::b]n[-::-] New  [green::b]?[-::-] Help",
-		profileInfo, leaderInfo, count)
+	liveInfo := ""
+	if s.IsLive() {
+		liveInfo = "[green]● Live[-] | "
+	}
+
+	statusText := fmt.Sprintf("%s[green]Connected[-] | %sLeader: [cyan]%s[-] | Keys: [yellow]%d[-] | %s[green::b]p[-::-] Profiles  [green::b]/[-::-] Search  [green::b]n[-::-] New  [green::b]?[-::-] Help",
+		profileInfo, s.identityInfo(), leaderInfo, count, liveInfo)
 
 	s.SetStatusBarText(statusText)
 }
@@ -144,74 +436,112 @@ func (s *State) RefreshKeys(ctx context.Context) error {
 		return err
 	}
 
-	s.updateStatusBar(ctx)
 	return nil
 }
 
-// DeleteKey deletes a key from etcd.
-func (s *State) DeleteKey(ctx context.Context, key string) error {
+// DeleteKey deletes a key from etcd. Deletes the profile refuses return a
+// *client.ProtectionError; confirmed is set once the user typed the key of
+// one protected with confirmation.
+func (s *State) DeleteKey(ctx context.Context, key string, confirmed bool) error {
 	cli := s.connManager.GetClient()
 	if cli == nil {
 		return fmt.Errorf("not connected to etcd")
 	}
 
-	if err := cli.Delete(ctx, key); err != nil {
+	if err := cli.Delete(confirmedContext(ctx, confirmed), key); err != nil {
 		return fmt.Errorf("failed to delete key: %w", err)
 	}
 
 	return s.RefreshKeys(ctx)
 }
 
-// PutKey creates or updates a key.
-func (s *State) PutKey(ctx context.Context, key, value string) error {
+// PutKey creates or updates a key if it is still at modRevision, the
+// revision read before editing (0 for a new key), and refreshes the keys.
+// On a conflict it returns false and the key as it is now, nil if deleted.
+// Writes the profile refuses return a *client.ProtectionError, and unless
+// opts.SkipValidation is set a value failing the validation rules is not
+// written and a *client.ValidationError returned.
+func (s *State) PutKey(ctx context.Context, key, value string, modRevision, leaseID int64, opts WriteOptions) (bool, *client.KeyValue, error) {
 	cli := s.connManager.GetClient()
 	if cli == nil {
-		return fmt.Errorf("not connected to etcd")
+		return false, nil, fmt.Errorf("not connected to etcd")
 	}
 
-	if err := cli.Put(ctx, key, value); err != nil {
-		return fmt.Errorf("failed to put key: %w", err)
+	if !opts.SkipValidation {
+		if err := s.validateValue(key, []byte(value)); err != nil {
+			return false, nil, err
+		}
 	}
 
-	return s.RefreshKeys(ctx)
+	ok, current, err := cli.PutIfModRevision(confirmedContext(ctx, opts.Confirmed), key, value, modRevision, leaseID)
+	if err != nil || !ok {
+		return false, current, err
+	}
+
+	return true, nil, s.RefreshKeys(ctx)
 }
 
-// RefreshKeyDetails refreshes details for a specific key.
+// RefreshKeyDetails fetches a key with the TTL of its lease in the
+// background and shows its details, unless another node was selected
+// meanwhile.
 func (s *State) RefreshKeyDetails(ctx context.Context, key string) error {
 	cli := s.connManager.GetClient()
 	if cli == nil {
 		return fmt.Errorf("not connected to etcd")
 	}
 
-	kv, err := cli.Get(ctx, key)
-	if err != nil {
-		return fmt.Errorf("failed to get key: %w", err)
-	}
+	s.detailsID++
+	detailsID := s.detailsID
+
+	go func() {
+		kv, err := cli.Get(ctx, key)
+		var lease *client.LeaseInfo
+		if err == nil && kv.Lease > 0 {
+			// Without the TTL the details show the lease ID instead
+			lease, _ = cli.GetLeaseInfo(ctx, kv.Lease)
+		}
+
+		s.app.QueueUpdateDraw(func() {
+			if s.detailsID != detailsID || ctx.Err() != nil || s.inEditMode {
+				return
+			}
+			if err != nil {
+				s.clearKeyDetails(fmt.Sprintf("[red]Failed to load key:[white] %s", details.EscapeText(err.Error())))
+				return
+			}
+			s.currentLease = lease
+			s.showKeyDetails(ctx, kv)
+		})
+	}()
 
-	s.showKeyDetails(ctx, kv)
 	return nil
 }
 
 // SearchByPrefix searches keys by prefix and updates the tree.
+// Results are loaded in the background.
 func (s *State) SearchByPrefix(ctx context.Context, prefix string) error {
-	cli := s.connManager.GetClient()
-	if cli == nil {
+	if s.connManager.GetClient() == nil {
 		return fmt.Errorf("not connected to etcd")
 	}
 
-	kvs, err := cli.List(ctx, prefix)
-	if err != nil {
-		return fmt.Errorf("failed to search keys: %w", err)
-	}
+	s.loadKeys(ctx, prefix, func(kvs []*client.KeyValue, complete bool) {
+		// Search results are a different tree; live updates resume on refresh
+		s.StopLive()
 
-	if err = s.keysPanel.LoadKeys(ctx, kvs); err != nil {
-		return fmt.Errorf("failed to load search results: %w", err)
-	}
+		if err := s.keysPanel.LoadKeys(ctx, kvs); err != nil {
+			s.SetStatusBarText(fmt.Sprintf("[red]Failed to load search results:[white] %v", err))
+			return
+		}
 
-	// Clear current key selection and details
-	s.currentKey = nil
-	s.detailsPanel.SetText(fmt.Sprintf("[yellow]Search results for:[white] %s\n\n[cyan]%d keys found[-]", prefix, len(kvs)))
-	s.detailsPanel.HideButtons()
+		// Clear current key selection and details
+		s.clearKeyDetails(fmt.Sprintf("[yellow]Search results for:[white] %s\n\n[cyan]%d keys found[-]", details.EscapeText(prefix), len(kvs)))
+
+		if complete && s.liveEnabled {
+			s.SetStatusBarText("[green]Search:[white] " + tview.Escape(prefix) + " | [yellow]Live updates paused[-] | [green::b]r[-::-] Resume")
+		} else if complete {
+			s.SetStatusBarText("[green]Search:[white] " + tview.Escape(prefix))
+		}
+	})
 
 	return nil
 }
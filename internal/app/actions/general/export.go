@@ -0,0 +1,192 @@
+package general
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/keys"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// HandleExport shows a form to export the selected directory to a file.
+func (s *State) HandleExport(ctx context.Context) {
+	if s.connManager.GetClient() == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	node := s.keysPanel.GetTree().GetCurrentNode()
+	n := keys.GetNode(node)
+	if n == nil || (!n.IsDir() && node != s.keysPanel.GetTree().GetRoot()) {
+		s.SetStatusBarText("[yellow]Select a directory to export")
+		return
+	}
+	prefix := n.Prefix
+
+	s.debugPanel.LogInfo("Opening export form for prefix '%s'", prefix)
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	// Helper to close form and restore main view
+	closeForm := func() {
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	formats := make([]string, len(client.ExportFormats))
+	for i, f := range client.ExportFormats {
+		formats[i] = string(f)
+	}
+
+	form := tview.NewForm()
+	form.AddTextView("Prefix", tview.Escape(prefix), 50, 1, true, false)
+	form.AddInputField("File", exportFileName(prefix, client.ExportJSON), 50, nil, nil)
+	form.AddDropDown("Format", formats, 0, func(option string, _ int) {
+		// The callback fires while the form is still being built
+		if form.GetFormItemCount() < 2 {
+			return
+		}
+		file := form.GetFormItemByLabel("File").(*tview.InputField)
+		for _, f := range client.ExportFormats {
+			if file.GetText() == exportFileName(prefix, f) {
+				file.SetText(exportFileName(prefix, client.ExportFormat(option)))
+				break
+			}
+		}
+	})
+	form.AddCheckbox("Nested by path", false, nil)
+
+	form.AddButton("Export", func() {
+		path := form.GetFormItemByLabel("File").(*tview.InputField).GetText()
+		if path == "" {
+			s.SetStatusBarText("[yellow]Export file is required")
+			return
+		}
+		formatIndex, _ := form.GetFormItemByLabel("Format").(*tview.DropDown).GetCurrentOption()
+		s.exportPrefix(ctx, path, client.ExportOptions{
+			Prefix: prefix,
+			Format: client.ExportFormats[formatIndex],
+			Nested: form.GetFormItemByLabel("Nested by path").(*tview.Checkbox).IsChecked(),
+		})
+	})
+
+	form.AddButton("Cancel", func() {
+		closeForm()
+	})
+
+	// Setup ESC to close the form
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			closeForm()
+			return nil
+		}
+		return event
+	})
+
+	form.SetBorder(true).SetTitle(" Export Keys (Tab to navigate, ESC cancel) ").SetTitleAlign(tview.AlignLeft)
+	form.SetCancelFunc(closeForm)
+
+	// Set root and focus on the file field
+	s.app.SetRoot(form, true)
+	form.SetFocus(1)
+}
+
+// exportPrefix writes the keys with opts.Prefix to path while showing
+// progress. ESC cancels the export; the file is only created on success.
+func (s *State) exportPrefix(ctx context.Context, path string, opts client.ExportOptions) {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return
+	}
+
+	exportCtx, cancel := context.WithCancel(ctx)
+	done := false
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	progressView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf("[yellow]Exporting to[white] %s\n\n[gray]Reading keys...[-]", tview.Escape(path)))
+
+	progressView.SetBorder(true).
+		SetTitle(" Export (ESC to cancel) ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+
+	progressView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || (done && event.Key() == tcell.KeyEnter) {
+			if !done {
+				s.SetStatusBarText("[yellow]Export cancelled")
+				s.debugPanel.LogWarn("Export to %s cancelled", path)
+			}
+			closeView()
+			return nil
+		}
+		return event
+	})
+
+	// Center the progress window
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(progressView, 9, 1, true).
+			AddItem(nil, 0, 1, false), 70, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+	s.debugPanel.LogInfo("Exporting prefix '%s' as %s to %s", opts.Prefix, opts.Format, path)
+
+	go func() {
+		started := time.Now()
+
+		result, err := cli.ExportFile(exportCtx, path, opts, func(loaded, total int64) {
+			s.app.QueueUpdateDraw(func() {
+				progressView.SetText(fmt.Sprintf("[yellow]Exporting to[white] %s\n\n[cyan]Read:[white] %d / %d keys (%d%%)",
+					tview.Escape(path), loaded, total, percent(loaded, total)))
+			})
+		})
+
+		s.app.QueueUpdateDraw(func() {
+			done = true
+			if exportCtx.Err() != nil {
+				return
+			}
+			progressView.SetTitle(" Export (Enter or ESC to close) ")
+
+			if err != nil {
+				s.debugPanel.LogError("Export failed: %v", err)
+				progressView.SetText(fmt.Sprintf("[red]Export failed:[white] %s", tview.Escape(err.Error())))
+				s.SetStatusBarText("[red]Export failed:[white] " + err.Error())
+				return
+			}
+
+			s.debugPanel.LogInfo("Exported %d keys at revision %d to %s", result.Keys, result.Revision, path)
+			progressView.SetText(fmt.Sprintf("[green]Export complete[-]\n\n[cyan]File:[white] %s\n[cyan]Keys:[white] %d in %s\n[cyan]Revision:[white] %d",
+				tview.Escape(path), result.Keys, time.Since(started).Round(time.Millisecond), result.Revision))
+			s.SetStatusBarText("[green]Exported:[white] " + path)
+		})
+	}()
+}
+
+// exportFileName suggests a file name for exporting prefix in format
+func exportFileName(prefix string, format client.ExportFormat) string {
+	name := strings.Trim(strings.ReplaceAll(prefix, client.KeySeparator, "-"), "-")
+	if name == "" {
+		name = "etcd"
+	}
+	ext := string(format)
+	if format == client.ExportDump {
+		ext = "jsonl"
+	}
+	return fmt.Sprintf("%s-export.%s", name, ext)
+}
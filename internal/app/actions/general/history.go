@@ -0,0 +1,329 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alex-dev-master/etcdtui/internal/diff"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// historyPreviewWidth is the number of characters of a value shown in the
+// revisions list
+const historyPreviewWidth = 60
+
+// HandleHistory shows the previous versions of the selected key with
+// their values, diffs between versions and a restore action.
+func (s *State) HandleHistory(ctx context.Context) {
+	kv := s.GetCurrentKey()
+	if kv == nil {
+		s.SetStatusBarText("[yellow]No key selected")
+		return
+	}
+
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	s.debugPanel.LogInfo("Loading history of key: %s", kv.Key)
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	historyCtx, cancel := context.WithCancel(ctx)
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(" History: " + tview.Escape(kv.Key) + " ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText("[gray]Loading history...[-]")
+	textView.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]↑/↓[-] select  [green]Space[-] mark diff base  [green]d[-] value/diff  [green]r[-] restore  [green]Tab[-] scroll value  [green]ESC[-] close")
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(textView, 0, 2, false).
+		AddItem(hint, 1, 0, false)
+
+	var (
+		history  *client.KeyHistory
+		base     = -1 // index of the version marked as diff base, -1 for the previous version
+		showDiff = true
+	)
+
+	// render updates the lower pane for the selected version
+	render := func() {
+		if history == nil {
+			return
+		}
+		row, _ := table.GetSelection()
+		i := row - 1
+		if i < 0 || i >= len(history.Versions) {
+			return
+		}
+		version := history.Versions[i]
+
+		if !showDiff {
+			textView.SetTitle(fmt.Sprintf(" Value at revision %d ", version.ModRevision))
+			// Values that do not pretty print are shown as stored
+			value, _ := details.RenderValue(version.Value, details.DefaultValueMode(version.Value), client.DetectValueFormat(kv.Key, version.Value))
+			textView.SetText(value)
+			textView.ScrollToBeginning()
+			return
+		}
+
+		// Compare against the marked version or the one before the selection
+		fromName, from := "/dev/null", ""
+		j := i + 1
+		if base >= 0 {
+			j = base
+		}
+		if j < len(history.Versions) && j != i {
+			fromName = fmt.Sprintf("%s@%d", kv.Key, history.Versions[j].ModRevision)
+			from = details.DiffText(history.Versions[j].Value)
+		}
+		toName := fmt.Sprintf("%s@%d", kv.Key, version.ModRevision)
+
+		textView.SetTitle(fmt.Sprintf(" Diff %s → %d ", strings.TrimPrefix(fromName, kv.Key+"@"), version.ModRevision))
+		unified := diff.Unified(fromName, toName, from, details.DiffText(version.Value))
+		if unified == "" {
+			textView.SetText("[gray]No changes[-]")
+		} else {
+			textView.SetText(details.RenderDiff(unified))
+		}
+		textView.ScrollToBeginning()
+	}
+
+	// fill lists the loaded versions in the table
+	fill := func() {
+		table.Clear()
+		for col, title := range []string{"Revision", "Version", "Size", "Value"} {
+			table.SetCell(0, col, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+
+		for i, version := range history.Versions {
+			marker := " "
+			if i == base {
+				marker = "*"
+			}
+
+			preview := "[red]binary[-]"
+			if !version.IsBinary() {
+				preview = details.EscapeText(previewLine(string(version.Value)))
+			}
+			if i == 0 {
+				preview = "[green](current)[-] " + preview
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(fmt.Sprintf("%s%d", marker, version.ModRevision)))
+			table.SetCell(i+1, 1, tview.NewTableCell(fmt.Sprintf("%d", version.Version)))
+			table.SetCell(i+1, 2, tview.NewTableCell(client.FormatBytes(int64(len(version.Value)))).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 3, tview.NewTableCell(preview).SetExpansion(1))
+		}
+
+		switch {
+		case history.Compacted:
+			table.SetTitle(fmt.Sprintf(" History: %s (%d versions, older ones compacted) ", tview.Escape(kv.Key), len(history.Versions)))
+		case history.Truncated:
+			table.SetTitle(fmt.Sprintf(" History: %s (latest %d versions) ", tview.Escape(kv.Key), len(history.Versions)))
+		default:
+			table.SetTitle(fmt.Sprintf(" History: %s (%d versions) ", tview.Escape(kv.Key), len(history.Versions)))
+		}
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		render()
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab:
+			s.app.SetFocus(textView)
+			return nil
+		}
+
+		if history == nil {
+			return event
+		}
+		row, _ := table.GetSelection()
+
+		switch event.Rune() {
+		case ' ':
+			if base == row-1 {
+				base = -1
+			} else {
+				base = row - 1
+			}
+			fill()
+			render()
+			return nil
+		case 'd':
+			showDiff = !showDiff
+			render()
+			return nil
+		case 'r':
+			if row-1 <= 0 {
+				s.SetStatusBarText("[yellow]Select an older version to restore")
+				return nil
+			}
+			s.confirmRestore(ctx, flex, history.Versions[row-1], history.Versions[0], closeView)
+			return nil
+		}
+		return event
+	})
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab:
+			s.app.SetFocus(table)
+			return nil
+		}
+		return event
+	})
+
+	s.app.SetRoot(flex, true)
+
+	go func() {
+		h, err := cli.History(historyCtx, kv.Key, client.DefaultHistoryLimit)
+		s.app.QueueUpdateDraw(func() {
+			if historyCtx.Err() != nil {
+				return
+			}
+			if err != nil && (h == nil || len(h.Versions) == 0) {
+				s.debugPanel.LogError("Failed to load history of %s: %v", kv.Key, err)
+				textView.SetText("[red]Failed to load history:[white] " + tview.Escape(err.Error()))
+				return
+			}
+			if err != nil {
+				s.debugPanel.LogWarn("History of %s is incomplete: %v", kv.Key, err)
+				s.SetStatusBarText("[yellow]History incomplete:[white] " + err.Error())
+			}
+
+			s.debugPanel.LogInfo("Loaded %d versions of %s", len(h.Versions), kv.Key)
+			history = h
+			fill()
+			table.Select(1, 0)
+			render()
+		})
+	}()
+}
+
+// confirmRestore asks before writing an old version back, and for keys
+// protected with confirmation for the key to be typed. The write is
+// conditional on the key still being at the revision of current.
+func (s *State) confirmRestore(ctx context.Context, historyView tview.Primitive, version, current *client.KeyValue, closeHistory func()) {
+	if !s.checkProtection(version.Key, false) {
+		return
+	}
+
+	text := fmt.Sprintf("Restore %s to the value of revision %d (version %d)?", version.Key, version.ModRevision, version.Version)
+	button := "Restore"
+
+	// Old values may not pass the current validation rules
+	var validationErr *client.ValidationError
+	if err := s.validateValue(version.Key, version.Value); errors.As(err, &validationErr) {
+		s.debugPanel.LogWarn("Revision %d of %s fails validation: %v", version.ModRevision, version.Key, err)
+		text += "\n\nThe value fails validation:\n" + validationSummary(validationErr, 5)
+		button = "Restore anyway"
+	} else if err != nil {
+		s.SetStatusBarText("[red]Cannot restore:[white] " + tview.Escape(err.Error()))
+		return
+	}
+
+	var restore func(ctx context.Context)
+	restore = func(ctx context.Context) {
+		cli := s.connManager.GetClient()
+		if cli == nil {
+			s.app.SetRoot(historyView, true)
+			s.SetStatusBarText("[red]Not connected to etcd")
+			return
+		}
+
+		restored, err := cli.RestoreVersion(ctx, version, current)
+		var protectionErr *client.ProtectionError
+		if errors.As(err, &protectionErr) && errors.Is(err, client.ErrNotConfirmed) {
+			s.confirmProtected(protectionErr, func() {
+				s.app.SetRoot(historyView, true)
+			}, func() {
+				restore(client.WithConfirmation(ctx))
+			})
+			return
+		}
+		if err != nil {
+			s.app.SetRoot(historyView, true)
+			s.SetStatusBarText("[red]Failed to restore:[white] " + err.Error())
+			s.debugPanel.LogError("Failed to restore %s: %v", version.Key, err)
+			return
+		}
+		if !restored {
+			s.app.SetRoot(historyView, true)
+			s.SetStatusBarText("[yellow]Key changed since the history was loaded; reopen history and try again")
+			s.debugPanel.LogWarn("Restore of %s skipped: modified after revision %d", version.Key, current.ModRevision)
+			return
+		}
+
+		s.debugPanel.LogInfo("Restored %s to revision %d", version.Key, version.ModRevision)
+		closeHistory()
+		if err := s.RefreshKeyDetails(ctx, version.Key); err != nil {
+			s.debugPanel.LogWarn("Restored but failed to refresh details: %v", err)
+		}
+		s.SetStatusBarText(fmt.Sprintf("[green]Restored:[white] %s to revision %d", version.Key, version.ModRevision))
+	}
+
+	modal := tview.NewModal().
+		SetText(tview.Escape(text)).
+		AddButtons([]string{button, "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel != button {
+				s.app.SetRoot(historyView, true)
+				return
+			}
+			restore(ctx)
+		})
+
+	s.app.SetRoot(modal, true)
+}
+
+// previewLine returns the first line of a value, shortened for a list
+func previewLine(value string) string {
+	line, _, multiline := strings.Cut(value, "\n")
+	if len([]rune(line)) > historyPreviewWidth {
+		return string([]rune(line)[:historyPreviewWidth]) + "…"
+	}
+	if multiline {
+		return line + " …"
+	}
+	return line
+}
@@ -0,0 +1,439 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alex-dev-master/etcdtui/internal/diff"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/keys"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// importFormats are the format choices of the import form; the first one
+// detects the format from the file extension
+var importFormats = []string{"auto", string(client.ExportJSON), string(client.ExportYAML), string(client.ExportDump)}
+
+// HandleImport shows a form to import a file into a prefix. The changes are
+// planned and shown for review before anything is written.
+func (s *State) HandleImport(ctx context.Context) {
+	if s.connManager.GetClient() == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+	if !s.canCreateKeys() {
+		return
+	}
+
+	// Import into the selected directory, or the one holding the selected key
+	prefix := ""
+	if n := keys.GetNode(s.keysPanel.GetTree().GetCurrentNode()); n != nil {
+		switch {
+		case n.IsDir():
+			prefix = n.Prefix
+		case n.KV != nil:
+			if i := strings.LastIndex(n.KV.Key, client.KeySeparator); i >= 0 {
+				prefix = n.KV.Key[:i+1]
+			}
+		}
+	}
+
+	s.debugPanel.LogInfo("Opening import form for prefix '%s'", prefix)
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	// Helper to close form and restore main view
+	closeForm := func() {
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	form := tview.NewForm()
+	form.AddInputField("File", "", 50, nil, nil)
+	form.AddInputField("Prefix", prefix, 50, nil, nil)
+	form.AddDropDown("Format", importFormats, 0, nil)
+	form.AddCheckbox("Delete keys not in file", false, nil)
+
+	form.AddButton("Plan", func() {
+		path := form.GetFormItemByLabel("File").(*tview.InputField).GetText()
+		if path == "" {
+			s.SetStatusBarText("[yellow]Import file is required")
+			return
+		}
+
+		var format client.ExportFormat
+		if formatIndex, option := form.GetFormItemByLabel("Format").(*tview.DropDown).GetCurrentOption(); formatIndex > 0 {
+			format = client.ExportFormat(option)
+		}
+		entries, err := client.ReadImportFile(path, format)
+		if err != nil {
+			s.debugPanel.LogError("Failed to read import file %s: %v", path, err)
+			s.SetStatusBarText("[red]Import failed:[white] " + tview.Escape(err.Error()))
+			return
+		}
+
+		s.planImport(ctx, path, entries, client.ImportOptions{
+			Prefix: form.GetFormItemByLabel("Prefix").(*tview.InputField).GetText(),
+			Delete: form.GetFormItemByLabel("Delete keys not in file").(*tview.Checkbox).IsChecked(),
+		})
+	})
+
+	form.AddButton("Cancel", func() {
+		closeForm()
+	})
+
+	// Setup ESC to close the form
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			closeForm()
+			return nil
+		}
+		return event
+	})
+
+	form.SetBorder(true).SetTitle(" Import Keys (Tab to navigate, ESC cancel) ").SetTitleAlign(tview.AlignLeft)
+	form.SetCancelFunc(closeForm)
+
+	// Set root and focus on the file field
+	s.app.SetRoot(form, true)
+}
+
+// planImport compares the imported entries with the keys under the
+// destination prefix and shows the resulting changes for review. Nothing is
+// written until the plan is applied with 'a'.
+func (s *State) planImport(ctx context.Context, path string, entries []*client.ImportEntry, opts client.ImportOptions) {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return
+	}
+
+	validator, err := s.validator()
+	if err != nil {
+		s.debugPanel.LogError("Import refused: %v", err)
+		s.SetStatusBarText("[red]Import failed:[white] " + tview.Escape(err.Error()))
+		return
+	}
+	opts.Validator = validator
+
+	importCtx, cancel := context.WithCancel(ctx)
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Import %s into %s ", tview.Escape(path), tview.Escape(prefixName(opts.Prefix)))).
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+
+	textView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText("[gray]Reading current keys...[-]")
+	textView.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]↑/↓[-] select  [green]Tab[-] scroll diff  [green]ESC[-] cancel")
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(textView, 0, 2, false).
+		AddItem(hint, 1, 0, false)
+
+	var (
+		plan     *client.ImportPlan
+		pending  []*client.ImportChange
+		invalid  map[string]*client.ValidationError
+		refused  map[string]*client.ProtectionError
+		blocked  int // Refused changes that cannot be confirmed
+		applying bool
+		applied  bool
+	)
+
+	// render shows the diff of the selected change
+	render := func() {
+		row, _ := table.GetSelection()
+		i := row - 1
+		if pending == nil || i < 0 || i >= len(pending) {
+			return
+		}
+		change := pending[i]
+
+		fromName, from := "/dev/null", ""
+		if change.Current != nil {
+			fromName = fmt.Sprintf("%s@%d", change.Key, change.Current.ModRevision)
+			from = details.DiffText(change.Current.Value)
+		}
+		toName, to := "/dev/null", ""
+		if change.Action != client.ImportDelete {
+			toName = change.Key + " (import)"
+			to = details.DiffText(change.Value)
+		}
+
+		textView.SetTitle(fmt.Sprintf(" %s: %s ", change.Action, details.EscapeText(change.Key)))
+		var text string
+		if protectionErr := refused[change.Key]; protectionErr != nil {
+			text = "[red]Protected:[-] " + details.EscapeText(protectionErr.Error()) + "\n\n"
+		}
+		if validationErr := invalid[change.Key]; validationErr != nil {
+			text += "[red]Fails validation:[-]\n" + details.EscapeText(validationSummary(validationErr, len(validationErr.Issues))) + "\n\n"
+		}
+		if unified := diff.Unified(fromName, toName, from, to); unified != "" {
+			textView.SetText(text + details.RenderDiff(unified))
+		} else {
+			textView.SetText(text + "[gray]Empty value[-]")
+		}
+		textView.ScrollToBeginning()
+	}
+
+	// fill lists the pending changes in the table
+	fill := func() {
+		table.Clear()
+		for col, title := range []string{"Action", "Key", "Size", "Check"} {
+			table.SetCell(0, col, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+
+		for i, change := range pending {
+			color := tcell.ColorGreen
+			size := client.FormatBytes(int64(len(change.Value)))
+			switch change.Action {
+			case client.ImportUpdate:
+				color = tcell.ColorYellow
+			case client.ImportDelete:
+				color = tcell.ColorRed
+				size = ""
+			}
+			table.SetCell(i+1, 0, tview.NewTableCell(change.Action.String()).SetTextColor(color))
+			table.SetCell(i+1, 1, tview.NewTableCell(details.EscapeText(change.Key)).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(size).SetAlign(tview.AlignRight))
+			switch protectionErr := refused[change.Key]; {
+			case protectionErr != nil && errors.Is(protectionErr, client.ErrNotConfirmed):
+				table.SetCell(i+1, 3, tview.NewTableCell("confirm").SetTextColor(tcell.ColorYellow))
+			case protectionErr != nil:
+				table.SetCell(i+1, 3, tview.NewTableCell("protected").SetTextColor(tcell.ColorRed))
+			case invalid[change.Key] != nil:
+				table.SetCell(i+1, 3, tview.NewTableCell("invalid").SetTextColor(tcell.ColorRed))
+			}
+		}
+	}
+
+	// apply writes the plan in batched transactions, with applyCtx carrying
+	// the confirmation of protected prefixes
+	apply := func(applyCtx context.Context) {
+		applying = true
+		hint.SetText("[yellow]Applying...[-]")
+		s.debugPanel.LogInfo("Importing %d changes into '%s'", len(pending), opts.Prefix)
+
+		go func() {
+			started := time.Now()
+			result, err := cli.ApplyImport(applyCtx, plan, opts, func(done, total int) {
+				s.app.QueueUpdateDraw(func() {
+					hint.SetText(fmt.Sprintf("[yellow]Applied %d / %d keys (%d%%)[-]", done, total, percent(int64(done), int64(total))))
+				})
+			})
+
+			s.app.QueueUpdateDraw(func() {
+				applying = false
+				applied = true
+				hint.SetText("[green]Enter[-] or [green]ESC[-] close")
+				if importCtx.Err() != nil {
+					return
+				}
+				if err := s.RefreshKeys(ctx); err != nil {
+					s.debugPanel.LogError("Failed to refresh keys: %v", err)
+				}
+
+				textView.SetTitle(" Result ")
+				if err != nil {
+					s.debugPanel.LogError("Import failed: %v", err)
+					text := fmt.Sprintf("[red]Import failed:[white] %s\n\n[cyan]Applied:[white] %d of %d keys in %d transactions",
+						tview.Escape(err.Error()), result.Applied, len(pending), result.Txns)
+					if len(result.Conflicts) > 0 {
+						text += "\n\n[yellow]Changed since planning:[-]\n" + details.EscapeText(strings.Join(result.Conflicts, "\n"))
+					}
+					textView.SetText(text)
+					s.SetStatusBarText("[red]Import failed:[white] " + tview.Escape(err.Error()))
+					return
+				}
+
+				s.debugPanel.LogInfo("Imported %d keys into '%s' in %d transactions, revision %d", result.Applied, opts.Prefix, result.Txns, result.Revision)
+				textView.SetText(fmt.Sprintf("[green]Import complete[-]\n\n[cyan]Keys:[white] %d in %d transactions, %s\n[cyan]Revision:[white] %d",
+					result.Applied, result.Txns, time.Since(started).Round(time.Millisecond), result.Revision))
+				s.SetStatusBarText(fmt.Sprintf("[green]Imported:[white] %d keys into %s", result.Applied, tview.Escape(prefixName(opts.Prefix))))
+			})
+		}()
+	}
+
+	// applyValidated applies the plan, asking first if values fail
+	// validation
+	applyValidated := func(applyCtx context.Context) {
+		if len(invalid) == 0 {
+			apply(applyCtx)
+			return
+		}
+		modal := tview.NewModal().
+			SetText(fmt.Sprintf("%d of %d values fail validation. Import them anyway?", len(invalid), len(pending))).
+			AddButtons([]string{"Import anyway", "Cancel"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				s.app.SetRoot(flex, true)
+				if buttonLabel == "Import anyway" {
+					s.debugPanel.LogWarn("Importing %d values that fail validation", len(invalid))
+					opts.Validator = nil
+					apply(applyCtx)
+				}
+			})
+		s.app.SetRoot(modal, true)
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if !applying && !applied {
+			render()
+		}
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			// A running import is not interrupted halfway through a batch
+			if !applying {
+				closeView()
+			}
+			return nil
+		case tcell.KeyEnter:
+			if applied {
+				closeView()
+			}
+			return nil
+		case tcell.KeyTab:
+			s.app.SetFocus(textView)
+			return nil
+		}
+
+		if event.Rune() == 'a' && plan != nil && len(pending) > 0 && blocked == 0 && !applying && !applied {
+			// Each prefix protected with confirmation is typed in turn
+			prefixes := confirmPrefixes(refused)
+			var confirmNext func(i int)
+			confirmNext = func(i int) {
+				if i < len(prefixes) {
+					s.confirmProtected(&client.ProtectionError{Key: prefixes[i], Prefix: prefixes[i], Protection: client.ProtectConfirm}, func() {
+						s.app.SetRoot(flex, true)
+					}, func() {
+						confirmNext(i + 1)
+					})
+					return
+				}
+				s.app.SetRoot(flex, true)
+				applyCtx := importCtx
+				if len(prefixes) > 0 {
+					applyCtx = client.WithConfirmation(importCtx)
+				}
+				applyValidated(applyCtx)
+			}
+			confirmNext(0)
+			return nil
+		}
+		return event
+	})
+
+	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc, tcell.KeyTab:
+			s.app.SetFocus(table)
+			return nil
+		}
+		return event
+	})
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+	s.app.SetRoot(flex, true)
+	s.debugPanel.LogInfo("Planning import of %d keys from %s into '%s'", len(entries), path, opts.Prefix)
+
+	go func() {
+		p, err := cli.PlanImport(importCtx, entries, opts, nil)
+
+		s.app.QueueUpdateDraw(func() {
+			if importCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				s.debugPanel.LogError("Failed to plan import: %v", err)
+				textView.SetText(fmt.Sprintf("[red]Failed to read current keys:[white] %s", tview.Escape(err.Error())))
+				return
+			}
+
+			plan = p
+			pending = plan.Pending()
+			invalid = make(map[string]*client.ValidationError)
+			for _, validationErr := range plan.Invalid(opts.Validator) {
+				invalid[validationErr.Key] = validationErr
+			}
+			refused = make(map[string]*client.ProtectionError)
+			for _, protectionErr := range plan.Refused(cli.Guard(), false) {
+				refused[protectionErr.Key] = protectionErr
+				if !errors.Is(protectionErr, client.ErrNotConfirmed) {
+					blocked++
+				}
+			}
+			table.SetTitle(fmt.Sprintf(" Import into %s at revision %d: %s ", tview.Escape(prefixName(opts.Prefix)), plan.Revision, plan.Summary()))
+			fill()
+
+			if len(pending) == 0 {
+				textView.SetText("[green]Nothing to change:[white] the prefix already holds the imported keys")
+				hint.SetText("[green]ESC[-] close")
+				return
+			}
+			switch {
+			case blocked > 0:
+				s.debugPanel.LogWarn("%d imported changes refused by the profile", blocked)
+				hint.SetText(fmt.Sprintf("[red]%d changes refused by the profile[-]  [green]↑/↓[-] select  [green]Tab[-] scroll diff  [green]ESC[-] cancel", blocked))
+			case len(invalid) > 0:
+				s.debugPanel.LogWarn("%d imported values fail validation", len(invalid))
+				hint.SetText(fmt.Sprintf("[red]%d values fail validation[-]  [green]↑/↓[-] select  [green]Tab[-] scroll diff  [green]a[-] apply anyway  [green]ESC[-] cancel", len(invalid)))
+			default:
+				hint.SetText("[green]↑/↓[-] select  [green]Tab[-] scroll diff  [green]a[-] apply  [green]ESC[-] cancel")
+			}
+			table.Select(1, 0)
+			render()
+		})
+	}()
+}
+
+// confirmPrefixes returns the prefixes protected with confirmation that
+// changes were refused by, sorted
+func confirmPrefixes(refused map[string]*client.ProtectionError) []string {
+	seen := make(map[string]bool)
+	var prefixes []string
+	for _, protectionErr := range refused {
+		if errors.Is(protectionErr, client.ErrNotConfirmed) && !seen[protectionErr.Prefix] {
+			seen[protectionErr.Prefix] = true
+			prefixes = append(prefixes, protectionErr.Prefix)
+		}
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// prefixName shows a prefix, naming the empty one
+func prefixName(prefix string) string {
+	if prefix == "" {
+		return "(all keys)"
+	}
+	return prefix
+}
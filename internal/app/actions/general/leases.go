@@ -0,0 +1,352 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// leaseKeysPreview is the number of attached keys listed per lease
+const leaseKeysPreview = 3
+
+// leaseEntry is a lease as listed in the leases view. The remaining TTL
+// counts down from the value reported by etcd at fetched.
+type leaseEntry struct {
+	info    *client.LeaseInfo
+	fetched time.Time
+}
+
+// remaining returns the TTL left at now
+func (e *leaseEntry) remaining(now time.Time) int64 {
+	left := e.info.TTL - int64(now.Sub(e.fetched)/time.Second)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// HandleLeases shows all leases with their TTLs and attached keys. The
+// remaining TTL counts down live; leases without keys are highlighted.
+func (s *State) HandleLeases(ctx context.Context) {
+	if s.connManager.GetClient() == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	s.debugPanel.LogInfo("Loading leases")
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	leasesCtx, cancel := context.WithCancel(ctx)
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(" Leases ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]Enter[-] show keys  [green]k[-] keep alive  [green]x[-] revoke  [green]r[-] refresh  [green]ESC[-] close")
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(hint, 1, 0, false)
+
+	var (
+		entries []*leaseEntry
+		loading bool
+	)
+
+	selected := func() *leaseEntry {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(entries) {
+			return nil
+		}
+		return entries[row-1]
+	}
+
+	// fill lists the leases, updating the countdown in place
+	fill := func() {
+		now := time.Now()
+		orphans := 0
+		for i, e := range entries {
+			remaining := e.remaining(now)
+			color := tcell.ColorWhite
+			switch {
+			case remaining == 0:
+				color = tcell.ColorGray
+			case e.info.IsOrphan():
+				color = tcell.ColorOrange
+			}
+
+			keysText := fmt.Sprintf("%d", len(e.info.Keys))
+			preview := strings.Join(e.info.Keys[:min(len(e.info.Keys), leaseKeysPreview)], ", ")
+			if len(e.info.Keys) > leaseKeysPreview {
+				preview += ", …"
+			}
+			if e.info.IsOrphan() {
+				orphans++
+				preview = "orphan: no keys attached"
+			}
+
+			remainingText := fmt.Sprintf("%ds", remaining)
+			if remaining == 0 {
+				remainingText = "expired"
+			}
+
+			row := i + 1
+			table.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf("%d", e.info.ID)).SetTextColor(color))
+			table.SetCell(row, 1, tview.NewTableCell(fmt.Sprintf("%ds", e.info.GrantedTTL)).SetTextColor(color).SetAlign(tview.AlignRight))
+			table.SetCell(row, 2, tview.NewTableCell(remainingText).SetTextColor(color).SetAlign(tview.AlignRight))
+			table.SetCell(row, 3, tview.NewTableCell(keysText).SetTextColor(color).SetAlign(tview.AlignRight))
+			table.SetCell(row, 4, tview.NewTableCell(tview.Escape(preview)).SetTextColor(color).SetExpansion(1))
+		}
+
+		if orphans > 0 {
+			table.SetTitle(fmt.Sprintf(" Leases (%d, %d orphaned) ", len(entries), orphans))
+		} else {
+			table.SetTitle(fmt.Sprintf(" Leases (%d) ", len(entries)))
+		}
+	}
+
+	// load fetches all leases and keeps the selected one selected
+	load := func() {
+		cli := s.connManager.GetClient()
+		if cli == nil || loading {
+			return
+		}
+		loading = true
+
+		var selectedID int64
+		if e := selected(); e != nil {
+			selectedID = e.info.ID
+		}
+
+		go func() {
+			infos, err := cli.ListLeaseInfos(leasesCtx)
+			fetched := time.Now()
+
+			s.app.QueueUpdateDraw(func() {
+				loading = false
+				if leasesCtx.Err() != nil {
+					return
+				}
+				if err != nil {
+					s.debugPanel.LogError("Failed to list leases: %v", err)
+					s.SetStatusBarText("[red]Failed to list leases:[white] " + err.Error())
+					if len(infos) == 0 {
+						table.Clear()
+						table.SetCell(0, 0, tview.NewTableCell("[red]Failed to list leases:[white] "+tview.Escape(err.Error())).
+							SetSelectable(false))
+						return
+					}
+				}
+
+				entries = entries[:0]
+				for _, info := range infos {
+					entries = append(entries, &leaseEntry{info: info, fetched: fetched})
+				}
+				s.debugPanel.LogInfo("Loaded %d leases", len(entries))
+
+				table.Clear()
+				for col, title := range []string{"ID", "Granted", "Remaining", "Keys", "Attached keys"} {
+					table.SetCell(0, col, tview.NewTableCell(title).
+						SetTextColor(tcell.ColorYellow).
+						SetSelectable(false))
+				}
+				if len(entries) == 0 {
+					table.SetCell(1, 0, tview.NewTableCell("[gray]No leases[-]").SetSelectable(false))
+					table.SetTitle(" Leases (0) ")
+					return
+				}
+
+				fill()
+				table.Select(1, 0)
+				for i, e := range entries {
+					if e.info.ID == selectedID {
+						table.Select(i+1, 0)
+					}
+				}
+			})
+		}()
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyEnter:
+			if e := selected(); e != nil && !e.info.IsOrphan() {
+				closeView()
+				s.showLeaseKeys(ctx, e.info)
+			}
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'r':
+			load()
+			return nil
+		case 'k':
+			if e := selected(); e != nil {
+				s.keepAliveLease(leasesCtx, e, fill)
+			}
+			return nil
+		case 'x':
+			if e := selected(); e != nil {
+				s.confirmRevoke(leasesCtx, flex, e.info, load)
+			}
+			return nil
+		}
+		return event
+	})
+
+	s.app.SetRoot(flex, true)
+	table.SetCell(0, 0, tview.NewTableCell("[gray]Loading leases...[-]").SetSelectable(false))
+	load()
+
+	// Count down once a second; reload when a listed lease expires
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leasesCtx.Done():
+				return
+			case <-ticker.C:
+				s.app.QueueUpdateDraw(func() {
+					if leasesCtx.Err() != nil || len(entries) == 0 {
+						return
+					}
+					fill()
+
+					now := time.Now()
+					for _, e := range entries {
+						if e.remaining(now) == 0 {
+							load()
+							return
+						}
+					}
+				})
+			}
+		}
+	}()
+}
+
+// keepAliveLease renews a lease once and restarts its countdown
+func (s *State) keepAliveLease(ctx context.Context, e *leaseEntry, onDone func()) {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	id := e.info.ID
+	go func() {
+		ttl, err := cli.KeepAliveOnce(ctx, id)
+		s.app.QueueUpdateDraw(func() {
+			if err != nil {
+				s.debugPanel.LogError("Failed to keep alive lease %d: %v", id, err)
+				s.SetStatusBarText("[red]Failed to keep alive:[white] " + err.Error())
+				return
+			}
+			e.info.TTL = ttl
+			e.fetched = time.Now()
+			onDone()
+			s.debugPanel.LogInfo("Renewed lease %d, TTL %ds", id, ttl)
+			s.SetStatusBarText(fmt.Sprintf("[green]Renewed:[white] lease %d for %ds", id, ttl))
+		})
+	}()
+}
+
+// confirmRevoke asks before revoking a lease, which deletes its keys. The
+// profile must allow deleting all of them, and attached keys protected with
+// confirmation have to be typed.
+func (s *State) confirmRevoke(ctx context.Context, leasesView tview.Primitive, info *client.LeaseInfo, onDone func()) {
+	text := fmt.Sprintf("Revoke lease %d?", info.ID)
+	if n := len(info.Keys); n > 0 {
+		text = fmt.Sprintf("Revoke lease %d?\n\nThis deletes %d attached key(s).", info.ID, n)
+	}
+
+	var revoke func(ctx context.Context)
+	revoke = func(ctx context.Context) {
+		cli := s.connManager.GetClient()
+		if cli == nil {
+			s.SetStatusBarText("[red]Not connected to etcd")
+			return
+		}
+
+		err := cli.RevokeLease(ctx, info.ID)
+		var protectionErr *client.ProtectionError
+		if errors.As(err, &protectionErr) && errors.Is(err, client.ErrNotConfirmed) {
+			s.confirmProtected(protectionErr, func() {
+				s.app.SetRoot(leasesView, true)
+			}, func() {
+				s.app.SetRoot(leasesView, true)
+				revoke(client.WithConfirmation(ctx))
+			})
+			return
+		}
+		if err != nil {
+			s.SetStatusBarText("[red]Failed to revoke:[white] " + err.Error())
+			s.debugPanel.LogError("Failed to revoke lease %d: %v", info.ID, err)
+			return
+		}
+
+		s.debugPanel.LogInfo("Revoked lease %d with %d keys", info.ID, len(info.Keys))
+		s.SetStatusBarText(fmt.Sprintf("[green]Revoked:[white] lease %d", info.ID))
+		onDone()
+	}
+
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{"Revoke", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			s.app.SetRoot(leasesView, true)
+			if buttonLabel != "Revoke" {
+				return
+			}
+			revoke(ctx)
+		})
+
+	s.app.SetRoot(modal, true)
+}
+
+// showLeaseKeys replaces the tree with the keys attached to a lease, like
+// search results do.
+func (s *State) showLeaseKeys(ctx context.Context, info *client.LeaseInfo) {
+	kvs := make([]*client.KeyValue, 0, len(info.Keys))
+	for _, key := range info.Keys {
+		kvs = append(kvs, &client.KeyValue{Key: key, Lease: info.ID})
+	}
+
+	// The lease's keys are a different tree; live updates resume on refresh
+	s.StopLive()
+
+	if err := s.keysPanel.LoadKeys(ctx, kvs); err != nil {
+		s.SetStatusBarText(fmt.Sprintf("[red]Failed to load lease keys:[white] %v", err))
+		return
+	}
+
+	s.clearKeyDetails(fmt.Sprintf("[yellow]Keys of lease:[white] %d\n\n[cyan]%d keys attached[-]", info.ID, len(kvs)))
+	s.app.SetFocus(s.keysPanel.GetTree())
+	s.SetStatusBarText(fmt.Sprintf("[green]Lease:[white] %d | [green::b]r[-::-] Back to all keys", info.ID))
+}
@@ -0,0 +1,180 @@
+package general
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/rivo/tview"
+)
+
+// liveHighlight is how long changed tree nodes stay highlighted
+const liveHighlight = 2 * time.Second
+
+// HandleToggleLive turns live tree updates on or off. Turning them on
+// reloads the tree and watches for changes from the revision it was read at.
+func (s *State) HandleToggleLive(ctx context.Context) {
+	if s.liveEnabled {
+		s.liveEnabled = false
+		s.StopLive()
+		s.debugPanel.LogInfo("Live tree updates disabled")
+		s.updateStatusBar(ctx)
+		return
+	}
+
+	s.liveEnabled = true
+	s.debugPanel.LogInfo("Live tree updates enabled")
+	if err := s.RefreshKeys(ctx); err != nil {
+		s.liveEnabled = false
+		s.SetStatusBarText("[red]Failed to start live updates:[white] " + err.Error())
+	}
+}
+
+// IsLive returns true while the tree is updated from a watch.
+func (s *State) IsLive() bool {
+	return s.liveCancel != nil
+}
+
+// StopLive stops the live tree watch. Live mode stays enabled and resumes
+// with the next refresh.
+func (s *State) StopLive() {
+	if s.liveCancel != nil {
+		s.liveCancel()
+		s.liveCancel = nil
+	}
+}
+
+// startLive watches prefix from the revision after the one the tree was
+// read at and applies the changes to the tree in place.
+func (s *State) startLive(ctx context.Context, prefix string, revision int64) {
+	s.StopLive()
+
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return
+	}
+
+	liveCtx, cancel := context.WithCancel(ctx)
+	s.liveCancel = cancel
+	s.debugPanel.LogInfo("Live tree updates from revision %d", revision+1)
+
+	// Events are collected and applied in batches so bursts of writes cause
+	// one redraw instead of one per key
+	var (
+		mu        sync.Mutex
+		pending   []*client.WatchEvent
+		scheduled bool
+	)
+
+	apply := func() {
+		mu.Lock()
+		events := pending
+		pending = nil
+		scheduled = false
+		mu.Unlock()
+
+		if liveCtx.Err() != nil {
+			return
+		}
+		s.applyLiveEvents(ctx, events)
+	}
+
+	go func() {
+		err := cli.WatchPrefixFromRevision(liveCtx, prefix, revision+1, func(event *client.WatchEvent) {
+			if event.Type == client.EventTypeProgress {
+				return
+			}
+
+			mu.Lock()
+			pending = append(pending, event)
+			schedule := !scheduled
+			scheduled = true
+			mu.Unlock()
+
+			if schedule {
+				s.app.QueueUpdateDraw(apply)
+			}
+		})
+
+		if err != nil && liveCtx.Err() == nil {
+			s.app.QueueUpdateDraw(func() {
+				if liveCtx.Err() != nil {
+					return
+				}
+				s.StopLive()
+				s.debugPanel.LogError("Live tree watch failed: %v", err)
+				s.SetStatusBarText("[red]Live updates stopped:[white] " + err.Error() + " | [green::b]r[-::-] Retry")
+			})
+		}
+	}()
+}
+
+// applyLiveEvents updates the tree and the details of the selected key
+// for a batch of watch events.
+func (s *State) applyLiveEvents(ctx context.Context, events []*client.WatchEvent) {
+	var changed []*tview.TreeNode
+	current := s.keysPanel.GetTree().GetCurrentNode()
+	refreshSelected, deletedSelected := false, false
+
+	for _, event := range events {
+		switch event.Type {
+		case client.EventTypeCompacted:
+			// Changes were missed; only a full reload is reliable
+			s.debugPanel.LogWarn("Live tree missed changes before compaction at revision %d, reloading", event.CompactRevision)
+			if err := s.RefreshKeys(ctx); err != nil {
+				s.debugPanel.LogError("Failed to reload keys: %v", err)
+			}
+			return
+
+		case client.EventTypePut:
+			// The tree only keeps key metadata; values are read on selection
+			node := s.keysPanel.ApplyPut(&client.KeyValue{
+				Key:            event.Key,
+				CreateRevision: event.CreateRevision,
+				ModRevision:    event.ModRevision,
+				Version:        event.Version,
+			})
+			if node != nil {
+				changed = append(changed, node)
+			}
+			if s.currentKey != nil && s.currentKey.Key == event.Key {
+				refreshSelected = true
+			}
+
+		case client.EventTypeDelete:
+			if node := s.keysPanel.ApplyDelete(event.Key, event.ModRevision); node != nil {
+				changed = append(changed, node)
+			}
+			if s.currentKey != nil && s.currentKey.Key == event.Key {
+				refreshSelected, deletedSelected = false, true
+				s.currentKey = nil
+			}
+		}
+	}
+
+	// Keep the details panel in line with the selection, which moves to the
+	// parent when the selected node is removed
+	node := s.keysPanel.GetTree().GetCurrentNode()
+	switch {
+	case node != current || deletedSelected || (s.currentKey == nil && slices.Contains(changed, node)):
+		s.showNodeDetails(ctx, node)
+		if deletedSelected {
+			s.SetStatusBarText("[yellow]The selected key was deleted")
+		}
+	case refreshSelected && s.currentKey != nil:
+		if err := s.RefreshKeyDetails(ctx, s.currentKey.Key); err != nil {
+			s.debugPanel.LogWarn("Failed to refresh details: %v", err)
+		}
+	}
+
+	for _, node := range changed {
+		s.keysPanel.Highlight(node)
+		time.AfterFunc(liveHighlight, func() {
+			s.app.QueueUpdateDraw(func() {
+				s.keysPanel.ResetColor(node)
+			})
+		})
+	}
+}
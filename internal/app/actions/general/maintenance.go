@@ -0,0 +1,631 @@
+package general
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// HandleSnapshot asks for a file path and saves a verified snapshot of the
+// etcd database there.
+func (s *State) HandleSnapshot(ctx context.Context) {
+	if s.connManager.GetClient() == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	s.debugPanel.LogInfo("Opening snapshot form")
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	// Helper to close form and restore main view
+	closeForm := func() {
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	form := tview.NewForm()
+
+	defaultPath := fmt.Sprintf("etcd-snapshot-%s.db", time.Now().Format("20060102-150405"))
+	form.AddInputField("File", defaultPath, 50, nil, nil)
+
+	form.AddButton("Save", func() {
+		path := form.GetFormItemByLabel("File").(*tview.InputField).GetText()
+		if path == "" {
+			s.SetStatusBarText("[yellow]Snapshot file is required")
+			return
+		}
+		s.saveSnapshot(ctx, path)
+	})
+
+	form.AddButton("Cancel", func() {
+		closeForm()
+	})
+
+	// Setup ESC to close the form
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			closeForm()
+			return nil
+		}
+		return event
+	})
+
+	form.SetBorder(true).SetTitle(" Save Snapshot (Tab to navigate, ESC cancel) ").SetTitleAlign(tview.AlignLeft)
+	form.SetCancelFunc(closeForm)
+
+	// Set root and focus on first form field
+	s.app.SetRoot(form, true)
+	form.SetFocus(0)
+}
+
+// saveSnapshot streams a snapshot to path while showing progress. ESC
+// cancels the transfer; the target file is only created on success.
+func (s *State) saveSnapshot(ctx context.Context, path string) {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return
+	}
+
+	snapCtx, cancel := context.WithCancel(ctx)
+	done := false
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	progressView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(fmt.Sprintf("[yellow]Saving snapshot to[white] %s\n\n[gray]Waiting for data...[-]", tview.Escape(path)))
+
+	progressView.SetBorder(true).
+		SetTitle(" Snapshot (ESC to cancel) ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+
+	progressView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || (done && event.Key() == tcell.KeyEnter) {
+			if !done {
+				s.SetStatusBarText("[yellow]Snapshot cancelled")
+				s.debugPanel.LogWarn("Snapshot to %s cancelled", path)
+			}
+			closeView()
+			return nil
+		}
+		return event
+	})
+
+	// Center the progress window
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(progressView, 9, 1, true).
+			AddItem(nil, 0, 1, false), 70, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+	s.debugPanel.LogInfo("Saving snapshot to %s", path)
+
+	go func() {
+		started := time.Now()
+		var lastUpdate time.Time
+
+		info, err := cli.SaveSnapshot(snapCtx, path, func(written int64) {
+			// Redraw a few times per second at most
+			if time.Since(lastUpdate) < 200*time.Millisecond {
+				return
+			}
+			lastUpdate = time.Now()
+			s.app.QueueUpdateDraw(func() {
+				progressView.SetText(fmt.Sprintf("[yellow]Saving snapshot to[white] %s\n\n[cyan]Received:[white] %s",
+					tview.Escape(path), client.FormatBytes(written)))
+			})
+		})
+
+		s.app.QueueUpdateDraw(func() {
+			done = true
+			if snapCtx.Err() != nil {
+				return
+			}
+			progressView.SetTitle(" Snapshot (Enter or ESC to close) ")
+
+			if err != nil {
+				s.debugPanel.LogError("Snapshot failed: %v", err)
+				progressView.SetText(fmt.Sprintf("[red]Snapshot failed:[white] %s", tview.Escape(err.Error())))
+				s.SetStatusBarText("[red]Snapshot failed:[white] " + err.Error())
+				return
+			}
+
+			s.debugPanel.LogInfo("Snapshot saved to %s (%d bytes, sha256 %s)", info.Path, info.Size, info.Hash)
+			progressView.SetText(fmt.Sprintf("[green]Snapshot saved and verified[-]\n\n[cyan]File:[white] %s\n[cyan]Size:[white] %s in %s\n[cyan]SHA256:[white] %s",
+				tview.Escape(info.Path), client.FormatBytes(info.Size), time.Since(started).Round(time.Millisecond), info.Hash))
+			s.SetStatusBarText("[green]Snapshot saved:[white] " + info.Path)
+		})
+	}()
+}
+
+// HandleMaintenance shows the members with their database size, alarms and
+// hashes, and runs compaction, defragmentation, alarm disarming and a hash
+// comparison across members.
+func (s *State) HandleMaintenance(ctx context.Context) {
+	if s.connManager.GetClient() == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	s.debugPanel.LogInfo("Opening maintenance")
+
+	// Enable edit mode to bypass global input capture
+	s.SetEditMode(true)
+
+	maintCtx, cancel := context.WithCancel(ctx)
+
+	closeView := func() {
+		cancel()
+		s.SetEditMode(false)
+		s.app.SetRoot(s.rootFlex, true)
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(" Maintenance ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorYellow)
+	table.SetCell(0, 0, tview.NewTableCell("[gray]Loading members...[-]").SetSelectable(false))
+
+	logView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	logView.SetBorder(true).
+		SetTitle(" Log ").
+		SetTitleAlign(tview.AlignLeft)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]c[-] compact  [green]d[-] defrag member  [green]D[-] defrag all  [green]x[-] disarm alarms  [green]h[-] hash check  [green]r[-] refresh  [green]ESC[-] close")
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(logView, 0, 1, false).
+		AddItem(hint, 1, 0, false)
+
+	var (
+		status *client.ClusterStatus
+		alarms []*client.Alarm
+		check  *client.HashCheck
+		busy   bool
+	)
+
+	logf := func(format string, args ...interface{}) {
+		_, _ = fmt.Fprintf(logView, "[gray]%s[-] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+		logView.ScrollToEnd()
+	}
+
+	memberAlarms := func(id uint64) []*client.Alarm {
+		var result []*client.Alarm
+		for _, a := range alarms {
+			if a.MemberID == id {
+				result = append(result, a)
+			}
+		}
+		return result
+	}
+
+	selected := func() *client.MemberStatus {
+		if status == nil {
+			return nil
+		}
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(status.Members) {
+			return nil
+		}
+		return status.Members[row-1]
+	}
+
+	// fill lists the members with sizes, alarms and the last hash check
+	fill := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+		for col, title := range []string{"Name", "Endpoint", "DB size", "In use", "Free", "Alarms", "Hash"} {
+			table.SetCell(0, col, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+
+		hashes := make(map[uint64]*client.EndpointHash)
+		if check != nil {
+			for _, h := range check.Hashes {
+				hashes[h.MemberID] = h
+			}
+		}
+
+		for i, m := range status.Members {
+			color := tcell.ColorWhite
+			cells := []string{tview.Escape(memberName(m)), tview.Escape(m.Endpoint), "", "", ""}
+
+			switch st := m.Status; {
+			case st == nil:
+				color = tcell.ColorGray
+			case st.Err != nil:
+				color = tcell.ColorRed
+				cells[2] = "unreachable"
+			default:
+				cells[2] = client.FormatBytes(st.DBSize)
+				cells[3] = client.FormatBytes(st.DBSizeInUse)
+				cells[4] = client.FormatBytes(st.DBSize - st.DBSizeInUse)
+			}
+
+			alarmText := "-"
+			if ma := memberAlarms(m.ID); len(ma) > 0 {
+				names := make([]string, 0, len(ma))
+				for _, a := range ma {
+					names = append(names, a.Type)
+				}
+				alarmText = strings.Join(names, ", ")
+				color = tcell.ColorRed
+			}
+			cells = append(cells, alarmText)
+
+			hashText := "-"
+			if h, ok := hashes[m.ID]; ok {
+				if h.Err != nil {
+					hashText = "error"
+				} else {
+					hashText = fmt.Sprintf("%08x (compacted %d)", h.Hash, h.CompactRevision)
+				}
+			}
+			cells = append(cells, hashText)
+
+			for col, text := range cells {
+				cell := tview.NewTableCell(text).SetTextColor(color)
+				if col >= 2 && col <= 4 {
+					cell.SetAlign(tview.AlignRight)
+				}
+				if col == len(cells)-1 {
+					cell.SetExpansion(1)
+				}
+				table.SetCell(i+1, col, cell)
+			}
+		}
+
+		table.SetTitle(fmt.Sprintf(" Maintenance: %d members, %d alarms ", len(status.Members), len(alarms)))
+		if row < 1 || row > len(status.Members) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	// load fetches members and alarms
+	load := func() {
+		cli := s.connManager.GetClient()
+		if cli == nil {
+			return
+		}
+
+		go func() {
+			st, err := cli.GetClusterStatus(maintCtx)
+			var al []*client.Alarm
+			if err == nil {
+				al, err = cli.ListAlarms(maintCtx)
+			}
+
+			s.app.QueueUpdateDraw(func() {
+				if maintCtx.Err() != nil {
+					return
+				}
+				if err != nil {
+					s.debugPanel.LogError("Failed to load maintenance data: %v", err)
+					logf("[red]Failed to load members:[white] %s", tview.Escape(err.Error()))
+					return
+				}
+				status, alarms = st, al
+				fill()
+			})
+		}()
+	}
+
+	// run starts a long running operation unless one is already running
+	run := func(name string, op func(cli *client.Client)) {
+		cli := s.connManager.GetClient()
+		if cli == nil {
+			s.SetStatusBarText("[red]Not connected to etcd")
+			return
+		}
+		if busy {
+			logf("[yellow]Wait for the running operation to finish[-]")
+			return
+		}
+		busy = true
+		logf("%s...", name)
+
+		go func() {
+			op(cli)
+			s.app.QueueUpdateDraw(func() {
+				busy = false
+			})
+			load()
+		}()
+	}
+
+	// defrag defragments members one at a time and stops at the first error
+	defrag := func(members []*client.MemberStatus) {
+		run("Defragmenting", func(cli *client.Client) {
+			for _, m := range members {
+				started := time.Now()
+				err := cli.Defragment(maintCtx, m.Endpoint)
+				s.app.QueueUpdateDraw(func() {
+					if err != nil {
+						s.debugPanel.LogError("Defragmentation of %s failed: %v", m.Endpoint, err)
+						logf("[red]Defragmentation of %s failed:[white] %s", tview.Escape(memberName(m)), tview.Escape(err.Error()))
+						return
+					}
+					s.debugPanel.LogInfo("Defragmented %s in %s", m.Endpoint, time.Since(started))
+					logf("[green]Defragmented[-] %s in %s", tview.Escape(memberName(m)), time.Since(started).Round(time.Millisecond))
+				})
+				if err != nil {
+					return
+				}
+			}
+		})
+	}
+
+	confirm := func(text, button string, onConfirm func()) {
+		modal := tview.NewModal().
+			SetText(text).
+			AddButtons([]string{button, "Cancel"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				s.app.SetRoot(flex, true)
+				s.app.SetFocus(table)
+				if buttonLabel == button {
+					onConfirm()
+				}
+			})
+		s.app.SetRoot(modal, true)
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab:
+			s.app.SetFocus(logView)
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'r':
+			load()
+			return nil
+		case 'c':
+			s.showCompactForm(maintCtx, flex, func(retention int64) {
+				run("Compacting", func(cli *client.Client) {
+					current, target, err := cli.CompactionRevision(maintCtx, retention)
+					if err == nil && target > 0 {
+						err = cli.CompactHistory(maintCtx, target)
+					}
+					s.app.QueueUpdateDraw(func() {
+						if err == nil && target == 0 {
+							logf("[yellow]Nothing to compact:[-] revision %d is within the retention of %d", current, retention)
+							return
+						}
+						if err != nil {
+							s.debugPanel.LogError("Compaction failed: %v", err)
+							logf("[red]Compaction failed:[white] %s", tview.Escape(err.Error()))
+							return
+						}
+						s.debugPanel.LogInfo("Compacted to revision %d", target)
+						logf("[green]Compacted[-] to revision %d, keeping %d of %d revisions", target, current-target, current)
+					})
+				})
+			})
+			return nil
+		case 'd':
+			m := selected()
+			if m == nil || m.Status == nil || m.Status.Err != nil {
+				logf("[yellow]Select a reachable member to defragment[-]")
+				return nil
+			}
+			confirm(fmt.Sprintf("Defragment %s (%s)?\n\nThe member blocks reads and writes while it runs.", memberName(m), m.Endpoint), "Defragment", func() {
+				defrag([]*client.MemberStatus{m})
+			})
+			return nil
+		case 'D':
+			if status == nil {
+				return nil
+			}
+			var members []*client.MemberStatus
+			for _, m := range status.Members {
+				if m.Status != nil && m.Status.Err == nil {
+					members = append(members, m)
+				}
+			}
+			if len(members) == 0 {
+				logf("[yellow]No reachable members to defragment[-]")
+				return nil
+			}
+			confirm(fmt.Sprintf("Defragment %d member(s) one at a time?\n\nEach member blocks reads and writes while it runs.", len(members)), "Defragment", func() {
+				defrag(members)
+			})
+			return nil
+		case 'x':
+			m := selected()
+			if m == nil {
+				return nil
+			}
+			ma := memberAlarms(m.ID)
+			if len(ma) == 0 {
+				logf("[yellow]%s has no alarms[-]", tview.Escape(memberName(m)))
+				return nil
+			}
+			confirm(fmt.Sprintf("Disarm %d alarm(s) of %s?\n\nDisarm NOSPACE only after freeing space (compact and defragment).", len(ma), memberName(m)), "Disarm", func() {
+				run("Disarming alarms", func(cli *client.Client) {
+					for _, a := range ma {
+						err := cli.DisarmAlarm(maintCtx, a)
+						s.app.QueueUpdateDraw(func() {
+							if err != nil {
+								s.debugPanel.LogError("Failed to disarm alarm: %v", err)
+								logf("[red]Failed to disarm %s:[white] %s", a.Type, tview.Escape(err.Error()))
+								return
+							}
+							s.debugPanel.LogInfo("Disarmed %s alarm of %x", a.Type, a.MemberID)
+							logf("[green]Disarmed[-] %s alarm of %s", a.Type, tview.Escape(memberName(m)))
+						})
+					}
+				})
+			})
+			return nil
+		case 'h':
+			run("Comparing hashes", func(cli *client.Client) {
+				result, err := cli.CompareHashes(maintCtx)
+				s.app.QueueUpdateDraw(func() {
+					if err != nil {
+						s.debugPanel.LogError("Hash check failed: %v", err)
+						logf("[red]Hash check failed:[white] %s", tview.Escape(err.Error()))
+						return
+					}
+					check = result
+					for _, h := range result.Hashes {
+						if h.Err != nil {
+							logf("[red]Hash of %s failed:[white] %s", tview.Escape(h.Endpoint), tview.Escape(h.Err.Error()))
+						}
+					}
+					switch {
+					case result.Mismatch:
+						s.debugPanel.LogError("Hash mismatch at revision %d", result.Revision)
+						logf("[red::b]Data inconsistent:[-::-] members report different hashes at revision %d", result.Revision)
+					case result.Incomplete:
+						logf("[yellow]Hash check incomplete[-] at revision %d: not all members could be compared", result.Revision)
+					default:
+						logf("[green]Consistent:[-] %d members have the same hash at revision %d", len(result.Hashes), result.Revision)
+					}
+					if status != nil {
+						fill()
+					}
+				})
+			})
+			return nil
+		}
+		return event
+	})
+
+	logView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeView()
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab:
+			s.app.SetFocus(table)
+			return nil
+		}
+		return event
+	})
+
+	s.app.SetRoot(flex, true)
+	load()
+}
+
+// showCompactForm asks for the number of revisions to keep and shows the
+// resulting compaction revision once the current revision is loaded in the
+// background
+func (s *State) showCompactForm(ctx context.Context, maintenanceView tview.Primitive, onCompact func(retention int64)) {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return
+	}
+
+	var (
+		current int64
+		loadErr error
+	)
+
+	back := func() {
+		s.app.SetRoot(maintenanceView, true)
+	}
+
+	form := tview.NewForm()
+	info := tview.NewTextView().SetDynamicColors(true)
+
+	parseRetention := func(text string) (int64, bool) {
+		retention, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		return retention, err == nil && retention >= 0
+	}
+
+	describe := func(text string) {
+		retention, ok := parseRetention(text)
+		if !ok {
+			info.SetText("[red]Retention must be a number of revisions[-]")
+			return
+		}
+		switch {
+		case loadErr != nil:
+			info.SetText("[red]Failed to get revision:[white] " + tview.Escape(loadErr.Error()))
+			return
+		case current == 0:
+			info.SetText("[yellow]Loading current revision...[-]")
+			return
+		}
+		target := current - retention
+		if target <= 1 {
+			info.SetText(fmt.Sprintf("[yellow]Current revision:[white] %d\n\n[gray]Nothing to compact with this retention.[-]", current))
+			return
+		}
+		info.SetText(fmt.Sprintf("[yellow]Current revision:[white] %d\n[yellow]Compact to:[white] %d\n\n[gray]History before the target revision is removed; watches and history older than it fail.[-]", current, target))
+	}
+
+	form.AddInputField("Keep revisions", strconv.FormatInt(client.DefaultCompactionRetention, 10), 20, tview.InputFieldInteger, describe)
+	form.AddButton("Compact", func() {
+		retention, ok := parseRetention(form.GetFormItemByLabel("Keep revisions").(*tview.InputField).GetText())
+		if !ok {
+			return
+		}
+		back()
+		onCompact(retention)
+	})
+	form.AddButton("Cancel", back)
+	form.SetCancelFunc(back)
+	describe(strconv.FormatInt(client.DefaultCompactionRetention, 10))
+
+	content := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 5, 0, true).
+		AddItem(info, 0, 1, false)
+	content.SetBorder(true).
+		SetTitle(" Compact History (ESC to cancel) ").
+		SetTitleAlign(tview.AlignLeft)
+
+	// Center the form window
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(content, 12, 1, true).
+			AddItem(nil, 0, 1, false), 70, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+
+	go func() {
+		rev, err := cli.CurrentRevision(ctx)
+		s.app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			current, loadErr = rev, err
+			describe(form.GetFormItemByLabel("Keep revisions").(*tview.InputField).GetText())
+		})
+	}()
+}
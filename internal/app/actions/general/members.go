@@ -0,0 +1,295 @@
+package general
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Member changes offered by the cluster view
+const (
+	memberAdd        = "add"
+	memberRemove     = "remove"
+	memberPromote    = "promote"
+	memberMoveLeader = "move leader"
+)
+
+// memberChange is a membership change waiting for confirmation
+type memberChange struct {
+	action string
+	status *client.ClusterStatus
+
+	// target is the member the change applies to, nil when adding
+	target *client.MemberStatus
+
+	// name, peerURLs and learner describe the member to add
+	name     string
+	peerURLs []string
+	learner  bool
+}
+
+// confirmText returns what has to be typed to confirm the change
+func (c *memberChange) confirmText() string {
+	if c.target == nil {
+		return c.name
+	}
+	if c.target.Name != "" {
+		return c.target.Name
+	}
+	return fmt.Sprintf("%x", c.target.ID)
+}
+
+// title describes the change in one line
+func (c *memberChange) title() string {
+	switch c.action {
+	case memberAdd:
+		kind := "voting member"
+		if c.learner {
+			kind = "learner"
+		}
+		return fmt.Sprintf("Add %s %s (%s)", kind, c.name, strings.Join(c.peerURLs, ", "))
+	case memberMoveLeader:
+		return "Move leader to " + memberName(c.target)
+	default:
+		return fmt.Sprintf("%s %s (%x)", strings.ToUpper(c.action[:1])+c.action[1:], memberName(c.target), c.target.ID)
+	}
+}
+
+// preview lists the members as they will be after the change
+func (c *memberChange) preview() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "[yellow::b]%s[-::-]\n\n", tview.Escape(c.title()))
+	b.WriteString("[yellow]Members afterwards:[-]\n")
+
+	for _, m := range c.status.Members {
+		role := m.Role()
+		marker, color := " ", "white"
+		if c.target != nil && m.ID == c.target.ID {
+			switch c.action {
+			case memberRemove:
+				marker, color, role = "-", "red", "removed"
+			case memberPromote:
+				marker, color, role = "~", "green", "follower (promoted)"
+			case memberMoveLeader:
+				marker, color, role = "~", "green", "leader"
+			}
+		} else if c.action == memberMoveLeader && m.IsLeader {
+			marker, color, role = "~", "yellow", "follower"
+		}
+		fmt.Fprintf(&b, "[%s]%s %-16s %016x  %-20s %s[-]\n", color, marker, tview.Escape(memberName(m)), m.ID, role, tview.Escape(strings.Join(m.PeerURLs, ",")))
+	}
+
+	if c.action == memberAdd {
+		role := "follower (unstarted)"
+		if c.learner {
+			role = "learner (unstarted)"
+		}
+		fmt.Fprintf(&b, "[green]+ %-16s %16s  %-20s %s[-]\n", tview.Escape(c.name), "(new)", role, tview.Escape(strings.Join(c.peerURLs, ",")))
+	}
+
+	if warnings := c.warnings(); len(warnings) > 0 {
+		b.WriteString("\n")
+		for _, w := range warnings {
+			fmt.Fprintf(&b, "[red]![-] %s\n", tview.Escape(w))
+		}
+	}
+
+	return b.String()
+}
+
+// warnings returns the risks of the change, mainly to quorum
+func (c *memberChange) warnings() []string {
+	voters, healthy := 0, 0
+	for _, m := range c.status.Members {
+		if m.IsLearner {
+			continue
+		}
+		voters++
+		if m.Started() && m.Status != nil && m.Status.Err == nil {
+			healthy++
+		}
+	}
+
+	var warnings []string
+	switch c.action {
+	case memberAdd:
+		if !c.learner {
+			voters++
+			warnings = append(warnings, "A voting member counts towards quorum before it has started; adding it as a learner and promoting it later is safer")
+		}
+	case memberRemove:
+		if !c.target.IsLearner {
+			voters--
+			if c.target.Role() != "unreachable" && c.target.Started() {
+				healthy--
+			}
+		}
+		if c.target.IsLeader {
+			warnings = append(warnings, "This member is the leader; the cluster elects a new one after the removal")
+		}
+		if voters == 0 {
+			warnings = append(warnings, "This is the last voting member")
+		}
+	case memberPromote:
+		voters++
+		if c.target.Started() && c.target.Status != nil && c.target.Status.Err == nil {
+			healthy++
+		}
+	}
+
+	if quorum := voters/2 + 1; voters > 0 && healthy < quorum {
+		warnings = append(warnings, fmt.Sprintf("Only %d of %d voting members are reachable afterwards; %d are needed for quorum", healthy, voters, quorum))
+	}
+	return warnings
+}
+
+// apply performs the change and returns a message describing the result
+func (c *memberChange) apply(ctx context.Context, cli *client.Client) (string, error) {
+	switch c.action {
+	case memberAdd:
+		added, members, err := cli.AddMember(ctx, c.peerURLs, c.learner)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[green]Added member %x.[-] Start it with:\n\n"+
+			"ETCD_NAME=%q\nETCD_INITIAL_CLUSTER=%q\nETCD_INITIAL_ADVERTISE_PEER_URLS=%q\nETCD_INITIAL_CLUSTER_STATE=\"existing\"",
+			added.ID, c.name, client.InitialCluster(members, added.ID, c.name), strings.Join(c.peerURLs, ",")), nil
+	case memberRemove:
+		return fmt.Sprintf("[green]Removed member[-] %s", memberName(c.target)), cli.RemoveMember(ctx, c.target.ID)
+	case memberPromote:
+		return fmt.Sprintf("[green]Promoted[-] %s to a voting member", memberName(c.target)), cli.PromoteMember(ctx, c.target.ID)
+	case memberMoveLeader:
+		return fmt.Sprintf("[green]Moved leader to[-] %s", memberName(c.target)), cli.MoveLeader(ctx, c.target.ID)
+	}
+	return "", fmt.Errorf("unknown member change: %s", c.action)
+}
+
+// showAddMemberForm asks for the name and peer URLs of a new member
+func (s *State) showAddMemberForm(clusterView tview.Primitive, status *client.ClusterStatus, onConfirm func(change *memberChange)) {
+	back := func() {
+		s.app.SetRoot(clusterView, true)
+	}
+
+	form := tview.NewForm()
+	form.AddInputField("Name", "", 40, nil, nil)
+	form.AddInputField("Peer URLs", "", 40, nil, nil)
+	form.AddCheckbox("Learner", true, nil)
+
+	form.AddButton("Preview", func() {
+		name := strings.TrimSpace(form.GetFormItemByLabel("Name").(*tview.InputField).GetText())
+		var peerURLs []string
+		for _, u := range strings.Split(form.GetFormItemByLabel("Peer URLs").(*tview.InputField).GetText(), ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				peerURLs = append(peerURLs, u)
+			}
+		}
+		if name == "" || len(peerURLs) == 0 {
+			form.SetTitle(" Add Member: [red]name and peer URLs are required[-] ")
+			return
+		}
+
+		onConfirm(&memberChange{
+			action:   memberAdd,
+			status:   status,
+			name:     name,
+			peerURLs: peerURLs,
+			learner:  form.GetFormItemByLabel("Learner").(*tview.Checkbox).IsChecked(),
+		})
+	})
+	form.AddButton("Cancel", back)
+	form.SetCancelFunc(back)
+
+	form.SetBorder(true).
+		SetTitle(" Add Member (peer URLs comma separated, ESC cancel) ").
+		SetTitleAlign(tview.AlignLeft)
+
+	// Center the form window
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 11, 1, true).
+			AddItem(nil, 0, 1, false), 70, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+}
+
+// confirmMemberChange previews a membership change and applies it once
+// the member's name has been typed. onDone is called with the result.
+func (s *State) confirmMemberChange(ctx context.Context, clusterView tview.Primitive, change *memberChange, onDone func(message string, err error)) {
+	back := func() {
+		s.app.SetRoot(clusterView, true)
+	}
+
+	preview := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(change.preview())
+
+	form := tview.NewForm()
+	form.AddInputField(tview.Escape(fmt.Sprintf("Type %q to confirm", change.confirmText())), "", 30, nil, nil)
+
+	applying := false
+	form.AddButton("Apply", func() {
+		if applying {
+			return
+		}
+		typed := form.GetFormItem(0).(*tview.InputField).GetText()
+		if typed != change.confirmText() {
+			form.SetTitle(" [red]Confirmation does not match[-] ")
+			return
+		}
+
+		cli := s.connManager.GetClient()
+		if cli == nil {
+			back()
+			onDone("", fmt.Errorf("not connected to etcd"))
+			return
+		}
+
+		applying = true
+		form.SetTitle(" Applying... ")
+		s.debugPanel.LogInfo("Member change: %s", change.title())
+
+		go func() {
+			message, err := change.apply(ctx, cli)
+			s.app.QueueUpdateDraw(func() {
+				if err != nil {
+					s.debugPanel.LogError("Member change failed: %v", err)
+				} else {
+					s.debugPanel.LogInfo("Member change done: %s", change.title())
+				}
+				back()
+				onDone(message, err)
+			})
+		}()
+	})
+	form.AddButton("Cancel", back)
+	form.SetCancelFunc(back)
+	form.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+
+	content := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(preview, 0, 1, false).
+		AddItem(form, 7, 0, true)
+	content.SetBorder(true).
+		SetTitle(" Confirm Membership Change (ESC cancel) ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorRed)
+
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(content, len(change.status.Members)+len(change.warnings())+14, 1, true).
+			AddItem(nil, 0, 1, false), 110, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+}
@@ -0,0 +1,123 @@
+package general
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// WriteOptions relax the checks PutKey makes once the user agreed
+type WriteOptions struct {
+	// SkipValidation writes values that fail the validation rules
+	SkipValidation bool
+
+	// Confirmed is set once the user typed the key of a write to a prefix
+	// protected with confirmation
+	Confirmed bool
+}
+
+// guard returns the guard the connected client checks every change with,
+// nil if not connected. It is only read to show what is protected; the
+// client refuses the changes itself.
+func (s *State) guard() *client.Guard {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		return nil
+	}
+	return cli.Guard()
+}
+
+// keyProtection returns the strictest protected prefix key is under
+func (s *State) keyProtection(key string) client.ProtectedPrefix {
+	return s.guard().Protection(key)
+}
+
+// treeProtection returns the protection of a key or directory as the keys
+// tree marks it
+func (s *State) treeProtection(path string) client.Protection {
+	return s.keyProtection(path).Protection
+}
+
+// checkProtection returns false and explains why in the status bar if the
+// client would refuse writing key, or deleting it with del set, even after
+// a confirmation, so no form is opened for it
+func (s *State) checkProtection(key string, del bool) bool {
+	err := s.guard().CheckPut(key, true)
+	if del {
+		err = s.guard().CheckDelete(key, true)
+	}
+	if err == nil {
+		return true
+	}
+	s.SetStatusBarText("[red]Protected:[white] " + details.EscapeText(err.Error()))
+	s.debugPanel.LogWarn("Change refused: %v", err)
+	return false
+}
+
+// confirmedContext returns ctx allowing changes to keys protected with
+// confirmation if the user confirmed them
+func confirmedContext(ctx context.Context, confirmed bool) context.Context {
+	if confirmed {
+		return client.WithConfirmation(ctx)
+	}
+	return ctx
+}
+
+// confirmProtected asks the user to type the key of a change to a prefix
+// protected with confirmation, or the prefix itself for bulk changes, and
+// calls onConfirm once it matches. Cancel and ESC call back.
+func (s *State) confirmProtected(protectionErr *client.ProtectionError, back, onConfirm func()) {
+	action := "write"
+	if protectionErr.Delete {
+		action = "delete"
+	}
+
+	text := fmt.Sprintf("[yellow]%s[-] is below [red]%s[-], which is protected with confirmation.\n\nType the key to %s it.",
+		details.EscapeText(protectionErr.Key), details.EscapeText(protectionErr.Prefix), action)
+	if protectionErr.Key == protectionErr.Prefix {
+		// Bulk changes confirm the protected prefix itself
+		text = fmt.Sprintf("Keys below [red]%s[-] are protected with confirmation.\n\nType the prefix to change them.",
+			details.EscapeText(protectionErr.Prefix))
+	}
+	message := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetText(text)
+
+	form := tview.NewForm()
+	form.AddInputField("Confirm", "", 50, nil, nil)
+	form.AddButton("Confirm", func() {
+		if form.GetFormItem(0).(*tview.InputField).GetText() != protectionErr.Key {
+			form.SetTitle(" [red]Confirmation does not match[-] ")
+			return
+		}
+		s.debugPanel.LogInfo("Confirmed %s of protected key %s", action, protectionErr.Key)
+		onConfirm()
+	})
+	form.AddButton("Cancel", back)
+	form.SetCancelFunc(back)
+	form.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+
+	content := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(message, 0, 1, false).
+		AddItem(form, 7, 0, true)
+	content.SetBorder(true).
+		SetTitle(" Protected Key (ESC cancel) ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorRed)
+
+	flex := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(content, 14, 1, true).
+			AddItem(nil, 0, 1, false), 80, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	s.app.SetRoot(flex, true)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
 	"github.com/alex-dev-master/etcdtui/internal/ui/panels/keys"
 	"github.com/alex-dev-master/etcdtui/internal/ui/panels/statusbar"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/watches"
 	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -22,6 +23,7 @@ type State struct {
 	detailsPanel   *details.Panel
 	statusBarPanel *statusbar.Panel
 	debugPanel     *debug.Panel
+	watchesPanel   *watches.Panel
 
 	// Connection
 	connManager *etcd.Manager
@@ -30,14 +32,25 @@ type State struct {
 	profile       *config.Profile
 	configManager *config.Manager
 
+	// saveProfile saves profile to the config file once connected
+	saveProfile bool
+
 	// Current state
-	currentKey  *client.KeyValue
-	inEditMode  bool
-	watchCancel context.CancelFunc // Cancel function for active watch
+	currentKey   *client.KeyValue
+	currentLease *client.LeaseInfo // Lease of the current key, fetched with its value
+	detailsID    int               // Incremented whenever the details panel shows something else
+	inEditMode   bool
+	watchCancels map[int]context.CancelFunc // Cancel functions of running watches by watch ID
+	loadCancel   context.CancelFunc         // Cancel function for key listing in progress
+	loadID       int                        // Incremented for every key listing
+	statusID     int                        // Incremented for every status bar refresh
+	liveEnabled  bool                       // Live tree updates requested by the user
+	liveCancel   context.CancelFunc         // Cancel function for the live tree watch
 
 	// App reference for UI operations
 	app          *tview.Application
 	rootFlex     *tview.Flex
+	contentFlex  *tview.Flex
 	inputCapture func(event *tcell.EventKey) *tcell.EventKey
 }
 
@@ -48,7 +61,9 @@ func NewState() *State {
 		detailsPanel:   details.New(),
 		statusBarPanel: statusbar.New(),
 		debugPanel:     debug.New(),
+		watchesPanel:   watches.New(),
 		connManager:    etcd.NewManager(),
+		watchCancels:   make(map[int]context.CancelFunc),
 	}
 }
 
@@ -57,6 +72,12 @@ func (s *State) SetProfile(profile *config.Profile) {
 	s.profile = profile
 }
 
+// SetSaveProfile makes the connection save the profile to the config file
+// once it succeeds, for profiles built from command line flags
+func (s *State) SetSaveProfile(save bool) {
+	s.saveProfile = save
+}
+
 // GetProfile returns the current profile
 func (s *State) GetProfile() *config.Profile {
 	return s.profile
@@ -147,6 +168,11 @@ func (s *State) GetDebugPanel() *debug.Panel {
 	return s.debugPanel
 }
 
+// GetWatchesPanel returns the watches panel.
+func (s *State) GetWatchesPanel() *watches.Panel {
+	return s.watchesPanel
+}
+
 // GetConnectionManager returns the connection manager.
 func (s *State) GetConnectionManager() *etcd.Manager {
 	return s.connManager
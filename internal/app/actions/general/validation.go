@@ -0,0 +1,145 @@
+package general
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// validator returns the validation rules of the config file. Schema files
+// are read again for every write, so changes to them apply at once.
+func (s *State) validator() (*client.Validator, error) {
+	if s.configManager == nil {
+		return nil, nil
+	}
+	v, err := s.configManager.Validator()
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation rules: %w", err)
+	}
+	return v, nil
+}
+
+// validateValue checks value against the validation rules matching key
+func (s *State) validateValue(key string, value []byte) error {
+	v, err := s.validator()
+	if err != nil {
+		return err
+	}
+	return v.Validate(key, value)
+}
+
+// showValidationFailure lists the problems of a value that failed
+// validation next to the value with the offending lines marked. ESC returns
+// to form; the value is only saved anyway, by override, after a
+// confirmation.
+func (s *State) showValidationFailure(key, value string, validationErr *client.ValidationError, form tview.Primitive, override func()) {
+	marked := make(map[int]bool)
+	var issues strings.Builder
+	for _, issue := range validationErr.Issues {
+		if issue.Line > 0 {
+			marked[issue.Line] = true
+			fmt.Fprintf(&issues, "[red]line %d:[-] ", issue.Line)
+		}
+		if issue.Path != "" {
+			fmt.Fprintf(&issues, "[aqua]%s[-] ", details.EscapeText(issue.Path))
+		}
+		issues.WriteString(details.EscapeText(issue.Message) + "\n")
+	}
+
+	issuesView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(strings.TrimSuffix(issues.String(), "\n"))
+	issuesView.SetBorder(true).
+		SetTitle(fmt.Sprintf(" %d problems ", len(validationErr.Issues))).
+		SetTitleAlign(tview.AlignLeft)
+
+	valueView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(numberedValue(value, marked))
+	valueView.SetBorder(true).
+		SetTitle(" Value ").
+		SetTitleAlign(tview.AlignLeft)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]o[-] save anyway  [green]Tab[-] next pane  [green]↑/↓[-] scroll  [green]ESC[-] back to editing")
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(issuesView, 0, 1, true).
+		AddItem(valueView, 0, 2, false).
+		AddItem(hint, 1, 0, false)
+	flex.SetBorder(true).
+		SetTitle(" Invalid value: " + details.EscapeText(key) + " ").
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderColor(tcell.ColorRed)
+
+	flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			s.app.SetRoot(form, true)
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab:
+			if issuesView.HasFocus() {
+				s.app.SetFocus(valueView)
+			} else {
+				s.app.SetFocus(issuesView)
+			}
+			return nil
+		}
+		if event.Rune() == 'o' {
+			modal := tview.NewModal().
+				SetText(fmt.Sprintf("Save %s although its value fails validation?", key)).
+				AddButtons([]string{"Save anyway", "Cancel"}).
+				SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+					if buttonLabel != "Save anyway" {
+						s.app.SetRoot(flex, true)
+						return
+					}
+					override()
+				})
+			s.app.SetRoot(modal, true)
+			return nil
+		}
+		return event
+	})
+
+	s.SetStatusBarText("[red]Not saved:[white] " + key + " fails validation")
+	s.app.SetRoot(flex, true)
+}
+
+// validationSummary lists the problems of a value for a modal, at most
+// limit of them
+func validationSummary(validationErr *client.ValidationError, limit int) string {
+	var lines []string
+	for i, issue := range validationErr.Issues {
+		if i == limit {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(validationErr.Issues)-limit))
+			break
+		}
+		lines = append(lines, issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// numberedValue shows text with line numbers for a TextView with dynamic
+// colors, marking the lines in marked red
+func numberedValue(text string, marked map[int]bool) string {
+	lines := strings.Split(text, "\n")
+	width := len(fmt.Sprint(len(lines)))
+	for i, line := range lines {
+		number := fmt.Sprintf("%*d", width, i+1)
+		if marked[i+1] {
+			lines[i] = fmt.Sprintf("[red]%s >[-] %s", number, details.EscapeText(line))
+		} else {
+			lines[i] = fmt.Sprintf("[gray]%s │[-] %s", number, details.EscapeText(line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,170 @@
+package general
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/details"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/keys"
+	"github.com/alex-dev-master/etcdtui/internal/ui/panels/watches"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/rivo/tview"
+)
+
+// HandleWatch starts a watch on the selected node in the watches pane: a
+// key watch for keys and a prefix watch for directories.
+func (s *State) HandleWatch(ctx context.Context) {
+	n := keys.GetNode(s.keysPanel.GetTree().GetCurrentNode())
+	if n == nil || (n.KV == nil && !n.IsDir()) {
+		s.SetStatusBarText("[yellow]Select a key or directory to watch")
+		return
+	}
+
+	target, prefix := n.Prefix, true
+	if n.KV != nil {
+		target, prefix = n.KV.Key, false
+	}
+
+	if w := s.watchesPanel.Find(target, prefix); w != nil {
+		s.watchesPanel.Select(w)
+		s.showWatchesPanel()
+		s.SetStatusBarText("[yellow]Already watching:[white] " + details.EscapeText(w.Label()))
+		return
+	}
+
+	s.startWatch(ctx, target, prefix)
+}
+
+// startWatch runs a watch in the background and feeds its events to the
+// watches pane.
+func (s *State) startWatch(ctx context.Context, target string, prefix bool) {
+	cli := s.connManager.GetClient()
+	if cli == nil {
+		s.SetStatusBarText("[red]Not connected to etcd")
+		return
+	}
+
+	w := s.watchesPanel.Add(target, prefix)
+	watchCtx, cancel := context.WithCancel(ctx)
+	s.watchCancels[w.ID] = cancel
+
+	s.showWatchesPanel()
+	s.debugPanel.LogInfo("Watch %d started on %s", w.ID, w.Label())
+	s.SetStatusBarText("[green]Watching:[white] " + details.EscapeText(w.Label()))
+	s.watchesPanel.SetStatus(w, "watching")
+
+	go func() {
+		callback := func(event *client.WatchEvent) {
+			s.app.QueueUpdateDraw(func() {
+				if watchCtx.Err() != nil {
+					return
+				}
+				switch event.Type {
+				case client.EventTypeProgress:
+					s.watchesPanel.SetStatus(w, fmt.Sprintf("alive at rev %d, %s", event.Revision, time.Now().Format("15:04:05")))
+				default:
+					s.watchesPanel.AddEvent(w, formatWatchEvent(event, prefix))
+				}
+			})
+		}
+
+		var err error
+		if prefix {
+			err = cli.WatchPrefix(watchCtx, target, callback)
+		} else {
+			err = cli.Watch(watchCtx, target, callback)
+		}
+
+		if err != nil && watchCtx.Err() == nil {
+			s.app.QueueUpdateDraw(func() {
+				s.debugPanel.LogError("Watch %d on %s failed: %v", w.ID, w.Label(), err)
+				s.watchesPanel.SetStatus(w, "failed")
+				s.watchesPanel.AddEvent(w, "[red]Watch error:[-] "+tview.Escape(err.Error()))
+			})
+		}
+	}()
+}
+
+// StopWatch cancels a watch and removes it from the watches pane.
+func (s *State) StopWatch(w *watches.Watch) {
+	if cancel, ok := s.watchCancels[w.ID]; ok {
+		cancel()
+		delete(s.watchCancels, w.ID)
+	}
+	s.watchesPanel.Remove(w)
+	s.debugPanel.LogInfo("Watch %d on %s stopped", w.ID, w.Label())
+	s.SetStatusBarText("[yellow]Watch stopped:[white] " + details.EscapeText(w.Label()))
+}
+
+// StopWatches cancels all running watches.
+func (s *State) StopWatches() {
+	for _, w := range s.watchesPanel.Watches() {
+		if cancel, ok := s.watchCancels[w.ID]; ok {
+			cancel()
+			delete(s.watchCancels, w.ID)
+		}
+		s.watchesPanel.Remove(w)
+	}
+}
+
+// SetContentFlex sets the flex the side panes are docked into.
+func (s *State) SetContentFlex(flex *tview.Flex) {
+	s.contentFlex = flex
+}
+
+// ToggleWatchesPanel shows or hides the watches pane. Watches keep running
+// while the pane is hidden.
+func (s *State) ToggleWatchesPanel() {
+	if s.watchesPanel.IsVisible() {
+		s.contentFlex.RemoveItem(s.watchesPanel.GetView())
+		s.watchesPanel.SetVisible(false)
+		s.app.SetFocus(s.keysPanel.GetTree())
+		s.SetStatusBarText("[yellow]Watches pane hidden (W to show)")
+		return
+	}
+
+	s.showWatchesPanel()
+	s.app.SetFocus(s.watchesPanel.GetList())
+}
+
+// showWatchesPanel docks the watches pane next to the details panel.
+func (s *State) showWatchesPanel() {
+	if s.watchesPanel.IsVisible() || s.contentFlex == nil {
+		return
+	}
+	s.contentFlex.AddItem(s.watchesPanel.GetView(), 0, 1, false)
+	s.watchesPanel.SetVisible(true)
+}
+
+// formatWatchEvent formats an event as a single line for the watches pane.
+func formatWatchEvent(event *client.WatchEvent, showKey bool) string {
+	line := fmt.Sprintf("[gray]%s[-] ", time.Now().Format("15:04:05"))
+
+	switch event.Type {
+	case client.EventTypePut:
+		line += "[green]PUT[-]"
+	case client.EventTypeDelete:
+		line += "[red]DEL[-]"
+	case client.EventTypeCompacted:
+		return line + fmt.Sprintf("[yellow]COMPACTED[-] [gray]changes before rev %d may have been missed[-]", event.CompactRevision)
+	}
+
+	if showKey {
+		line += " " + details.EscapeText(event.Key)
+	}
+	line += fmt.Sprintf(" [gray](rev %d)[-]", event.ModRevision)
+
+	value := event.Value
+	if event.Type == client.EventTypeDelete {
+		value = event.PrevValue
+	}
+	switch {
+	case len(value) == 0:
+	case client.IsPrintable(value):
+		line += " " + details.EscapeText(previewLine(string(value)))
+	default:
+		line += fmt.Sprintf(" [red]binary[-] %s", client.FormatBytes(int64(len(value))))
+	}
+	return line
+}
@@ -122,6 +122,7 @@ func (s *State) showProfileForm(existing *config.Profile, restoreInput func()) {
 	certFile := ""
 	keyFile := ""
 	isDefault := false
+	readOnly := false
 
 	if existing != nil {
 		name = existing.Name
@@ -131,6 +132,7 @@ func (s *State) showProfileForm(existing *config.Profile, restoreInput func()) {
 		username = existing.Username
 		password = existing.DecodePassword()
 		isDefault = existing.Default
+		readOnly = existing.ReadOnly
 		if existing.TLS != nil {
 			tlsEnabled = existing.TLS.Enabled
 			caFile = existing.TLS.CAFile
@@ -153,6 +155,7 @@ func (s *State) showProfileForm(existing *config.Profile, restoreInput func()) {
 	form.AddInputField("Cert File", certFile, 40, nil, nil)
 	form.AddInputField("Key File", keyFile, 40, nil, nil)
 	form.AddCheckbox("Default", isDefault, nil)
+	form.AddCheckbox("Read-only", readOnly, nil)
 
 	form.AddButton("Save", func() {
 		s.saveProfile(form, existing)
@@ -192,12 +195,22 @@ func (s *State) saveProfile(form *tview.Form, existing *config.Profile) {
 	newCertFile := form.GetFormItemByLabel("Cert File").(*tview.InputField).GetText()
 	newKeyFile := form.GetFormItemByLabel("Key File").(*tview.InputField).GetText()
 	newIsDefault := form.GetFormItemByLabel("Default").(*tview.Checkbox).IsChecked()
+	newReadOnly := form.GetFormItemByLabel("Read-only").(*tview.Checkbox).IsChecked()
 
 	profile := &config.Profile{
 		Name:      newName,
 		Endpoints: []string{newEndpoints},
 		Username:  newUsername,
 		Default:   newIsDefault,
+		ReadOnly:  newReadOnly,
+	}
+
+	// Timeouts and protected prefixes are not in the form; keep those set
+	// in the config file
+	if existing != nil {
+		profile.DialTimeout = existing.DialTimeout
+		profile.RequestTimeout = existing.RequestTimeout
+		profile.ProtectedPrefixes = existing.ProtectedPrefixes
 	}
 
 	if newPassword != "" {
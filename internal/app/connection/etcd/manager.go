@@ -10,9 +10,11 @@ import (
 
 // Manager manages etcd connection
 type Manager struct {
-	client *client.Client
-	config *client.Config
-	mu     sync.RWMutex
+	client      *client.Client
+	config      *client.Config
+	identity    *client.Identity
+	identityErr error // Why the permissions could not be read on connect
+	mu          sync.RWMutex
 }
 
 // NewManager creates a new connection manager
@@ -43,8 +45,19 @@ func (m *Manager) Connect(cfg *client.Config) error {
 		return fmt.Errorf("etcd health check failed: %w", err)
 	}
 
+	// Evaluate what the user may do so the UI can hide rejected actions.
+	// Clusters that cannot tell, such as those before 3.5 without
+	// AuthStatus, are probed key by key instead.
+	identity, err := cli.GetIdentity(ctx)
+	m.identityErr = nil
+	if err != nil {
+		identity = &client.Identity{User: cfg.Username, AuthEnabled: true, Probed: true}
+		m.identityErr = err
+	}
+
 	m.client = cli
 	m.config = cfg
+	m.identity = identity
 	return nil
 }
 
@@ -61,6 +74,8 @@ func (m *Manager) Disconnect() error {
 	if m.client != nil {
 		err := m.client.Close()
 		m.client = nil
+		m.identity = nil
+		m.identityErr = nil
 		return err
 	}
 	return nil
@@ -86,3 +101,19 @@ func (m *Manager) GetConfig() *client.Config {
 	defer m.mu.RUnlock()
 	return m.config
 }
+
+// GetIdentity returns the connected user and its permissions, nil if not
+// connected
+func (m *Manager) GetIdentity() *client.Identity {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.identity
+}
+
+// GetIdentityError returns why the permissions of the connected user could
+// not be read, in which case GetIdentity returns a probed identity
+func (m *Manager) GetIdentityError() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.identityErr
+}
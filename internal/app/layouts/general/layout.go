@@ -19,6 +19,7 @@ type Layout struct {
 	mainFlex        *tview.Flex
 	contentFlex     *tview.Flex
 	profile         *config.Profile
+	saveProfile     bool
 	configManager   *config.Manager
 	onSwitchProfile func()
 }
@@ -38,6 +39,11 @@ func (l *Layout) SetProfile(profile *config.Profile) {
 	l.profile = profile
 }
 
+// SetSaveProfile saves the profile to the config file once connected
+func (l *Layout) SetSaveProfile(save bool) {
+	l.saveProfile = save
+}
+
 // SetConfigManager sets the config manager
 func (l *Layout) SetConfigManager(cm *config.Manager) {
 	l.configManager = cm
@@ -53,6 +59,7 @@ func (l *Layout) Render(ctx context.Context) error {
 	// Pass profile to state for connection
 	if l.profile != nil {
 		l.state.SetProfile(l.profile)
+		l.state.SetSaveProfile(l.saveProfile)
 	}
 	if l.configManager != nil {
 		l.state.SetConfigManager(l.configManager)
@@ -67,8 +74,19 @@ func (l *Layout) Render(ctx context.Context) error {
 		l.state.HandleDetailsAction(ctx, action)
 	})
 
-	// Setup tab callback for details panel to switch focus back to keys
+	// Setup tab callback for details panel to move focus on to the watches
+	// pane when it is shown, back to keys otherwise
 	l.state.GetDetailsPanel().SetTabCallback(func() {
+		if l.state.GetWatchesPanel().IsVisible() {
+			l.app.SetFocus(l.state.GetWatchesPanel().GetList())
+			return
+		}
+		l.app.SetFocus(l.state.GetKeysPanel().GetTree())
+	})
+
+	// Watches pane: stop watches and return focus to keys
+	l.state.GetWatchesPanel().SetStopFunc(l.state.StopWatch)
+	l.state.GetWatchesPanel().SetDoneFunc(func() {
 		l.app.SetFocus(l.state.GetKeysPanel().GetTree())
 	})
 
@@ -77,9 +95,10 @@ func (l *Layout) Render(ctx context.Context) error {
 		AddItem(l.state.GetKeysPanel().GetTree(), 0, 1, true).
 		AddItem(l.state.GetDetailsPanel().GetView(), 0, 2, false)
 
-	// Content flex: main view + optional debug panel (side by side)
+	// Content flex: main view + optional watches and debug panels (side by side)
 	l.contentFlex = tview.NewFlex().
 		AddItem(l.mainFlex, 0, 1, true)
+	l.state.SetContentFlex(l.contentFlex)
 
 	// Main layout with status bar at bottom
 	l.rootFlex = tview.NewFlex().
@@ -112,7 +131,8 @@ func (l *Layout) handleInput(ctx context.Context, event *tcell.EventKey) *tcell.
 		return event
 	}
 
-	// Don't intercept keys when we're not in the main view
+	// Don't intercept keys when we're not in the main view; the watches
+	// pane handles its own keys
 	if l.app.GetFocus() != l.state.GetKeysPanel().GetTree() &&
 		l.app.GetFocus() != l.state.GetDetailsPanel().GetForm() {
 		if event.Key() == tcell.KeyCtrlC {
@@ -132,6 +152,10 @@ func (l *Layout) handleInput(ctx context.Context, event *tcell.EventKey) *tcell.
 		return nil
 	case tcell.KeyTab:
 		return l.handleTab()
+	case tcell.KeyEsc:
+		if l.state.CancelLoading() {
+			return nil
+		}
 	}
 
 	// Handle rune keys
@@ -159,12 +183,53 @@ func (l *Layout) handleInput(ctx context.Context, event *tcell.EventKey) *tcell.
 	case 'e':
 		l.state.HandleEdit(ctx)
 		return nil
+	case 'E':
+		l.state.HandleExternalEdit(ctx)
+		return nil
 	case 'w':
 		l.state.HandleWatch(ctx)
 		return nil
+	case 'W':
+		l.state.ToggleWatchesPanel()
+		return nil
+	case 'L':
+		l.state.HandleToggleLive(ctx)
+		return nil
+	case 'f':
+		l.state.HandleTogglePretty(ctx)
+		return nil
+	case 'v':
+		l.state.HandleCycleValueMode(ctx)
+		return nil
+	case 'H':
+		l.state.HandleHistory(ctx)
+		return nil
+	case 'X':
+		l.state.HandleExport(ctx)
+		return nil
+	case 'I':
+		l.state.HandleImport(ctx)
+		return nil
+	case 'C':
+		l.state.HandleCluster(ctx)
+		return nil
+	case 'M':
+		l.state.HandleMaintenance(ctx)
+		return nil
+	case 'S':
+		l.state.HandleSnapshot(ctx)
+		return nil
+	case 'T':
+		l.state.HandleLeases(ctx)
+		return nil
+	case 'U':
+		l.state.HandleAuth(ctx)
+		return nil
 	case 'p':
 		// Switch profiles
 		if l.onSwitchProfile != nil {
+			l.state.StopWatches()
+			l.state.StopLive()
 			l.onSwitchProfile()
 		}
 		return nil
@@ -176,10 +241,16 @@ func (l *Layout) handleInput(ctx context.Context, event *tcell.EventKey) *tcell.
 // handleTab switches focus between panels.
 func (l *Layout) handleTab() *tcell.EventKey {
 	current := l.app.GetFocus()
-	if current == l.state.GetKeysPanel().GetTree() && l.state.GetCurrentKey() != nil {
-		l.app.SetFocus(l.state.GetDetailsPanel().GetForm())
+	if current != l.state.GetKeysPanel().GetTree() {
 		return nil
 	}
+
+	switch {
+	case l.state.GetCurrentKey() != nil:
+		l.app.SetFocus(l.state.GetDetailsPanel().GetForm())
+	case l.state.GetWatchesPanel().IsVisible():
+		l.app.SetFocus(l.state.GetWatchesPanel().GetList())
+	}
 	return nil
 }
 
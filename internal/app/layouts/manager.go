@@ -3,6 +3,7 @@ package layouts
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/alex-dev-master/etcdtui/internal/app/layouts/general"
 	"github.com/alex-dev-master/etcdtui/internal/app/layouts/profiles"
@@ -15,6 +16,8 @@ type Manager struct {
 	app            *tview.Application
 	configManager  *config.Manager
 	profileName    string // profile to use (from CLI flag)
+	connection     *config.ConnectionFlags
+	saveProfile    bool // save the first profile connected to
 	generalLayout  *general.Layout
 	profilesLayout *profiles.Layout
 }
@@ -35,6 +38,12 @@ func (m *Manager) SetProfileName(name string) {
 	m.profileName = name
 }
 
+// SetConnectionFlags sets connection flags that override the profile or
+// connect without one
+func (m *Manager) SetConnectionFlags(flags *config.ConnectionFlags) {
+	m.connection = flags
+}
+
 // Render renders the application.
 func (m *Manager) Render(ctx context.Context) error {
 	// Load config
@@ -43,11 +52,30 @@ func (m *Manager) Render(ctx context.Context) error {
 	}
 
 	// If profile specified via CLI flag, connect directly
+	var profile *config.Profile
 	if m.profileName != "" {
-		profile, err := m.configManager.GetProfile(m.profileName)
+		var err error
+		profile, err = m.configManager.GetProfile(m.profileName)
 		if err != nil {
 			return fmt.Errorf("profile '%s' not found", m.profileName)
 		}
+	}
+
+	// Connection flags and ETCDCTL_* variables connect directly too
+	if m.connection != nil {
+		if name := m.connection.SaveProfile; name != "" {
+			if _, err := m.configManager.GetProfile(name); err == nil {
+				return fmt.Errorf("profile '%s' already exists", name)
+			}
+		}
+		var err error
+		profile, err = m.connection.Profile(profile, os.Getenv, os.Stdin)
+		if err != nil {
+			return err
+		}
+		m.saveProfile = m.connection.SaveProfile != ""
+	}
+	if profile != nil {
 		return m.renderGeneralLayout(ctx, profile)
 	}
 
@@ -85,6 +113,8 @@ func (m *Manager) renderProfilesLayout(ctx context.Context) error {
 func (m *Manager) renderGeneralLayout(ctx context.Context, profile *config.Profile) error {
 	m.generalLayout = general.NewLayout(m.app)
 	m.generalLayout.SetProfile(profile)
+	m.generalLayout.SetSaveProfile(m.saveProfile)
+	m.saveProfile = false
 	m.generalLayout.SetConfigManager(m.configManager)
 
 	// Set callback to switch back to profiles
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the access command
+var (
+	accessPrefix   bool
+	accessFromKey  bool
+	accessRangeEnd string
+)
+
+func init() {
+	register(&Command{
+		Name:  "access",
+		Usage: "access <user> <key>",
+		Short: "Show whether a user can read or write a key or range and which roles grant it",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&accessPrefix, "prefix", false, "Check all keys with the key as prefix")
+			fs.BoolVar(&accessFromKey, "from-key", false, "Check all keys from the key on")
+			fs.StringVar(&accessRangeEnd, "range-end", "", "Check the keys up to this range end (exclusive)")
+		},
+		Run: runAccess,
+	})
+}
+
+// runAccess evaluates the permissions of a user for a key range
+func runAccess(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 2 {
+		return usageErrorf("expected a user and a key")
+	}
+	user, key := args[0], args[1]
+
+	rangeEnd, err := accessRange(key)
+	if err != nil {
+		return err
+	}
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	evaluator, err := cli.LoadEvaluator(ctx)
+	if err != nil {
+		return err
+	}
+
+	check, err := evaluator.Check(user, key, rangeEnd)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(env.Stdout, "%s on %s: %s\n", check.User, check.Range(), check.Access)
+	_, _ = fmt.Fprintf(env.Stdout, "  read:  %s\n", describeGrants(check.Access.Read, check.ReadGrants))
+	_, _ = fmt.Fprintf(env.Stdout, "  write: %s\n", describeGrants(check.Access.Write, check.WriteGrants))
+	if len(check.MissingRoles) > 0 {
+		_, _ = fmt.Fprintf(env.Stdout, "  missing roles: %s\n", strings.Join(check.MissingRoles, ", "))
+	}
+	return nil
+}
+
+// accessRange returns the range end selected by the flags
+func accessRange(key string) (string, error) {
+	selected := 0
+	for _, set := range []bool{accessPrefix, accessFromKey, accessRangeEnd != ""} {
+		if set {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return "", usageErrorf("--prefix, --from-key and --range-end are mutually exclusive")
+	}
+
+	switch {
+	case accessPrefix:
+		return client.PrefixRangeEnd(key), nil
+	case accessFromKey:
+		return "\x00", nil
+	case accessRangeEnd != "":
+		if accessRangeEnd <= key {
+			return "", usageErrorf("range end must sort after the key")
+		}
+		return accessRangeEnd, nil
+	default:
+		return "", nil
+	}
+}
+
+// describeGrants returns the coverage and the grants it comes from
+func describeGrants(coverage client.Coverage, grants []*client.Grant) string {
+	var text string
+	switch coverage {
+	case client.CoverageFull:
+		text = "allowed"
+	case client.CoveragePartial:
+		text = "partly allowed"
+	default:
+		return "denied"
+	}
+	for _, g := range grants {
+		text += "\n    " + g.String()
+	}
+	return text
+}
@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/alex-dev-master/etcdtui/internal/config"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+
+	// ExitNotFound is returned when a requested key, lease or member does
+	// not exist
+	ExitNotFound = 3
+)
+
+// Command is a non-interactive subcommand
+type Command struct {
+	// Name is the word that selects the command
+	Name string
+
+	// Usage is the synopsis shown in help, without the program name
+	Usage string
+
+	// Short is a one-line description
+	Short string
+
+	// Flags registers command specific flags
+	Flags func(fs *pflag.FlagSet)
+
+	// Confirm registers the --confirm flag, for commands changing keys
+	Confirm bool
+
+	// Run executes the command with the positional arguments
+	Run func(ctx context.Context, env *Env, args []string) error
+}
+
+// UsageError is returned by commands for invalid arguments
+type UsageError struct {
+	msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.msg
+}
+
+// usageErrorf creates a UsageError
+func usageErrorf(format string, args ...interface{}) error {
+	return &UsageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// NotFoundError is returned by commands when what they were asked for does
+// not exist
+type NotFoundError struct {
+	msg string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.msg
+}
+
+// notFoundErrorf creates a NotFoundError
+func notFoundErrorf(format string, args ...interface{}) error {
+	return &NotFoundError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Env carries the streams and connection settings shared by commands
+type Env struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// ProfileName selects the profile from the config file; the default
+	// profile is used when empty
+	ProfileName string
+
+	// Connection holds connection flags that override the profile or
+	// connect without one
+	Connection config.ConnectionFlags
+
+	// Confirm repeats the key, prefix or lease ID of a change to keys
+	// protected with confirmation
+	Confirm string
+
+	configManager *config.Manager
+	profile       *config.Profile
+	client        *client.Client
+}
+
+var commands = map[string]*Command{}
+
+// register adds a command to the registry
+func register(cmd *Command) {
+	commands[cmd.Name] = cmd
+}
+
+// IsCommand returns true if name selects a subcommand
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Commands returns all subcommands sorted by name
+func Commands() []*Command {
+	list := make([]*Command, 0, len(commands))
+	for _, cmd := range commands {
+		list = append(list, cmd)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Run executes the subcommand named by args[0] and returns the exit code
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown command\n")
+		return ExitUsage
+	}
+	cmd := commands[args[0]]
+
+	env := &Env{
+		Stdout: stdout,
+		Stderr: stderr,
+		Stdin:  stdin,
+	}
+	defer env.close()
+
+	fs := pflag.NewFlagSet(cmd.Name, pflag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVarP(&env.ProfileName, "profile", "p", "", "Profile name to use for connection")
+	env.Connection.Register(fs)
+	if cmd.Confirm {
+		fs.StringVar(&env.Confirm, "confirm", "", "Repeat the key, prefix or lease ID to change keys protected with confirmation")
+	}
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage:\n  etcdtui %s\n\n%s\n\nFlags:\n%s", cmd.Usage, cmd.Short, fs.FlagUsages())
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	if err := cmd.Run(ctx, env, fs.Args()); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		var usageErr *UsageError
+		if errors.As(err, &usageErr) {
+			fs.Usage()
+			return ExitUsage
+		}
+		var notFoundErr *NotFoundError
+		if errors.As(err, &notFoundErr) || errors.Is(err, client.ErrKeyNotFound) {
+			return ExitNotFound
+		}
+		return ExitError
+	}
+
+	return ExitOK
+}
+
+// Usage returns the command list for the program help
+func Usage() string {
+	var b strings.Builder
+	for _, cmd := range Commands() {
+		_, _ = fmt.Fprintf(&b, "  %-28s %s\n", cmd.Usage, cmd.Short)
+	}
+	return b.String()
+}
+
+// Profile returns the selected profile from the config file with the
+// connection flags applied. Connection flags or ETCDCTL_* variables without
+// a selected profile replace the default profile.
+func (e *Env) Profile() (*config.Profile, error) {
+	if e.profile != nil {
+		return e.profile, nil
+	}
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	var base *config.Profile
+	if e.ProfileName != "" {
+		profile, err := e.configManager.GetProfile(e.ProfileName)
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s' not found", e.ProfileName)
+		}
+		base = profile
+	}
+
+	if name := e.Connection.SaveProfile; name != "" {
+		if _, err := e.configManager.GetProfile(name); err == nil {
+			return nil, usageErrorf("profile '%s' already exists", name)
+		}
+	}
+
+	profile, err := e.Connection.Profile(base, os.Getenv, e.Stdin)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+	if profile == nil {
+		profile, err = e.configManager.GetDefaultProfile()
+		if errors.Is(err, config.ErrNoDefaultProfile) {
+			// No config yet: fall back to the local default endpoint
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	e.profile = profile
+	return profile, nil
+}
+
+// Validator returns the validation rules of the config file, nil if there
+// are none
+func (e *Env) Validator() (*client.Validator, error) {
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+	v, err := e.configManager.Validator()
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation rules: %w", err)
+	}
+	return v, nil
+}
+
+// confirmed reports whether --confirm repeats name
+func (e *Env) confirmed(name string) bool {
+	return e.Confirm != "" && e.Confirm == name
+}
+
+// confirm returns ctx allowing changes to keys protected with confirmation
+// if --confirm repeats name
+func (e *Env) confirm(ctx context.Context, name string) context.Context {
+	if e.confirmed(name) {
+		return client.WithConfirmation(ctx)
+	}
+	return ctx
+}
+
+// confirmHint tells how to confirm a change refused for the lack of a
+// confirmation of name; other errors are returned as they are
+func confirmHint(err error, name string) error {
+	if errors.Is(err, client.ErrNotConfirmed) {
+		return fmt.Errorf("%w; repeat it with --confirm %s", err, name)
+	}
+	return err
+}
+
+// loadConfig reads the config file once
+func (e *Env) loadConfig() error {
+	if e.configManager != nil {
+		return nil
+	}
+	e.configManager = config.NewManager()
+	if err := e.configManager.Load(); err != nil {
+		e.configManager = nil
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	return nil
+}
+
+// Client connects to etcd using the selected profile
+func (e *Env) Client() (*client.Client, error) {
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	profile, err := e.Profile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := client.DefaultConfig()
+	if profile != nil {
+		if cfg, err = profile.ToClientConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	cli, err := client.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	e.client = cli
+
+	if e.Connection.SaveProfile != "" {
+		if err := e.saveProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+	return cli, nil
+}
+
+// saveProfile saves the connection settings as a profile once the cluster
+// answers
+func (e *Env) saveProfile(profile *config.Profile) error {
+	if err := e.client.HealthCheck(context.Background()); err != nil {
+		return fmt.Errorf("not saving profile '%s': %w", profile.Name, err)
+	}
+	if err := e.configManager.SaveNewProfile(profile); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	_, _ = fmt.Fprintf(e.Stderr, "Saved profile '%s'\n", profile.Name)
+	return nil
+}
+
+// close releases the connection
+func (e *Env) close() {
+	if e.client != nil {
+		_ = e.client.Close()
+	}
+}
+
+// isTerminal returns true if w is a character device, e.g. an interactive
+// terminal rather than a pipe or file
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alex-dev-master/etcdtui/internal/config"
+	"github.com/alex-dev-master/etcdtui/internal/etcdtest"
+	"go.yaml.in/yaml/v3"
+)
+
+// startTestCluster starts a single member cluster for commands and keeps
+// the config file and ETCDCTL_* variables of the machine out of the test.
+// It returns the --endpoints flag to connect with.
+func startTestCluster(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{config.EnvEndpoints, config.EnvUser, config.EnvPassword, config.EnvCACert, config.EnvCert, config.EnvKey} {
+		t.Setenv(name, "")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	return "--endpoints=" + members[0].ClientURL
+}
+
+// run runs a command and returns its exit code and output
+func run(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// TestRunExitCodes verifies the exit codes of commands
+func TestRunExitCodes(t *testing.T) {
+	endpoints := startTestCluster(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"put", []string{"put", "/app/a", "1", endpoints}, ExitOK},
+		{"get", []string{"get", "/app/a", endpoints}, ExitOK},
+		{"get missing key", []string{"get", "/app/missing", endpoints}, ExitNotFound},
+		{"get missing prefix", []string{"get", "--prefix", "/none/", endpoints}, ExitNotFound},
+		{"del nothing", []string{"del", "/app/missing", endpoints}, ExitNotFound},
+		{"del", []string{"del", "/app/a", endpoints}, ExitOK},
+		{"unknown flag", []string{"get", "--no-such-flag", "/app/a", endpoints}, ExitUsage},
+		{"missing argument", []string{"get", endpoints}, ExitUsage},
+		{"unknown output", []string{"get", "-o", "xml", "/app/a", endpoints}, ExitUsage},
+		{"unknown command", []string{"nope"}, ExitUsage},
+		{"help", []string{"get", "--help"}, ExitOK},
+	}
+
+	for _, tt := range tests {
+		if code, _, stderr := run(t, "", tt.args...); code != tt.want {
+			t.Errorf("%s: exit code %d, want %d (stderr %q)", tt.name, code, tt.want, stderr)
+		}
+	}
+}
+
+// TestRunOutput verifies the JSON and YAML output of commands
+func TestRunOutput(t *testing.T) {
+	endpoints := startTestCluster(t)
+
+	code, stdout, stderr := run(t, "", "put", "/app/a", "1", "-o", "json", endpoints)
+	if code != ExitOK {
+		t.Fatalf("put exit code %d: %s", code, stderr)
+	}
+	var put map[string]any
+	if err := json.Unmarshal([]byte(stdout), &put); err != nil {
+		t.Fatalf("put output is not JSON: %v\n%s", err, stdout)
+	}
+	if put["key"] != "/app/a" || put["revision"] == nil {
+		t.Errorf("put output = %v, want key and revision", put)
+	}
+
+	// Values are read from stdin without a value argument
+	if code, _, stderr := run(t, "two\n", "put", "/app/b", endpoints); code != ExitOK {
+		t.Fatalf("put from stdin exit code %d: %s", code, stderr)
+	}
+
+	_, stdout, _ = run(t, "", "get", "/app/a", "-o", "json", endpoints)
+	var key keyOutput
+	if err := json.Unmarshal([]byte(stdout), &key); err != nil {
+		t.Fatalf("get output is not a JSON object: %v\n%s", err, stdout)
+	}
+	if key.Key != "/app/a" || key.Value == nil || *key.Value != "1" || key.Version != 1 || key.ModRevision == 0 {
+		t.Errorf("get output = %+v", key)
+	}
+
+	_, stdout, _ = run(t, "", "get", "--prefix", "/app/", "-o", "yaml", endpoints)
+	var list []keyOutput
+	if err := yaml.Unmarshal([]byte(stdout), &list); err != nil {
+		t.Fatalf("get --prefix output is not a YAML list: %v\n%s", err, stdout)
+	}
+	if len(list) != 2 || list[0].Key != "/app/a" || list[1].Key != "/app/b" || *list[1].Value != "two\n" {
+		t.Errorf("get --prefix output = %+v", list)
+	}
+
+	_, stdout, _ = run(t, "", "get", "/app/a", "--print-value-only", endpoints)
+	if stdout != "1\n" {
+		t.Errorf("get --print-value-only output = %q, want 1", stdout)
+	}
+
+	code, stdout, _ = run(t, "", "del", "--prefix", "/app/", "-o", "yaml", endpoints)
+	var del delOutput
+	if err := yaml.Unmarshal([]byte(stdout), &del); err != nil {
+		t.Fatalf("del output is not YAML: %v\n%s", err, stdout)
+	}
+	if code != ExitOK || del.Deleted != 2 || del.Revision == 0 {
+		t.Errorf("del output = %+v with exit code %d, want 2 deleted", del, code)
+	}
+}
+
+// TestRunGuardedProfile verifies that commands get the changes a profile
+// forbids refused and that --confirm allows confirm-protected ones
+func TestRunGuardedProfile(t *testing.T) {
+	endpoints := startTestCluster(t)
+
+	url := strings.TrimPrefix(endpoints, "--endpoints=")
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), config.DefaultConfigDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	profiles := fmt.Sprintf(`profiles:
+  - name: guarded
+    endpoints: [%s]
+    protected_prefixes:
+      - prefix: /cfg/
+        rule: confirm
+      - prefix: /secret/
+        rule: no-write
+  - name: ro
+    endpoints: [%s]
+    read_only: true
+`, url, url)
+	if err := os.WriteFile(filepath.Join(dir, config.DefaultConfigFile), []byte(profiles), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		want   int
+		stderr string
+	}{
+		{"free put", []string{"put", "/app/a", "1", "-p", "guarded"}, ExitOK, ""},
+		{"no-write put", []string{"put", "/secret/a", "1", "-p", "guarded"}, ExitError, "protected (no-write)"},
+		{"unconfirmed put", []string{"put", "/cfg/a", "1", "-p", "guarded"}, ExitError, "--confirm /cfg/a"},
+		{"confirmed put", []string{"put", "/cfg/a", "1", "--confirm", "/cfg/a", "-p", "guarded"}, ExitOK, ""},
+		{"confirmation of an earlier run", []string{"put", "/cfg/a", "2", "-p", "guarded"}, ExitError, "--confirm /cfg/a"},
+		{"confirmation of another key", []string{"put", "/cfg/b", "1", "--confirm", "/cfg/a", "-p", "guarded"}, ExitError, "--confirm /cfg/b"},
+		{"unconfirmed del", []string{"del", "--prefix", "/cfg/", "-p", "guarded"}, ExitError, "--confirm /cfg/"},
+		{"read-only get", []string{"get", "/cfg/a", "-p", "ro"}, ExitOK, ""},
+		{"read-only put", []string{"put", "/app/b", "1", "-p", "ro"}, ExitError, "read-only"},
+		{"read-only lease", []string{"lease", "grant", "60", "-p", "ro"}, ExitError, "read-only"},
+		{"read-only member", []string{"member", "add", "http://127.0.0.1:1", "-p", "ro"}, ExitError, "read-only"},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := run(t, "", tt.args...)
+		if code != tt.want || !strings.Contains(stderr, tt.stderr) {
+			t.Errorf("%s: exit code %d with %q, want %d with %q", tt.name, code, stderr, tt.want, tt.stderr)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the del command
+var delPrefix bool
+
+func init() {
+	register(&Command{
+		Name:  "del",
+		Usage: "del <key>",
+		Short: "Delete a key, or all keys with a prefix; exits with 3 if nothing was deleted",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&delPrefix, "prefix", false, "Delete all keys with the key as prefix")
+			outputFlag(fs)
+		},
+		Confirm: true,
+		Run:     runDel,
+	})
+}
+
+// delOutput is the result of del
+type delOutput struct {
+	Deleted  int64 `json:"deleted" yaml:"deleted"`
+	Revision int64 `json:"revision" yaml:"revision"`
+}
+
+// runDel deletes a key or a prefix
+func runDel(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("expected a key")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+	key := args[0]
+	if delPrefix && key == "" {
+		return usageErrorf("refusing to delete all keys with an empty prefix")
+	}
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+
+	op := client.OpDelete(key)
+	if delPrefix {
+		op = client.OpDeletePrefix(key)
+	}
+	result, err := cli.NewTxn().Then(op).Commit(env.confirm(ctx, key))
+	if err != nil {
+		return confirmHint(fmt.Errorf("failed to delete %s: %w", key, err), key)
+	}
+
+	out := &delOutput{Revision: result.Revision}
+	if len(result.Results) > 0 {
+		out.Deleted = result.Results[0].Deleted
+	}
+
+	if err := writeOutput(env.Stdout, out, func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintf(tw, "Deleted %d keys at revision %d\n", out.Deleted, out.Revision)
+	}); err != nil {
+		return err
+	}
+	if out.Deleted == 0 {
+		return notFoundErrorf("nothing to delete at %s", key)
+	}
+	return nil
+}
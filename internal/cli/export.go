@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the export command
+var (
+	exportFormat   string
+	exportNested   bool
+	exportFile     string
+	exportRevision int64
+)
+
+func init() {
+	register(&Command{
+		Name:  "export",
+		Usage: "export <prefix>",
+		Short: "Export the keys with a prefix as JSON, YAML or an etcdctl style dump",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&exportFormat, "format", string(client.ExportJSON), "Output format: json, yaml or dump")
+			fs.BoolVar(&exportNested, "nested", false, "Nest JSON and YAML by path segment instead of flat keys")
+			fs.StringVarP(&exportFile, "file", "f", "", "Write to this file instead of stdout")
+			fs.Int64Var(&exportRevision, "revision", 0, "Export the keys at this revision instead of the current one")
+		},
+		Run: runExport,
+	})
+}
+
+// runExport writes the keys with a prefix to stdout or a file
+func runExport(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("expected a prefix")
+	}
+
+	format, err := client.ParseExportFormat(exportFormat)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	opts := client.ExportOptions{
+		Prefix:   args[0],
+		Format:   format,
+		Nested:   exportNested,
+		Revision: exportRevision,
+	}
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+
+	// Progress would mix with the data on a terminal without a file
+	interactive := exportFile != "" && isTerminal(env.Stderr)
+	progress := func(loaded, total int64) {
+		if interactive {
+			_, _ = fmt.Fprintf(env.Stderr, "\rRead %d / %d keys   ", loaded, total)
+		}
+	}
+
+	var result *client.ExportResult
+	if exportFile != "" {
+		result, err = cli.ExportFile(ctx, exportFile, opts, progress)
+	} else {
+		result, err = cli.Export(ctx, env.Stdout, opts, progress)
+	}
+	if interactive {
+		_, _ = fmt.Fprintln(env.Stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if exportFile != "" {
+		_, _ = fmt.Fprintf(env.Stdout, "Exported %d keys at revision %d to %s\n", result.Keys, result.Revision, exportFile)
+	}
+	return nil
+}
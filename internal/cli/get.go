@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the get command
+var (
+	getPrefix    bool
+	getRevision  int64
+	getValueOnly bool
+)
+
+func init() {
+	register(&Command{
+		Name:  "get",
+		Usage: "get <key>",
+		Short: "Print a key, or all keys with a prefix, with its value and revisions",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&getPrefix, "prefix", false, "Get all keys with the key as prefix")
+			fs.Int64Var(&getRevision, "revision", 0, "Read the keys at this revision instead of the current one")
+			fs.BoolVar(&getValueOnly, "print-value-only", false, "Print only the raw values, one per line")
+			outputFlag(fs)
+		},
+		Run: runGet,
+	})
+}
+
+// runGet prints one key or all keys with a prefix
+func runGet(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("expected a key")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+	if getValueOnly && outputFormat != OutputTable {
+		return usageErrorf("--print-value-only cannot be combined with --output %s", outputFormat)
+	}
+	key := args[0]
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+
+	var kvs []*client.KeyValue
+	switch {
+	case getPrefix:
+		kvs, _, err = cli.ListAll(ctx, client.ListOptions{Prefix: key, Revision: getRevision}, nil)
+		if err == nil && len(kvs) == 0 {
+			return notFoundErrorf("no keys with prefix %s", key)
+		}
+	case getRevision > 0:
+		var kv *client.KeyValue
+		kv, err = cli.GetWithRevision(ctx, key, getRevision)
+		kvs = []*client.KeyValue{kv}
+	default:
+		var kv *client.KeyValue
+		kv, err = cli.Get(ctx, key)
+		kvs = []*client.KeyValue{kv}
+	}
+	if err != nil {
+		return err
+	}
+
+	if getValueOnly {
+		for _, kv := range kvs {
+			if _, err := fmt.Fprintf(env.Stdout, "%s\n", kv.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// A single key is an object, a prefix a list
+	var out any
+	if getPrefix {
+		list := make([]*keyOutput, 0, len(kvs))
+		for _, kv := range kvs {
+			list = append(list, newKeyOutput(kv, true))
+		}
+		out = list
+	} else {
+		out = newKeyOutput(kvs[0], true)
+	}
+
+	return writeOutput(env.Stdout, out, func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintln(tw, "KEY\tMOD REVISION\tVERSION\tLEASE\tVALUE")
+		for _, kv := range kvs {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", kv.Key, kv.ModRevision, kv.Version, leaseText(kv.Lease), previewValue(kv.Value))
+		}
+	})
+}
+
+// leaseText shows a lease ID in tables, "-" for none
+func leaseText(id int64) string {
+	if id == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", id)
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/alex-dev-master/etcdtui/internal/diff"
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the import command
+var (
+	importFormat    string
+	importDelete    bool
+	importApply     bool
+	importDiff      bool
+	importMaxTxnOps int
+	importSkipValid bool
+)
+
+func init() {
+	register(&Command{
+		Name:  "import",
+		Usage: "import <file> <prefix>",
+		Short: "Show the changes an export file makes below a prefix, and apply them with --apply",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&importFormat, "format", "", "Input format: json, yaml or dump (default from the file extension)")
+			fs.BoolVar(&importDelete, "delete", false, "Delete keys below the prefix that are not in the file")
+			fs.BoolVar(&importApply, "apply", false, "Apply the changes instead of only showing them")
+			fs.BoolVar(&importDiff, "diff", false, "Show a diff of the value of every changed key")
+			fs.IntVar(&importMaxTxnOps, "max-txn-ops", client.DefaultMaxTxnOps, "Most keys written per transaction")
+			fs.BoolVar(&importSkipValid, "skip-validation", false, "Apply values that fail the validation rules of the config")
+		},
+		Confirm: true,
+		Run:     runImport,
+	})
+}
+
+// runImport plans an import and applies it when asked to
+func runImport(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 2 {
+		return usageErrorf("expected a file and a prefix")
+	}
+	path := args[0]
+
+	var format client.ExportFormat
+	if importFormat != "" {
+		var err error
+		if format, err = client.ParseExportFormat(importFormat); err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+	if importMaxTxnOps < 1 {
+		return usageErrorf("--max-txn-ops must be at least 1")
+	}
+	opts := client.ImportOptions{
+		Prefix:    args[1],
+		Delete:    importDelete,
+		MaxTxnOps: importMaxTxnOps,
+	}
+
+	entries, err := client.ReadImportFile(path, format)
+	if err != nil {
+		return err
+	}
+
+	if !importSkipValid {
+		if opts.Validator, err = env.Validator(); err != nil {
+			return err
+		}
+	}
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	ctx = env.confirm(ctx, opts.Prefix)
+
+	plan, err := cli.PlanImport(ctx, entries, opts, nil)
+	if err != nil {
+		return err
+	}
+
+	invalid := make(map[string]*client.ValidationError)
+	for _, validationErr := range plan.Invalid(opts.Validator) {
+		invalid[validationErr.Key] = validationErr
+	}
+	refused := make(map[string]*client.ProtectionError)
+	unconfirmed := false
+	for _, protectionErr := range plan.Refused(cli.Guard(), env.confirmed(opts.Prefix)) {
+		refused[protectionErr.Key] = protectionErr
+		unconfirmed = unconfirmed || errors.Is(protectionErr, client.ErrNotConfirmed)
+	}
+
+	for _, change := range plan.Pending() {
+		_, _ = fmt.Fprintf(env.Stdout, "%s %s\n", importMark(change.Action), change.Key)
+		if protectionErr := refused[change.Key]; protectionErr != nil {
+			_, _ = fmt.Fprintf(env.Stdout, "    refused: %s\n", protectionErr)
+		}
+		if validationErr := invalid[change.Key]; validationErr != nil {
+			for _, issue := range validationErr.Issues {
+				_, _ = fmt.Fprintf(env.Stdout, "    invalid: %s\n", issue)
+			}
+		}
+		if importDiff {
+			_, _ = fmt.Fprint(env.Stdout, importChangeDiff(change))
+		}
+	}
+	_, _ = fmt.Fprintf(env.Stdout, "Plan at revision %d: %s\n", plan.Revision, plan.Summary())
+
+	if len(refused) > 0 {
+		err := fmt.Errorf("%d changes refused by the profile", len(refused))
+		if unconfirmed {
+			err = fmt.Errorf("%w; changes protected with confirmation need --confirm %s", err, opts.Prefix)
+		}
+		return err
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%d values: %w; --skip-validation imports them anyway", len(invalid), client.ErrInvalidValue)
+	}
+
+	if !importApply {
+		if len(plan.Pending()) > 0 {
+			_, _ = fmt.Fprintln(env.Stdout, "Dry run, nothing changed. Run again with --apply to import.")
+		}
+		return nil
+	}
+
+	interactive := isTerminal(env.Stderr)
+	result, err := cli.ApplyImport(ctx, plan, opts, func(applied, total int) {
+		if interactive {
+			_, _ = fmt.Fprintf(env.Stderr, "\rApplied %d / %d keys   ", applied, total)
+		}
+	})
+	if interactive && result != nil && result.Txns > 0 {
+		_, _ = fmt.Fprintln(env.Stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(env.Stdout, "Imported %d keys in %d transactions, revision %d\n", result.Applied, result.Txns, result.Revision)
+	return nil
+}
+
+// importMark returns the marker of an action in the plan listing
+func importMark(action client.ImportAction) string {
+	switch action {
+	case client.ImportCreate:
+		return "+"
+	case client.ImportUpdate:
+		return "~"
+	case client.ImportDelete:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// importChangeDiff returns the unified diff of the value of a change
+func importChangeDiff(change *client.ImportChange) string {
+	fromName, from := "/dev/null", ""
+	if change.Current != nil {
+		fromName = fmt.Sprintf("%s@%d", change.Key, change.Current.ModRevision)
+		from = valueText(change.Current.Value)
+	}
+	toName, to := "/dev/null", ""
+	if change.Action != client.ImportDelete {
+		toName = change.Key + " (import)"
+		to = valueText(change.Value)
+	}
+	return diff.Unified(fromName, toName, from, to)
+}
+
+// valueText returns a value as text to compare: the value itself if it is
+// printable, its hex dump otherwise
+func valueText(value []byte) string {
+	if client.IsPrintable(value) {
+		return string(value)
+	}
+	return hex.Dump(value)
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	register(&Command{
+		Name:  "lease",
+		Usage: "lease <command> [id|ttl]",
+		Short: "Manage leases: list, show <id>, grant <ttl>, keepalive <id>, revoke <id>",
+		Flags: func(fs *pflag.FlagSet) {
+			outputFlag(fs)
+		},
+		Confirm: true,
+		Run:     runLease,
+	})
+}
+
+// leaseOutput is a lease as printed by the lease commands
+type leaseOutput struct {
+	ID         int64    `json:"id" yaml:"id"`
+	TTL        int64    `json:"ttl" yaml:"ttl"`
+	GrantedTTL int64    `json:"granted_ttl" yaml:"granted_ttl"`
+	Keys       []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// runLease dispatches the lease subcommands
+func runLease(ctx context.Context, env *Env, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected list, show, grant, keepalive or revoke")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+
+	command, args := args[0], args[1:]
+	wantArgs := 1
+	if command == "list" {
+		wantArgs = 0
+	}
+	if len(args) != wantArgs {
+		if wantArgs == 0 {
+			return usageErrorf("lease list takes no arguments")
+		}
+		if command == "grant" {
+			return usageErrorf("expected a TTL, e.g. 'lease grant 60s'")
+		}
+		return usageErrorf("expected a lease ID")
+	}
+
+	switch command {
+	case "list":
+		return leaseList(ctx, env)
+	case "grant":
+		return leaseGrant(ctx, env, args[0])
+	case "show", "keepalive", "revoke":
+		id, err := parseLeaseID(args[0])
+		if err != nil {
+			return err
+		}
+		return leaseUpdate(ctx, env, command, id)
+	default:
+		return usageErrorf("unknown lease command: %s", command)
+	}
+}
+
+// leaseList prints all leases with their keys
+func leaseList(ctx context.Context, env *Env) error {
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	infos, err := cli.ListLeaseInfos(ctx)
+	if err != nil {
+		return err
+	}
+
+	list := make([]*leaseOutput, 0, len(infos))
+	for _, info := range infos {
+		list = append(list, newLeaseOutput(info))
+	}
+	return writeOutput(env.Stdout, list, func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintln(tw, "ID\tGRANTED\tREMAINING\tKEYS")
+		for _, info := range infos {
+			_, _ = fmt.Fprintf(tw, "%d\t%ds\t%ds\t%s\n", info.ID, info.GrantedTTL, info.TTL, strings.Join(info.Keys, ","))
+		}
+	})
+}
+
+// leaseGrant creates a lease. The TTL is a duration or a number of seconds.
+func leaseGrant(ctx context.Context, env *Env, ttlText string) error {
+	ttl, err := time.ParseDuration(ttlText)
+	if err != nil {
+		seconds, convErr := strconv.ParseInt(ttlText, 10, 64)
+		if convErr != nil {
+			return usageErrorf("invalid TTL %q", ttlText)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	if ttl < time.Second {
+		return usageErrorf("TTL must be at least 1s")
+	}
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	info, err := cli.GrantLease(ctx, ttl)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(env.Stdout, newLeaseOutput(info), func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintf(tw, "Granted lease %d with TTL %ds\n", info.ID, info.TTL)
+	})
+}
+
+// leaseUpdate shows, renews or revokes a lease
+func leaseUpdate(ctx context.Context, env *Env, command string, id int64) error {
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+
+	info, err := cli.GetLeaseInfoWithKeys(ctx, id)
+	if err != nil {
+		return err
+	}
+	if info.TTL < 0 {
+		return notFoundErrorf("lease %d not found", id)
+	}
+
+	var message string
+	switch command {
+	case "keepalive":
+		if info.TTL, err = cli.KeepAliveOnce(ctx, id); err != nil {
+			return err
+		}
+		message = fmt.Sprintf("Renewed lease %d, TTL %ds", id, info.TTL)
+	case "revoke":
+		// Revoking deletes the attached keys, so protected ones need
+		// --confirm with the lease ID
+		name := strconv.FormatInt(id, 10)
+		if err := cli.RevokeLease(env.confirm(ctx, name), id); err != nil {
+			return confirmHint(err, name)
+		}
+		message = fmt.Sprintf("Revoked lease %d and deleted %d keys", id, len(info.Keys))
+	}
+
+	return writeOutput(env.Stdout, newLeaseOutput(info), func(tw *tabwriter.Writer) {
+		if message != "" {
+			_, _ = fmt.Fprintln(tw, message)
+			return
+		}
+		_, _ = fmt.Fprintf(tw, "ID:\t%d\nGranted:\t%ds\nRemaining:\t%ds\nKeys:\t%s\n",
+			info.ID, info.GrantedTTL, info.TTL, strings.Join(info.Keys, ", "))
+	})
+}
+
+// newLeaseOutput converts a lease for printing
+func newLeaseOutput(info *client.LeaseInfo) *leaseOutput {
+	return &leaseOutput{ID: info.ID, TTL: info.TTL, GrantedTTL: info.GrantedTTL, Keys: info.Keys}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the ls command
+var (
+	lsFlat     bool
+	lsRevision int64
+)
+
+func init() {
+	register(&Command{
+		Name:  "ls",
+		Usage: "ls [prefix]",
+		Short: "List the keys with a prefix as a tree by path segment, or flat with revisions",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&lsFlat, "flat", false, "List full keys with revisions instead of a tree")
+			fs.Int64Var(&lsRevision, "revision", 0, "List the keys at this revision instead of the current one")
+			outputFlag(fs)
+		},
+		Run: runLs,
+	})
+}
+
+// lsNode is a path segment of the ls tree
+type lsNode struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Key is set if a key is stored at this path
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	Children []*lsNode `json:"children,omitempty" yaml:"children,omitempty"`
+
+	index map[string]*lsNode
+}
+
+// child returns the child named name, adding it if needed
+func (n *lsNode) child(name string) *lsNode {
+	if n.index == nil {
+		n.index = make(map[string]*lsNode)
+	}
+	c, ok := n.index[name]
+	if !ok {
+		c = &lsNode{Name: name}
+		n.index[name] = c
+		n.Children = append(n.Children, c)
+	}
+	return c
+}
+
+// runLs lists the keys with a prefix
+func runLs(ctx context.Context, env *Env, args []string) error {
+	if len(args) > 1 {
+		return usageErrorf("expected at most one prefix")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	kvs, _, err := cli.ListAll(ctx, client.ListOptions{Prefix: prefix, KeysOnly: true, Revision: lsRevision}, nil)
+	if err != nil {
+		return err
+	}
+
+	if lsFlat {
+		list := make([]*keyOutput, 0, len(kvs))
+		for _, kv := range kvs {
+			list = append(list, newKeyOutput(kv, false))
+		}
+		return writeOutput(env.Stdout, list, func(tw *tabwriter.Writer) {
+			_, _ = fmt.Fprintln(tw, "KEY\tMOD REVISION\tVERSION\tLEASE")
+			for _, kv := range kvs {
+				_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", kv.Key, kv.ModRevision, kv.Version, leaseText(kv.Lease))
+			}
+		})
+	}
+
+	root := lsTree(kvs, prefix)
+	return writeOutput(env.Stdout, root.Children, func(tw *tabwriter.Writer) {
+		printLsTree(tw, root.Children, 0)
+	})
+}
+
+// lsTree arranges keys relative to prefix by path segment. Directories are
+// named with a trailing separator; a key stored at the prefix itself is
+// named ".".
+func lsTree(kvs []*client.KeyValue, prefix string) *lsNode {
+	root := &lsNode{}
+	for _, kv := range kvs {
+		rel := strings.TrimPrefix(kv.Key, prefix)
+		if rel == "" {
+			root.child(client.NestedValueKey).Key = kv.Key
+			continue
+		}
+
+		node := root
+		segments := strings.Split(rel, client.KeySeparator)
+		for _, segment := range segments[:len(segments)-1] {
+			node = node.child(segment + client.KeySeparator)
+		}
+		if last := segments[len(segments)-1]; last != "" {
+			node.child(last).Key = kv.Key
+		} else {
+			// A key ending with the separator marks its directory
+			node.Key = kv.Key
+		}
+	}
+	return root
+}
+
+// printLsTree prints nodes indented by depth
+func printLsTree(tw *tabwriter.Writer, nodes []*lsNode, depth int) {
+	for _, n := range nodes {
+		_, _ = fmt.Fprintf(tw, "%s%s\n", strings.Repeat("  ", depth), n.Name)
+		printLsTree(tw, n.Children, depth+1)
+	}
+}
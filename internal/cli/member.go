@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the member command
+var (
+	memberLearner bool
+	memberName    string
+)
+
+func init() {
+	register(&Command{
+		Name:  "member",
+		Usage: "member <command> [id|urls]",
+		Short: "Manage members: list, add <peer-urls>, remove <id>, promote <id>",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&memberLearner, "learner", false, "Add the member as a non-voting learner")
+			fs.StringVar(&memberName, "name", "", "Name the added member will be started with, for its initial cluster setting")
+			outputFlag(fs)
+		},
+		Run: runMember,
+	})
+}
+
+// memberOutput is a member as printed by the member commands
+type memberOutput struct {
+	ID         string   `json:"id" yaml:"id"`
+	Name       string   `json:"name" yaml:"name"`
+	PeerURLs   []string `json:"peer_urls" yaml:"peer_urls"`
+	ClientURLs []string `json:"client_urls" yaml:"client_urls"`
+	Learner    bool     `json:"learner" yaml:"learner"`
+}
+
+// memberAddOutput is the result of adding a member
+type memberAddOutput struct {
+	Member *memberOutput `json:"member" yaml:"member"`
+
+	// InitialCluster is the initial cluster setting to start the member
+	// with, when its name is known
+	InitialCluster string `json:"initial_cluster,omitempty" yaml:"initial_cluster,omitempty"`
+}
+
+// runMember dispatches the member subcommands
+func runMember(ctx context.Context, env *Env, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected list, add, remove or promote")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "list":
+		if len(args) != 0 {
+			return usageErrorf("member list takes no arguments")
+		}
+		return memberList(ctx, env)
+	case "add":
+		if len(args) != 1 {
+			return usageErrorf("expected comma separated peer URLs")
+		}
+		return memberAdd(ctx, env, strings.Split(args[0], ","))
+	case "remove", "promote":
+		if len(args) != 1 {
+			return usageErrorf("expected a member ID")
+		}
+		id, err := strconv.ParseUint(strings.TrimPrefix(args[0], "0x"), 16, 64)
+		if err != nil {
+			return usageErrorf("invalid member ID %q, expected hex", args[0])
+		}
+		return memberUpdate(ctx, env, command, id)
+	default:
+		return usageErrorf("unknown member command: %s", command)
+	}
+}
+
+// memberList prints the members of the cluster
+func memberList(ctx context.Context, env *Env) error {
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	members, err := cli.ListMembers(ctx)
+	if err != nil {
+		return err
+	}
+
+	list := make([]*memberOutput, 0, len(members))
+	for _, m := range members {
+		list = append(list, newMemberOutput(m))
+	}
+	return writeOutput(env.Stdout, list, func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tPEER URLS\tCLIENT URLS")
+		for _, m := range members {
+			_, _ = fmt.Fprintf(tw, "%x\t%s\t%s\t%s\t%s\n", m.ID, m.Name, memberState(m),
+				strings.Join(m.PeerURLs, ","), strings.Join(m.ClientURLs, ","))
+		}
+	})
+}
+
+// memberAdd adds a member and prints how to start it
+func memberAdd(ctx context.Context, env *Env, peerURLs []string) error {
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	added, members, err := cli.AddMember(ctx, peerURLs, memberLearner)
+	if err != nil {
+		return err
+	}
+
+	out := &memberAddOutput{Member: newMemberOutput(added)}
+	if memberName != "" {
+		out.InitialCluster = client.InitialCluster(members, added.ID, memberName)
+	}
+	return writeOutput(env.Stdout, out, func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintf(tw, "Added member %x (%s)\n", added.ID, memberState(added))
+		if out.InitialCluster != "" {
+			_, _ = fmt.Fprintf(tw, "\nStart it with:\n  --name=%s\n  --initial-cluster=%s\n  --initial-cluster-state=existing\n", memberName, out.InitialCluster)
+		}
+	})
+}
+
+// memberUpdate removes or promotes a member
+func memberUpdate(ctx context.Context, env *Env, command string, id uint64) error {
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	members, err := cli.ListMembers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var target *client.Member
+	for _, m := range members {
+		if m.ID == id {
+			target = m
+		}
+	}
+	if target == nil {
+		return notFoundErrorf("member %x not found", id)
+	}
+
+	verb := "Removed"
+	if command == "promote" {
+		verb = "Promoted"
+		if !target.IsLearner {
+			return fmt.Errorf("member %x is not a learner", id)
+		}
+		err = cli.PromoteMember(ctx, id)
+		target.IsLearner = false
+	} else {
+		err = cli.RemoveMember(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(env.Stdout, newMemberOutput(target), func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintf(tw, "%s member %x (%s)\n", verb, id, target.Name)
+	})
+}
+
+// memberState describes whether a member is started and voting
+func memberState(m *client.Member) string {
+	switch {
+	case !m.Started():
+		return "unstarted"
+	case m.IsLearner:
+		return "learner"
+	default:
+		return "started"
+	}
+}
+
+// newMemberOutput converts a member for printing
+func newMemberOutput(m *client.Member) *memberOutput {
+	return &memberOutput{
+		ID:         fmt.Sprintf("%x", m.ID),
+		Name:       m.Name,
+		PeerURLs:   m.PeerURLs,
+		ClientURLs: m.ClientURLs,
+		Learner:    m.IsLearner,
+	}
+}
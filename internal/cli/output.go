@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+// Output formats of the --output flag
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// outputFormat is the --output flag of the running command
+var outputFormat string
+
+// outputFlag registers the --output flag
+func outputFlag(fs *pflag.FlagSet) {
+	fs.StringVarP(&outputFormat, "output", "o", OutputTable, "Output format: table, json or yaml")
+}
+
+// checkOutput validates the --output flag
+func checkOutput() error {
+	switch outputFormat {
+	case OutputTable, OutputJSON, OutputYAML:
+		return nil
+	}
+	return usageErrorf("unknown output format %q, expected table, json or yaml", outputFormat)
+}
+
+// writeOutput writes v as JSON or YAML, or as a table written by table
+func writeOutput(w io.Writer, v any, table func(tw *tabwriter.Writer)) error {
+	switch outputFormat {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+}
+
+// keyOutput is a key as printed by get and ls
+type keyOutput struct {
+	Key string `json:"key" yaml:"key"`
+
+	// Value is nil when only keys were read. Values that are not text are
+	// base64 encoded as in exports.
+	Value *string `json:"value,omitempty" yaml:"value,omitempty"`
+
+	CreateRevision int64 `json:"create_revision" yaml:"create_revision"`
+	ModRevision    int64 `json:"mod_revision" yaml:"mod_revision"`
+	Version        int64 `json:"version" yaml:"version"`
+	Lease          int64 `json:"lease,omitempty" yaml:"lease,omitempty"`
+}
+
+// newKeyOutput converts kv, with its value if withValue is set
+func newKeyOutput(kv *client.KeyValue, withValue bool) *keyOutput {
+	out := &keyOutput{
+		Key:            kv.Key,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Version:        kv.Version,
+		Lease:          kv.Lease,
+	}
+	if withValue {
+		value := client.EncodeValue(kv.Value)
+		out.Value = &value
+	}
+	return out
+}
+
+// maxPreview is the number of characters of a value shown in tables
+const maxPreview = 60
+
+// previewValue returns the first line of a value shortened for a table
+// cell, or its size if it is not text
+func previewValue(value []byte) string {
+	if !client.IsPrintable(value) {
+		return fmt.Sprintf("(binary, %s)", client.FormatBytes(int64(len(value))))
+	}
+	text, rest, multiline := strings.Cut(string(value), "\n")
+	text = strings.ReplaceAll(text, "\t", " ")
+	if runes := []rune(text); len(runes) > maxPreview {
+		return string(runes[:maxPreview]) + "…"
+	}
+	if multiline && rest != "" {
+		return text + " …"
+	}
+	return text
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Flags of the put command
+var (
+	putLease          string
+	putTTL            time.Duration
+	putSkipValidation bool
+)
+
+func init() {
+	register(&Command{
+		Name:  "put",
+		Usage: "put <key> [value]",
+		Short: "Store a value at a key, read from stdin when no value is given",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&putLease, "lease", "", "Attach the key to this lease ID")
+			fs.DurationVar(&putTTL, "ttl", 0, "Attach the key to a new lease with this TTL, e.g. 30s")
+			fs.BoolVar(&putSkipValidation, "skip-validation", false, "Store the value even if it fails the validation rules of the config")
+			outputFlag(fs)
+		},
+		Confirm: true,
+		Run:     runPut,
+	})
+}
+
+// putOutput is the result of put
+type putOutput struct {
+	Key      string `json:"key" yaml:"key"`
+	Revision int64  `json:"revision" yaml:"revision"`
+	Lease    int64  `json:"lease,omitempty" yaml:"lease,omitempty"`
+}
+
+// runPut stores a value
+func runPut(ctx context.Context, env *Env, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return usageErrorf("expected a key and an optional value")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+	if putLease != "" && putTTL != 0 {
+		return usageErrorf("--lease and --ttl are mutually exclusive")
+	}
+	if putTTL < 0 || (putTTL > 0 && putTTL < time.Second) {
+		return usageErrorf("--ttl must be at least 1s")
+	}
+
+	var leaseID int64
+	if putLease != "" {
+		var err error
+		if leaseID, err = parseLeaseID(putLease); err != nil {
+			return err
+		}
+	}
+
+	// Connect first: --password-stdin takes the first line of stdin
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+
+	key := args[0]
+	var value string
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		data, err := io.ReadAll(env.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read value from stdin: %w", err)
+		}
+		value = string(data)
+	}
+
+	if !putSkipValidation {
+		if err := validateValue(env, key, []byte(value)); err != nil {
+			return err
+		}
+	}
+
+	if putTTL > 0 {
+		lease, err := cli.GrantLease(ctx, putTTL)
+		if err != nil {
+			return err
+		}
+		leaseID = lease.ID
+	}
+
+	op := client.OpPut(key, value)
+	if leaseID != 0 {
+		op = client.OpPutWithLease(key, value, leaseID)
+	}
+	result, err := cli.NewTxn().Then(op).Commit(env.confirm(ctx, key))
+	if err != nil {
+		return confirmHint(fmt.Errorf("failed to put key %s: %w", key, err), key)
+	}
+
+	out := &putOutput{Key: key, Revision: result.Revision, Lease: leaseID}
+	return writeOutput(env.Stdout, out, func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintf(tw, "OK %s at revision %d", key, result.Revision)
+		if leaseID != 0 {
+			_, _ = fmt.Fprintf(tw, " with lease %d", leaseID)
+		}
+		_, _ = fmt.Fprintln(tw)
+	})
+}
+
+// validateValue checks value against the validation rules of the config
+// file, listing every problem on stderr
+func validateValue(env *Env, key string, value []byte) error {
+	v, err := env.Validator()
+	if err != nil {
+		return err
+	}
+
+	var validationErr *client.ValidationError
+	if !errors.As(v.Validate(key, value), &validationErr) {
+		return nil
+	}
+	for _, issue := range validationErr.Issues {
+		_, _ = fmt.Fprintf(env.Stderr, "  %s\n", issue)
+	}
+	return fmt.Errorf("%s: %w; --skip-validation stores it anyway", key, client.ErrInvalidValue)
+}
+
+// parseLeaseID parses a lease ID in decimal or, with a 0x prefix, in hex
+func parseLeaseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 0, 64)
+	if err != nil || id <= 0 {
+		return 0, usageErrorf("invalid lease ID %q", s)
+	}
+	return id, nil
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+)
+
+func init() {
+	register(&Command{
+		Name:  "snapshot",
+		Usage: "snapshot save|verify <file>",
+		Short: "Save a verified snapshot of the etcd database or verify a snapshot file",
+		Run:   runSnapshot,
+	})
+}
+
+// runSnapshot dispatches the snapshot subcommands
+func runSnapshot(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 2 {
+		return usageErrorf("expected 'save <file>' or 'verify <file>'")
+	}
+
+	switch args[0] {
+	case "save":
+		return snapshotSave(ctx, env, args[1])
+	case "verify":
+		info, err := client.VerifySnapshot(args[1])
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(env.Stdout, "Snapshot OK: %s (%s, sha256 %s)\n", info.Path, client.FormatBytes(info.Size), info.Hash)
+		return nil
+	default:
+		return usageErrorf("unknown snapshot command: %s", args[0])
+	}
+}
+
+// snapshotSave streams a snapshot to path, printing progress on stderr
+func snapshotSave(ctx context.Context, env *Env, path string) error {
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+
+	interactive := isTerminal(env.Stderr)
+	info, err := cli.SaveSnapshot(ctx, path, func(written int64) {
+		if interactive {
+			_, _ = fmt.Fprintf(env.Stderr, "\rReceived %s   ", client.FormatBytes(written))
+		}
+	})
+	if interactive {
+		_, _ = fmt.Fprintln(env.Stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(env.Stdout, "Snapshot saved: %s (%s, sha256 %s)\n", info.Path, client.FormatBytes(info.Size), info.Hash)
+	return nil
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+)
+
+func init() {
+	register(&Command{
+		Name:  "status",
+		Usage: "status",
+		Short: "Show the status of every member; exits with 1 if the cluster is unhealthy",
+		Flags: outputFlag,
+		Run:   runStatus,
+	})
+}
+
+// statusOutput is the cluster status printed by status
+type statusOutput struct {
+	ClusterID string                `json:"cluster_id" yaml:"cluster_id"`
+	Leader    string                `json:"leader" yaml:"leader"`
+	Healthy   bool                  `json:"healthy" yaml:"healthy"`
+	Members   []*memberStatusOutput `json:"members" yaml:"members"`
+}
+
+// memberStatusOutput is the status of a single member
+type memberStatusOutput struct {
+	ID          string   `json:"id" yaml:"id"`
+	Name        string   `json:"name" yaml:"name"`
+	Endpoint    string   `json:"endpoint" yaml:"endpoint"`
+	Role        string   `json:"role" yaml:"role"`
+	Version     string   `json:"version,omitempty" yaml:"version,omitempty"`
+	DBSize      int64    `json:"db_size,omitempty" yaml:"db_size,omitempty"`
+	DBSizeInUse int64    `json:"db_size_in_use,omitempty" yaml:"db_size_in_use,omitempty"`
+	RaftTerm    uint64   `json:"raft_term,omitempty" yaml:"raft_term,omitempty"`
+	RaftIndex   uint64   `json:"raft_index,omitempty" yaml:"raft_index,omitempty"`
+	Errors      []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// runStatus prints the status of the cluster members
+func runStatus(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 0 {
+		return usageErrorf("status takes no arguments")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+	status, err := cli.GetClusterStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := &statusOutput{
+		ClusterID: fmt.Sprintf("%x", status.ClusterID),
+		Leader:    fmt.Sprintf("%x", status.LeaderID),
+		Healthy:   status.IsHealthy,
+	}
+	for _, m := range status.Members {
+		out.Members = append(out.Members, newMemberStatusOutput(m))
+	}
+
+	if err := writeOutput(env.Stdout, out, func(tw *tabwriter.Writer) {
+		_, _ = fmt.Fprintln(tw, "ID\tNAME\tENDPOINT\tROLE\tVERSION\tDB SIZE\tRAFT TERM\tRAFT INDEX\tERRORS")
+		for _, m := range out.Members {
+			size := "-"
+			if m.DBSize > 0 {
+				size = client.FormatBytes(m.DBSize)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n", m.ID, m.Name, m.Endpoint, m.Role,
+				m.Version, size, m.RaftTerm, m.RaftIndex, strings.Join(m.Errors, "; "))
+		}
+	}); err != nil {
+		return err
+	}
+
+	if !status.IsHealthy {
+		return errors.New("cluster is unhealthy")
+	}
+	return nil
+}
+
+// newMemberStatusOutput converts a member status for printing
+func newMemberStatusOutput(m *client.MemberStatus) *memberStatusOutput {
+	out := &memberStatusOutput{
+		ID:       fmt.Sprintf("%x", m.ID),
+		Name:     m.Name,
+		Endpoint: m.Endpoint,
+		Role:     m.Role(),
+	}
+	if st := m.Status; st != nil {
+		if st.Err != nil {
+			out.Errors = append(out.Errors, st.Err.Error())
+		} else {
+			out.Version = st.Version
+			out.DBSize = st.DBSize
+			out.DBSizeInUse = st.DBSizeInUse
+			out.RaftTerm = st.RaftTerm
+			out.RaftIndex = st.RaftIndex
+			out.Errors = append(out.Errors, st.Errors...)
+		}
+	}
+	return out
+}
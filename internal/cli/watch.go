@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+// Flags of the watch command
+var (
+	watchPrefix   bool
+	watchRevision int64
+	watchCount    int
+)
+
+func init() {
+	register(&Command{
+		Name:  "watch",
+		Usage: "watch <key>",
+		Short: "Print changes of a key or prefix as they happen until interrupted",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&watchPrefix, "prefix", false, "Watch all keys with the key as prefix")
+			fs.Int64Var(&watchRevision, "revision", 0, "Start from this revision instead of now")
+			fs.IntVar(&watchCount, "count", 0, "Exit after this many changes")
+			outputFlag(fs)
+		},
+		Run: runWatch,
+	})
+}
+
+// watchOutput is a change printed by watch
+type watchOutput struct {
+	Type        string  `json:"type" yaml:"type"`
+	Key         string  `json:"key" yaml:"key"`
+	Value       *string `json:"value,omitempty" yaml:"value,omitempty"`
+	PrevValue   *string `json:"prev_value,omitempty" yaml:"prev_value,omitempty"`
+	ModRevision int64   `json:"mod_revision" yaml:"mod_revision"`
+	Version     int64   `json:"version" yaml:"version"`
+}
+
+// runWatch streams changes: a line per change for tables, JSON lines or
+// YAML documents
+func runWatch(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("expected a key")
+	}
+	if err := checkOutput(); err != nil {
+		return err
+	}
+	if watchCount < 0 {
+		return usageErrorf("--count must not be negative")
+	}
+	key := args[0]
+
+	cli, err := env.Client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jsonEnc := json.NewEncoder(env.Stdout)
+	jsonEnc.SetEscapeHTML(false)
+	yamlEnc := yaml.NewEncoder(env.Stdout)
+	defer func() { _ = yamlEnc.Close() }()
+
+	var (
+		seen     int
+		writeErr error
+	)
+	callback := func(e *client.WatchEvent) {
+		// Events of a response keep coming after cancelling
+		if writeErr != nil || (watchCount > 0 && seen >= watchCount) {
+			return
+		}
+
+		switch e.Type {
+		case client.EventTypeProgress:
+			return
+		case client.EventTypeCompacted:
+			_, _ = fmt.Fprintf(env.Stderr, "Warning: changes before revision %d were compacted and are skipped\n", e.CompactRevision)
+			return
+		}
+
+		out := &watchOutput{Type: strings.ToUpper(e.Type.String()), Key: e.Key, ModRevision: e.ModRevision, Version: e.Version}
+		if e.Type == client.EventTypePut {
+			value := client.EncodeValue(e.Value)
+			out.Value = &value
+		}
+		if e.PrevValue != nil {
+			prev := client.EncodeValue(e.PrevValue)
+			out.PrevValue = &prev
+		}
+
+		switch outputFormat {
+		case OutputJSON:
+			writeErr = jsonEnc.Encode(out)
+		case OutputYAML:
+			writeErr = yamlEnc.Encode(out)
+		default:
+			line := fmt.Sprintf("%d %s %s", e.ModRevision, out.Type, e.Key)
+			if e.Type == client.EventTypePut {
+				line += " " + previewValue(e.Value)
+			}
+			_, writeErr = fmt.Fprintln(env.Stdout, line)
+		}
+
+		seen++
+		if writeErr != nil || (watchCount > 0 && seen >= watchCount) {
+			cancel()
+		}
+	}
+
+	if watchPrefix {
+		err = cli.WatchPrefixFromRevision(ctx, key, watchRevision, callback)
+	} else {
+		err = cli.WatchFromRevision(ctx, key, watchRevision, callback)
+	}
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
@@ -29,6 +29,9 @@ type Config struct {
 
 	// ActiveProfile is the name of the currently active profile
 	ActiveProfile string `yaml:"active_profile,omitempty" mapstructure:"active_profile"`
+
+	// Validation lists the rules values must pass before they are written
+	Validation []*ValidationRule `yaml:"validation,omitempty" mapstructure:"validation"`
 }
 
 // Manager handles configuration loading and saving
@@ -129,6 +132,9 @@ func (m *Manager) SaveToPath(path string) error {
 	// Set all values in viper
 	m.v.Set("profiles", m.config.Profiles)
 	m.v.Set("active_profile", m.config.ActiveProfile)
+	if len(m.config.Validation) > 0 {
+		m.v.Set("validation", m.config.Validation)
+	}
 
 	// Write config
 	if err := m.v.WriteConfigAs(path); err != nil {
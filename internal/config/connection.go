@@ -0,0 +1,242 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/spf13/pflag"
+)
+
+// Environment variables read for ad-hoc connections; the names match etcdctl
+const (
+	EnvEndpoints             = "ETCDCTL_ENDPOINTS"
+	EnvUser                  = "ETCDCTL_USER"
+	EnvPassword              = "ETCDCTL_PASSWORD"
+	EnvCACert                = "ETCDCTL_CACERT"
+	EnvCert                  = "ETCDCTL_CERT"
+	EnvKey                   = "ETCDCTL_KEY"
+	EnvInsecureSkipTLSVerify = "ETCDCTL_INSECURE_SKIP_TLS_VERIFY"
+	EnvDialTimeout           = "ETCDCTL_DIAL_TIMEOUT"
+	EnvCommandTimeout        = "ETCDCTL_COMMAND_TIMEOUT"
+)
+
+// AdHocProfileName names a profile built from flags and environment
+// variables that is not saved
+const AdHocProfileName = "ad-hoc"
+
+// ConnectionFlags are connection settings given on the command line instead
+// of, or on top of, a saved profile
+type ConnectionFlags struct {
+	Endpoints          []string
+	User               string
+	PasswordStdin      bool
+	CACert             string
+	Cert               string
+	Key                string
+	InsecureSkipVerify bool
+	DialTimeout        time.Duration
+	CommandTimeout     time.Duration
+
+	// SaveProfile names the profile to save the settings as once connected
+	SaveProfile string
+
+	fs *pflag.FlagSet
+}
+
+// Register adds the connection flags to fs
+func (f *ConnectionFlags) Register(fs *pflag.FlagSet) {
+	f.fs = fs
+	fs.StringSliceVar(&f.Endpoints, "endpoints", nil, "Comma separated etcd endpoints to connect to without a profile")
+	fs.StringVar(&f.User, "user", "", "Username, or username:password, for authentication")
+	fs.BoolVar(&f.PasswordStdin, "password-stdin", false, "Read the password from the first line of stdin")
+	fs.StringVar(&f.CACert, "cacert", "", "CA certificate file to verify the server with")
+	fs.StringVar(&f.Cert, "cert", "", "Client certificate file")
+	fs.StringVar(&f.Key, "key", "", "Client key file")
+	fs.BoolVar(&f.InsecureSkipVerify, "insecure-skip-tls-verify", false, "Skip server certificate verification (not recommended)")
+	fs.DurationVar(&f.DialTimeout, "dial-timeout", 0, "Connection timeout (default 5s)")
+	fs.DurationVar(&f.CommandTimeout, "command-timeout", 0, "Request timeout (default 5s)")
+	fs.StringVar(&f.SaveProfile, "save-profile", "", "Save the connection settings as a profile with this name once connected")
+}
+
+// Profile builds the profile to connect with. Flags override the fields of
+// base, the profile selected by name. Without base the ETCDCTL_* variables
+// read with getenv fill in what the flags leave out, and endpoints default
+// to the local one. Returns base unchanged when no setting was given.
+func (f *ConnectionFlags) Profile(base *Profile, getenv func(string) string, stdin io.Reader) (*Profile, error) {
+	if base != nil {
+		// The environment only stands in for a profile
+		getenv = nil
+	}
+	if getenv == nil {
+		getenv = func(string) string { return "" }
+	}
+
+	p := &Profile{Name: AdHocProfileName}
+	if base != nil {
+		copied := *base
+		if base.TLS != nil {
+			tls := *base.TLS
+			copied.TLS = &tls
+		}
+		copied.Default = false
+		p = &copied
+	}
+	set := f.SaveProfile != ""
+
+	if v, ok := f.lookup("endpoints", strings.Join(f.Endpoints, ","), getenv(EnvEndpoints)); ok {
+		p.Endpoints = splitEndpoints(v)
+		set = true
+	}
+
+	if v, ok := f.lookup("user", f.User, getenv(EnvUser)); ok {
+		username, password, _ := strings.Cut(v, ":")
+		p.Username = username
+		p.Password = EncodePassword(password)
+		set = true
+	}
+	if v := getenv(EnvPassword); v != "" && p.Password == "" {
+		p.Password = EncodePassword(v)
+		set = true
+	}
+	if f.PasswordStdin {
+		password, err := readPasswordLine(stdin)
+		if err != nil {
+			return nil, err
+		}
+		p.Password = EncodePassword(password)
+		set = true
+	}
+	if p.Password != "" && p.Username == "" {
+		return nil, errors.New("a password needs a user, set with --user")
+	}
+
+	tls := p.TLS
+	if tls == nil {
+		tls = &TLSProfile{}
+	}
+	tlsSet := false
+	for _, file := range []struct {
+		flag, value, env string
+		dst              *string
+	}{
+		{"cacert", f.CACert, getenv(EnvCACert), &tls.CAFile},
+		{"cert", f.Cert, getenv(EnvCert), &tls.CertFile},
+		{"key", f.Key, getenv(EnvKey), &tls.KeyFile},
+	} {
+		if v, ok := f.lookup(file.flag, file.value, file.env); ok {
+			*file.dst = v
+			tlsSet = true
+		}
+	}
+	if v, ok := f.lookup("insecure-skip-tls-verify", strconv.FormatBool(f.InsecureSkipVerify), getenv(EnvInsecureSkipTLSVerify)); ok {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", EnvInsecureSkipTLSVerify, v)
+		}
+		tls.InsecureSkipVerify = skip
+		tlsSet = true
+	}
+	for _, endpoint := range p.Endpoints {
+		if strings.HasPrefix(endpoint, "https://") {
+			tlsSet = true
+		}
+	}
+	if tlsSet {
+		tls.Enabled = true
+		p.TLS = tls
+		set = true
+	}
+	if p.TLS != nil && (p.TLS.CertFile == "") != (p.TLS.KeyFile == "") {
+		return nil, errors.New("a client certificate and key must be given together")
+	}
+
+	if v, ok := f.lookup("dial-timeout", f.DialTimeout.String(), getenv(EnvDialTimeout)); ok {
+		p.DialTimeout = v
+		set = true
+	}
+	if v, ok := f.lookup("command-timeout", f.CommandTimeout.String(), getenv(EnvCommandTimeout)); ok {
+		p.RequestTimeout = v
+		set = true
+	}
+
+	if !set {
+		return base, nil
+	}
+	if f.SaveProfile != "" {
+		p.Name = f.SaveProfile
+	}
+	if len(p.Endpoints) == 0 {
+		p.Endpoints = client.DefaultConfig().Endpoints
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// lookup returns the value of a flag if it was given, otherwise the value
+// of its environment variable if set
+func (f *ConnectionFlags) lookup(flag, value, envValue string) (string, bool) {
+	if f.fs != nil && f.fs.Changed(flag) {
+		return value, true
+	}
+	return envValue, envValue != ""
+}
+
+// splitEndpoints splits a comma separated endpoint list
+func splitEndpoints(s string) []string {
+	var endpoints []string
+	for _, endpoint := range strings.Split(s, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// readPasswordLine reads the password from the first line of r. It reads
+// byte by byte so that the rest of r is left for the command.
+func readPasswordLine(r io.Reader) (string, error) {
+	if r == nil {
+		return "", errors.New("no stdin to read the password from")
+	}
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+	}
+	password := strings.TrimRight(string(line), "\r")
+	if password == "" {
+		return "", errors.New("no password on stdin")
+	}
+	return password, nil
+}
+
+// SaveNewProfile adds a profile and writes the config file. A profile with
+// the same name is not replaced.
+func (m *Manager) SaveNewProfile(profile *Profile) error {
+	if _, err := m.GetProfile(profile.Name); err == nil {
+		return fmt.Errorf("%w: %s", ErrProfileExists, profile.Name)
+	}
+	if err := m.AddProfile(profile); err != nil {
+		return err
+	}
+	return m.Save()
+}
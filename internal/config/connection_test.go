@@ -0,0 +1,182 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// TestConnectionFlagsProfile verifies how flags, ETCDCTL_* variables and a
+// base profile combine
+func TestConnectionFlagsProfile(t *testing.T) {
+	base := &Profile{
+		Name:      "prod",
+		Endpoints: []string{"prod:2379"},
+		Username:  "admin",
+		Default:   true,
+	}
+
+	tests := []struct {
+		name  string
+		base  *Profile
+		args  []string
+		env   map[string]string
+		stdin string
+
+		// check inspects the profile built; nil expects an error
+		check func(t *testing.T, p *Profile)
+	}{
+		{
+			name: "nothing given",
+			check: func(t *testing.T, p *Profile) {
+				if p != nil {
+					t.Errorf("profile = %+v, want nil", p)
+				}
+			},
+		},
+		{
+			name: "nothing given keeps base",
+			base: base,
+			env:  map[string]string{EnvEndpoints: "env:2379"},
+			check: func(t *testing.T, p *Profile) {
+				if p != base {
+					t.Errorf("profile = %+v, want base unchanged", p)
+				}
+			},
+		},
+		{
+			name: "variables without flags",
+			env:  map[string]string{EnvEndpoints: "a:2379, b:2379", EnvUser: "bob", EnvPassword: "pw"},
+			check: func(t *testing.T, p *Profile) {
+				if p.Name != AdHocProfileName || !slices.Equal(p.Endpoints, []string{"a:2379", "b:2379"}) {
+					t.Errorf("profile = %+v, want ad-hoc with both endpoints", p)
+				}
+				if p.Username != "bob" || p.DecodePassword() != "pw" {
+					t.Errorf("credentials = %s/%s, want bob/pw", p.Username, p.DecodePassword())
+				}
+			},
+		},
+		{
+			name: "flags beat variables",
+			args: []string{"--endpoints=flag:2379", "--user=carol"},
+			env:  map[string]string{EnvEndpoints: "env:2379", EnvUser: "bob", EnvCommandTimeout: "3s"},
+			check: func(t *testing.T, p *Profile) {
+				if !slices.Equal(p.Endpoints, []string{"flag:2379"}) || p.Username != "carol" {
+					t.Errorf("profile = %+v, want flag endpoint and user", p)
+				}
+				if p.RequestTimeout != "3s" {
+					t.Errorf("request timeout = %q, want 3s from the variable", p.RequestTimeout)
+				}
+			},
+		},
+		{
+			name: "variables ignored with base",
+			base: base,
+			args: []string{"--dial-timeout=2s"},
+			env:  map[string]string{EnvEndpoints: "env:2379", EnvUser: "bob"},
+			check: func(t *testing.T, p *Profile) {
+				if !slices.Equal(p.Endpoints, base.Endpoints) || p.Username != "admin" || p.DialTimeout != "2s" {
+					t.Errorf("profile = %+v, want base with the flag applied", p)
+				}
+				if p == base || p.Default || base.DialTimeout != "" {
+					t.Error("base profile was changed")
+				}
+			},
+		},
+		{
+			name: "user and password split",
+			args: []string{"--user=dave:pa:ss"},
+			check: func(t *testing.T, p *Profile) {
+				if p.Username != "dave" || p.DecodePassword() != "pa:ss" {
+					t.Errorf("credentials = %s/%s, want dave/pa:ss", p.Username, p.DecodePassword())
+				}
+			},
+		},
+		{
+			name:  "password on stdin",
+			args:  []string{"--user=erin", "--password-stdin"},
+			env:   map[string]string{EnvPassword: "env"},
+			stdin: "secret\r\nrest",
+			check: func(t *testing.T, p *Profile) {
+				if p.DecodePassword() != "secret" {
+					t.Errorf("password = %q, want secret", p.DecodePassword())
+				}
+			},
+		},
+		{
+			name: "password without user",
+			env:  map[string]string{EnvPassword: "pw"},
+		},
+		{
+			name: "certificate without key",
+			args: []string{"--cert=client.pem"},
+		},
+		{
+			name: "key from variable completes certificate",
+			args: []string{"--cert=client.pem"},
+			env:  map[string]string{EnvKey: "client-key.pem"},
+			check: func(t *testing.T, p *Profile) {
+				if p.TLS == nil || !p.TLS.Enabled || p.TLS.CertFile != "client.pem" || p.TLS.KeyFile != "client-key.pem" {
+					t.Errorf("TLS = %+v, want certificate and key", p.TLS)
+				}
+			},
+		},
+		{
+			name: "https endpoint enables TLS",
+			args: []string{"--endpoints=https://secure:2379"},
+			check: func(t *testing.T, p *Profile) {
+				if p.TLS == nil || !p.TLS.Enabled {
+					t.Errorf("TLS = %+v, want enabled", p.TLS)
+				}
+			},
+		},
+		{
+			name: "http endpoint leaves TLS off",
+			args: []string{"--endpoints=http://plain:2379"},
+			check: func(t *testing.T, p *Profile) {
+				if p.TLS != nil {
+					t.Errorf("TLS = %+v, want none", p.TLS)
+				}
+			},
+		},
+		{
+			name: "invalid skip verify variable",
+			env:  map[string]string{EnvInsecureSkipTLSVerify: "maybe"},
+		},
+		{
+			name: "save profile alone",
+			args: []string{"--save-profile=local"},
+			check: func(t *testing.T, p *Profile) {
+				if p == nil || p.Name != "local" || len(p.Endpoints) != 1 {
+					t.Errorf("profile = %+v, want local with the default endpoint", p)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flags ConnectionFlags
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.Register(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			getenv := func(name string) string { return tt.env[name] }
+
+			p, err := flags.Profile(tt.base, getenv, strings.NewReader(tt.stdin))
+			if tt.check == nil {
+				if err == nil {
+					t.Errorf("Profile() = %+v, want error", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Profile() error: %v", err)
+			}
+			tt.check(t, p)
+		})
+	}
+}
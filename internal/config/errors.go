@@ -20,4 +20,11 @@ var (
 
 	// ErrNoDefaultProfile is returned when no default profile is set
 	ErrNoDefaultProfile = errors.New("no default profile set")
+
+	// ErrInvalidTimeout is returned when a timeout is not a positive duration
+	ErrInvalidTimeout = errors.New("invalid timeout")
+
+	// ErrProfileExists is returned when saving a profile under a name that
+	// is already taken
+	ErrProfileExists = errors.New("profile already exists")
 )
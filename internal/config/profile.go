@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/base64"
+	"fmt"
 	"strings"
 	"time"
 
@@ -26,10 +27,27 @@ type Profile struct {
 	// TLS configuration (optional)
 	TLS *TLSProfile `yaml:"tls,omitempty" mapstructure:"tls"`
 
+	// DialTimeout is how long to wait for a connection, e.g. "10s"
+	// (optional, defaults to 5s)
+	DialTimeout string `yaml:"dial_timeout,omitempty" mapstructure:"dial_timeout"`
+
+	// RequestTimeout is how long a single request may take, e.g. "30s"
+	// (optional, defaults to 5s)
+	RequestTimeout string `yaml:"request_timeout,omitempty" mapstructure:"request_timeout"`
+
 	// Default marks this profile as the default connection
 	Default bool `yaml:"default,omitempty" mapstructure:"default"`
+
+	// ReadOnly refuses every change to the cluster made with this profile
+	ReadOnly bool `yaml:"read_only,omitempty" mapstructure:"read_only"`
+
+	// ProtectedPrefixes restrict changes to the keys below them (optional)
+	ProtectedPrefixes []*ProtectedPrefix `yaml:"protected_prefixes,omitempty" mapstructure:"protected_prefixes"`
 }
 
+// DefaultTimeout is used for profiles without dial or request timeout
+const DefaultTimeout = 5 * time.Second
+
 // TLSProfile represents TLS configuration in a profile
 type TLSProfile struct {
 	// Enabled enables TLS
@@ -75,14 +93,22 @@ func EncodePassword(password string) string {
 	return "base64:" + base64.StdEncoding.EncodeToString([]byte(password))
 }
 
-// ToClientConfig converts Profile to client.Config
-func (p *Profile) ToClientConfig() *client.Config {
+// ToClientConfig converts Profile to client.Config, with the guard of the
+// profile checking every change the client makes. Invalid protected
+// prefixes are an error rather than ignored.
+func (p *Profile) ToClientConfig() (*client.Config, error) {
+	guard, err := p.Guard()
+	if err != nil {
+		return nil, fmt.Errorf("invalid protected prefixes: %w", err)
+	}
+
 	cfg := &client.Config{
 		Endpoints:      p.Endpoints,
 		Username:       p.Username,
 		Password:       p.DecodePassword(),
-		DialTimeout:    5 * time.Second,
-		RequestTimeout: 5 * time.Second,
+		DialTimeout:    parseTimeout(p.DialTimeout),
+		RequestTimeout: parseTimeout(p.RequestTimeout),
+		Guard:          guard,
 	}
 
 	if p.TLS != nil && p.TLS.Enabled {
@@ -95,7 +121,7 @@ func (p *Profile) ToClientConfig() *client.Config {
 		}
 	}
 
-	return cfg
+	return cfg, nil
 }
 
 // Validate checks if the profile has required fields
@@ -106,9 +132,30 @@ func (p *Profile) Validate() error {
 	if len(p.Endpoints) == 0 {
 		return ErrEndpointsRequired
 	}
+	for _, timeout := range []string{p.DialTimeout, p.RequestTimeout} {
+		if timeout == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+			return fmt.Errorf("%w: %q", ErrInvalidTimeout, timeout)
+		}
+	}
+	if _, err := p.Guard(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// parseTimeout parses a profile timeout, using DefaultTimeout when it is
+// empty or invalid
+func parseTimeout(timeout string) time.Duration {
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return DefaultTimeout
+	}
+	return d
+}
+
 // HasAuth returns true if profile has authentication configured
 func (p *Profile) HasAuth() bool {
 	return p.Username != ""
@@ -138,6 +185,9 @@ func (p *Profile) DisplayString() string {
 	if p.Default {
 		flags = append(flags, "default")
 	}
+	if p.ReadOnly {
+		flags = append(flags, "read-only")
+	}
 
 	if len(flags) > 0 {
 		parts = append(parts, "["+strings.Join(flags, ", ")+"]")
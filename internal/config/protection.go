@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+)
+
+// ProtectedPrefix restricts the changes a profile may make below a prefix,
+// e.g.
+//
+//	protected_prefixes:
+//	  - prefix: /config/
+//	    rule: confirm      # type the key to write or delete
+//	  - prefix: /services/
+//	    rule: no-delete
+//	  - prefix: /secrets/
+//	    rule: no-write
+type ProtectedPrefix struct {
+	// Prefix matches keys starting with it
+	Prefix string `yaml:"prefix" mapstructure:"prefix"`
+
+	// Rule is confirm, no-delete or no-write
+	Rule string `yaml:"rule" mapstructure:"rule"`
+}
+
+// Guard returns the guard enforcing the read-only flag and the protected
+// prefixes of the profile
+func (p *Profile) Guard() (*client.Guard, error) {
+	prefixes := make([]client.ProtectedPrefix, len(p.ProtectedPrefixes))
+	for i, pp := range p.ProtectedPrefixes {
+		protection, err := client.ParseProtection(pp.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("protected prefix %q: %w", pp.Prefix, err)
+		}
+		prefixes[i] = client.ProtectedPrefix{Prefix: pp.Prefix, Protection: protection}
+	}
+	return client.NewGuard(p.ReadOnly, prefixes)
+}
+
+// IsGuarded reports whether the profile is read-only or protects any prefix
+func (p *Profile) IsGuarded() bool {
+	return p.ReadOnly || len(p.ProtectedPrefixes) > 0
+}
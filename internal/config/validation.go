@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+)
+
+// ValidationRule maps keys to the format and JSON Schema their values must
+// have, e.g.
+//
+//	validation:
+//	  - prefix: /config/
+//	    format: json
+//	    schema: schemas/config.json
+//	  - glob: /services/*/settings.yaml
+//	    format: yaml
+type ValidationRule struct {
+	// Prefix matches keys starting with it
+	Prefix string `yaml:"prefix,omitempty" mapstructure:"prefix"`
+
+	// Glob matches whole keys; * matches within one path segment
+	Glob string `yaml:"glob,omitempty" mapstructure:"glob"`
+
+	// Format is json, yaml, toml, xml, ini or env
+	Format string `yaml:"format,omitempty" mapstructure:"format"`
+
+	// Schema is a JSON Schema file, relative to the config directory
+	Schema string `yaml:"schema,omitempty" mapstructure:"schema"`
+}
+
+// Validator returns a validator for the rules in the config, reading their
+// schema files now, or nil if there are no rules
+func (m *Manager) Validator() (*client.Validator, error) {
+	if len(m.config.Validation) == 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Dir(m.configPath)
+	if m.configPath == "" {
+		var err error
+		if dir, err = GetConfigDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	rules := make([]client.ValidationRule, len(m.config.Validation))
+	for i, r := range m.config.Validation {
+		rules[i] = client.ValidationRule{Prefix: r.Prefix, Glob: r.Glob, Format: r.Format}
+		if r.Schema == "" {
+			continue
+		}
+		schemaPath := r.Schema
+		if !filepath.IsAbs(schemaPath) {
+			schemaPath = filepath.Join(dir, schemaPath)
+		}
+		schema, err := client.LoadSchema(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("validation rule %d: %w", i+1, err)
+		}
+		rules[i].Schema = schema
+	}
+
+	return client.NewValidator(rules)
+}
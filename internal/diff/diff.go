@@ -0,0 +1,224 @@
+// Package diff computes line-based differences between two texts and
+// formats them as unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around changes
+const DefaultContext = 3
+
+// maxCells bounds the size of the LCS table; larger inputs fall back to
+// replacing all differing lines at once
+const maxCells = 4 << 20
+
+// Op is the kind of a diff line
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Line is a single line of an edit script
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the edit script turning a into b, line by line.
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	// Strip the common prefix and suffix, which keeps the table small for
+	// typical edits of large values
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+
+	var out []Line
+	for _, l := range x[:pre] {
+		out = append(out, Line{Equal, l})
+	}
+	out = append(out, lcs(x[pre:len(x)-suf], y[pre:len(y)-suf])...)
+	for _, l := range x[len(x)-suf:] {
+		out = append(out, Line{Equal, l})
+	}
+	return out
+}
+
+// lcs diffs two line slices using a longest common subsequence table
+func lcs(x, y []string) []Line {
+	n, m := len(x), len(y)
+	if n*m > maxCells {
+		out := make([]Line, 0, n+m)
+		for _, l := range x {
+			out = append(out, Line{Delete, l})
+		}
+		for _, l := range y {
+			out = append(out, Line{Insert, l})
+		}
+		return out
+	}
+
+	// t[i][j] is the LCS length of x[i:] and y[j:]
+	t := make([][]int, n+1)
+	for i := range t {
+		t[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				t[i][j] = t[i+1][j+1] + 1
+			} else {
+				t[i][j] = max(t[i+1][j], t[i][j+1])
+			}
+		}
+	}
+
+	out := make([]Line, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, Line{Equal, x[i]})
+			i++
+			j++
+		case t[i+1][j] >= t[i][j+1]:
+			out = append(out, Line{Delete, x[i]})
+			i++
+		default:
+			out = append(out, Line{Insert, y[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		out = append(out, Line{Delete, x[i]})
+	}
+	for ; j < m; j++ {
+		out = append(out, Line{Insert, y[j]})
+	}
+	return out
+}
+
+// split splits text into lines without their line breaks
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified formats the difference between from and to as a unified diff with
+// DefaultContext lines of context. It returns an empty string if the texts
+// are equal.
+func Unified(fromName, toName, from, to string) string {
+	lines := Lines(from, to)
+
+	var b strings.Builder
+	for _, h := range hunks(lines, DefaultContext) {
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", span(h.fromLine, h.fromCount), span(h.toLine, h.toCount))
+		for _, l := range lines[h.start:h.end] {
+			switch l.Op {
+			case Equal:
+				b.WriteString(" ")
+			case Delete:
+				b.WriteString("-")
+			case Insert:
+				b.WriteString("+")
+			}
+			b.WriteString(l.Text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// hunk is a range of the edit script with its line positions
+type hunk struct {
+	start, end          int
+	fromLine, fromCount int
+	toLine, toCount     int
+}
+
+// hunks groups changes that are at most 2*context lines apart
+func hunks(lines []Line, context int) []hunk {
+	var out []hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != Equal {
+				end++
+				continue
+			}
+			// Look ahead: merge with the next change if it is close
+			next := end
+			for next < len(lines) && lines[next].Op == Equal {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = next
+		}
+
+		out = append(out, position(lines, start, end))
+		i = end
+	}
+	return out
+}
+
+// position computes the 1-based line numbers and counts of a hunk
+func position(lines []Line, start, end int) hunk {
+	h := hunk{start: start, end: end, fromLine: 1, toLine: 1}
+	for _, l := range lines[:start] {
+		if l.Op != Insert {
+			h.fromLine++
+		}
+		if l.Op != Delete {
+			h.toLine++
+		}
+	}
+	for _, l := range lines[start:end] {
+		if l.Op != Insert {
+			h.fromCount++
+		}
+		if l.Op != Delete {
+			h.toCount++
+		}
+	}
+	// An empty side is reported at the line before it
+	if h.fromCount == 0 {
+		h.fromLine--
+	}
+	if h.toCount == 0 {
+		h.toLine--
+	}
+	return h
+}
+
+// span formats a hunk range as line,count
+func span(line, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
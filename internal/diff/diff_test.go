@@ -0,0 +1,90 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestLines verifies the line edit scripts
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{"both empty", "", "", nil},
+		{"from empty", "", "a\nb\n", []Line{{Insert, "a"}, {Insert, "b"}}},
+		{"to empty", "a\nb\n", "", []Line{{Delete, "a"}, {Delete, "b"}}},
+		{"equal", "a\nb\n", "a\nb\n", []Line{{Equal, "a"}, {Equal, "b"}}},
+		{"no trailing newline", "a\nb", "a\nb\n", []Line{{Equal, "a"}, {Equal, "b"}}},
+		{"changed line", "a\nb\nc\n", "a\nx\nc\n", []Line{{Equal, "a"}, {Delete, "b"}, {Insert, "x"}, {Equal, "c"}}},
+		{"inserted line", "a\nc\n", "a\nb\nc\n", []Line{{Equal, "a"}, {Insert, "b"}, {Equal, "c"}}},
+		{"deleted line", "a\nb\nc\n", "a\nc\n", []Line{{Equal, "a"}, {Delete, "b"}, {Equal, "c"}}},
+		{"moved line", "a\nb\nc\n", "b\nc\na\n", []Line{{Delete, "a"}, {Equal, "b"}, {Equal, "c"}, {Insert, "a"}}},
+	}
+
+	for _, tt := range tests {
+		if got := Lines(tt.a, tt.b); !slices.Equal(got, tt.want) {
+			t.Errorf("Lines(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestUnified verifies hunk headers, context and merging of nearby changes
+func TestUnified(t *testing.T) {
+	numbered := func(from, to int, replace map[int]string) string {
+		var b strings.Builder
+		for i := from; i <= to; i++ {
+			if r, ok := replace[i]; ok {
+				b.WriteString(r + "\n")
+				continue
+			}
+			b.WriteString(string(rune('a'+i-1)) + "\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"both empty", "", "", ""},
+		{
+			"from empty", "", "a\n",
+			"--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			"to empty", "a\nb\n", "",
+			"--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"missing trailing newline", "a\nb", "a\nc",
+			"--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+		{
+			"context is cut", numbered(1, 10, nil), numbered(1, 10, map[int]string{5: "X"}),
+			"--- old\n+++ new\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+X\n f\n g\n h\n",
+		},
+		{
+			"close changes merge", numbered(1, 12, nil), numbered(1, 12, map[int]string{2: "X", 8: "Y"}),
+			"--- old\n+++ new\n@@ -1,11 +1,11 @@\n a\n-b\n+X\n c\n d\n e\n f\n g\n-h\n+Y\n i\n j\n k\n",
+		},
+		{
+			"distant changes split", numbered(1, 12, nil), numbered(1, 12, map[int]string{1: "X", 12: "Y"}),
+			"--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+X\n b\n c\n d\n@@ -9,4 +9,4 @@\n i\n j\n k\n-l\n+Y\n",
+		},
+		{
+			"insertion only", "a\nb\n", "a\nx\nb\n",
+			"--- old\n+++ new\n@@ -1,2 +1,3 @@\n a\n+x\n b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Unified("old", "new", tt.from, tt.to); got != tt.want {
+			t.Errorf("Unified(%s) =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Conflict markers written by Merge around lines both sides changed
+const (
+	ConflictStart = "<<<<<<<"
+	ConflictSep   = "======="
+	ConflictEnd   = ">>>>>>>"
+)
+
+// change replaces the base lines [start, end) with lines
+type change struct {
+	start, end int
+	lines      []string
+}
+
+// changes returns the changes turning base into other, in base order
+func changes(base, other string) []change {
+	var (
+		out []change
+		cur *change
+		pos int
+	)
+	for _, l := range Lines(base, other) {
+		if l.Op == Equal {
+			if cur != nil {
+				out = append(out, *cur)
+				cur = nil
+			}
+			pos++
+			continue
+		}
+		if cur == nil {
+			cur = &change{start: pos, end: pos}
+		}
+		if l.Op == Delete {
+			cur.end++
+			pos++
+		} else {
+			cur.lines = append(cur.lines, l.Text)
+		}
+	}
+	if cur != nil {
+		out = append(out, *cur)
+	}
+	return out
+}
+
+// apply returns the base lines [start, end) with changes applied
+func apply(base []string, start, end int, changes []change) []string {
+	var out []string
+	pos := start
+	for _, c := range changes {
+		out = append(out, base[pos:c.start]...)
+		out = append(out, c.lines...)
+		pos = c.end
+	}
+	return append(out, base[pos:end]...)
+}
+
+// Merge combines the changes mine and theirs made to base, line by line.
+// Regions changed differently by both sides, including changes that touch,
+// are kept from both between conflict markers labelled with mineName and
+// theirsName. It returns the merged text and the number of conflicts.
+func Merge(base, mine, theirs, mineName, theirsName string) (string, int) {
+	b := split(base)
+	ours, others := changes(base, mine), changes(base, theirs)
+
+	var (
+		out       []string
+		conflicts int
+		pos       int
+		i, j      int
+	)
+	for i < len(ours) || j < len(others) {
+		// Start a region at the earliest change, then grow it with every
+		// change of either side that overlaps or touches it
+		var start, end int
+		if j >= len(others) || (i < len(ours) && ours[i].start <= others[j].start) {
+			start, end = ours[i].start, ours[i].end
+		} else {
+			start, end = others[j].start, others[j].end
+		}
+		fromOurs, fromOthers := i, j
+		for grown := true; grown; {
+			grown = false
+			for ; i < len(ours) && ours[i].start <= end; i++ {
+				end = max(end, ours[i].end)
+				grown = true
+			}
+			for ; j < len(others) && others[j].start <= end; j++ {
+				end = max(end, others[j].end)
+				grown = true
+			}
+		}
+
+		out = append(out, b[pos:start]...)
+		ourLines := apply(b, start, end, ours[fromOurs:i])
+		otherLines := apply(b, start, end, others[fromOthers:j])
+		switch {
+		case fromOthers == j:
+			out = append(out, ourLines...)
+		case fromOurs == i, slices.Equal(ourLines, otherLines):
+			out = append(out, otherLines...)
+		default:
+			conflicts++
+			out = append(out, ConflictStart+" "+mineName)
+			out = append(out, ourLines...)
+			out = append(out, ConflictSep)
+			out = append(out, otherLines...)
+			out = append(out, ConflictEnd+" "+theirsName)
+		}
+		pos = end
+	}
+	out = append(out, b[pos:]...)
+
+	if len(out) == 0 {
+		return "", conflicts
+	}
+	merged := strings.Join(out, "\n")
+	if strings.HasSuffix(mine, "\n") || (mine == "" && strings.HasSuffix(theirs, "\n")) {
+		merged += "\n"
+	}
+	return merged, conflicts
+}
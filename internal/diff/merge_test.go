@@ -0,0 +1,84 @@
+package diff
+
+import "testing"
+
+// TestMerge verifies three-way merges and their conflicts
+func TestMerge(t *testing.T) {
+	const base = "a\nb\nc\nd\ne\nf\n"
+
+	tests := []struct {
+		name          string
+		mine, theirs  string
+		want          string
+		wantConflicts int
+	}{
+		{"unchanged", base, base, base, 0},
+		{"only mine", "a\nB\nc\nd\ne\nf\n", base, "a\nB\nc\nd\ne\nf\n", 0},
+		{"only theirs", base, "a\nb\nc\nd\ne\nF\n", "a\nb\nc\nd\ne\nF\n", 0},
+		{
+			"separate edits",
+			"A\nb\nc\nd\ne\nf\n", "a\nb\nc\nd\ne\nF\n",
+			"A\nb\nc\nd\ne\nF\n", 0,
+		},
+		{
+			"insert and edit",
+			"a\nb\nnew\nc\nd\ne\nf\n", "a\nb\nc\nd\nE\nf\n",
+			"a\nb\nnew\nc\nd\nE\nf\n", 0,
+		},
+		{"identical edits", "a\nX\nc\nd\ne\nf\n", "a\nX\nc\nd\ne\nf\n", "a\nX\nc\nd\ne\nf\n", 0},
+		{
+			"same line edited differently",
+			"a\nmine\nc\nd\ne\nf\n", "a\ntheirs\nc\nd\ne\nf\n",
+			"a\n<<<<<<< mine\nmine\n=======\ntheirs\n>>>>>>> theirs\nc\nd\ne\nf\n", 1,
+		},
+		{
+			"adjacent edits conflict",
+			"a\nB\nc\nd\ne\nf\n", "a\nb\nC\nd\ne\nf\n",
+			"a\n<<<<<<< mine\nB\nc\n=======\nb\nC\n>>>>>>> theirs\nd\ne\nf\n", 1,
+		},
+		{
+			"delete against edit",
+			"a\nc\nd\ne\nf\n", "a\nB\nc\nd\ne\nf\n",
+			"a\n<<<<<<< mine\n=======\nB\n>>>>>>> theirs\nc\nd\ne\nf\n", 1,
+		},
+		{
+			"delete against unchanged",
+			"a\nb\nc\nd\nf\n", base,
+			"a\nb\nc\nd\nf\n", 0,
+		},
+		{
+			"two conflicts",
+			"1\nb\nc\nd\ne\n1\n", "2\nb\nc\nd\ne\n2\n",
+			"<<<<<<< mine\n1\n=======\n2\n>>>>>>> theirs\nb\nc\nd\ne\n<<<<<<< mine\n1\n=======\n2\n>>>>>>> theirs\n", 2,
+		},
+		{"everything deleted", "", "", "", 0},
+	}
+
+	for _, tt := range tests {
+		got, conflicts := Merge(base, tt.mine, tt.theirs, "mine", "theirs")
+		if got != tt.want || conflicts != tt.wantConflicts {
+			t.Errorf("Merge(%s) = %d conflicts\n%s\nwant %d conflicts\n%s", tt.name, conflicts, got, tt.wantConflicts, tt.want)
+		}
+	}
+}
+
+// TestMergeTrailingNewline verifies that the merge ends with a newline if
+// mine does, or theirs when mine is empty
+func TestMergeTrailingNewline(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, mine, theirs string
+		want               string
+	}{
+		{"mine without newline", "a\nb\n", "a\nb", "a\nB\n", "a\nB"},
+		{"mine with newline", "a\nb", "a\nb\n", "A\nb", "A\nb\n"},
+		{"empty mine takes theirs", "", "", "x\n", "x\n"},
+		{"empty base", "", "x\n", "", "x\n"},
+	}
+
+	for _, tt := range tests {
+		if got, _ := Merge(tt.base, tt.mine, tt.theirs, "mine", "theirs"); got != tt.want {
+			t.Errorf("Merge(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package etcdtest starts embedded etcd clusters for tests.
+package etcdtest
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// Member is a member of an embedded test cluster
+type Member struct {
+	Name      string
+	PeerURL   string
+	ClientURL string
+	Etcd      *embed.Etcd
+}
+
+// FreeURL returns a localhost URL on a free port
+func FreeURL(t testing.TB) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+	return "http://" + l.Addr().String()
+}
+
+// StartMember starts an embedded member joining initialCluster. It is
+// stopped when the test ends.
+func StartMember(t testing.TB, m *Member, initialCluster, state string) {
+	t.Helper()
+
+	cfg := embed.NewConfig()
+	cfg.Name = m.Name
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	cfg.LogOutputs = []string{"/dev/null"}
+
+	peer, _ := url.Parse(m.PeerURL)
+	clientURL, _ := url.Parse(m.ClientURL)
+	cfg.ListenPeerUrls = []url.URL{*peer}
+	cfg.AdvertisePeerUrls = []url.URL{*peer}
+	cfg.ListenClientUrls = []url.URL{*clientURL}
+	cfg.AdvertiseClientUrls = []url.URL{*clientURL}
+	cfg.InitialCluster = initialCluster
+	cfg.ClusterState = state
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start member %s: %v", m.Name, err)
+	}
+	m.Etcd = e
+	t.Cleanup(e.Close)
+}
+
+// StartCluster starts a cluster of n embedded members and waits until it
+// is ready
+func StartCluster(t testing.TB, n int) []*Member {
+	t.Helper()
+
+	members := make([]*Member, n)
+	var initial []string
+	for i := range members {
+		members[i] = &Member{Name: fmt.Sprintf("m%d", i), PeerURL: FreeURL(t), ClientURL: FreeURL(t)}
+		initial = append(initial, members[i].Name+"="+members[i].PeerURL)
+	}
+
+	for _, m := range members {
+		StartMember(t, m, strings.Join(initial, ","), embed.ClusterStateFlagNew)
+	}
+
+	for _, m := range members {
+		select {
+		case <-m.Etcd.Server.ReadyNotify():
+		case <-time.After(30 * time.Second):
+			t.Fatalf("Member %s did not become ready", m.Name)
+		}
+	}
+	return members
+}
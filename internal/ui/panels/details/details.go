@@ -15,6 +15,8 @@ const (
 	ActionEdit
 	ActionDelete
 	ActionWatch
+	ActionView
+	ActionHistory
 )
 
 // ActionCallback is called when a button is pressed
@@ -32,7 +34,9 @@ type Panel struct {
 	callback      ActionCallback
 	tabCallback   TabCallback
 	buttonsShown  bool
+	readOnly      bool
 	currentButton int
+	valueMode     ValueMode
 	mu            sync.Mutex
 }
 
@@ -84,6 +88,18 @@ func (p *Panel) initialize() {
 		}
 	})
 
+	p.form.AddButton("View [v]", func() {
+		if p.callback != nil {
+			p.callback(ActionView)
+		}
+	})
+
+	p.form.AddButton("History [H]", func() {
+		if p.callback != nil {
+			p.callback(ActionHistory)
+		}
+	})
+
 	// Setup input capture for button navigation
 	p.form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		// Handle Tab to switch back to Keys panel
@@ -158,14 +174,47 @@ func (p *Panel) SetText(text string) {
 func (p *Panel) ShowButtons() {
 	if !p.buttonsShown {
 		p.mu.Lock()
-		p.currentButton = 0
+		p.currentButton = p.firstEnabledButton()
 		p.mu.Unlock()
 		p.flex.AddItem(p.form, 3, 0, false)
-		p.form.SetFocus(0)
+		p.form.SetFocus(p.currentButton)
 		p.buttonsShown = true
 	}
 }
 
+// SetReadOnly disables the Edit and Delete buttons, for keys the user may
+// not write
+func (p *Panel) SetReadOnly(readOnly bool) {
+	p.Draw()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.readOnly = readOnly
+	p.form.GetButton(0).SetDisabled(readOnly)
+	p.form.GetButton(1).SetDisabled(readOnly)
+	if p.form.GetButton(p.currentButton).IsDisabled() {
+		p.currentButton = p.firstEnabledButton()
+		p.form.SetFocus(p.currentButton)
+	}
+}
+
+// IsReadOnly returns true if the Edit and Delete buttons are disabled
+func (p *Panel) IsReadOnly() bool {
+	return p.readOnly
+}
+
+// firstEnabledButton returns the index of the first button that is not
+// disabled
+func (p *Panel) firstEnabledButton() int {
+	for i := 0; i < p.form.GetButtonCount(); i++ {
+		if !p.form.GetButton(i).IsDisabled() {
+			return i
+		}
+	}
+	return 0
+}
+
 // HideButtons hides the action buttons
 func (p *Panel) HideButtons() {
 	if p.buttonsShown {
@@ -188,3 +237,29 @@ func (p *Panel) SetTabCallback(callback TabCallback) {
 func (p *Panel) GetForm() *tview.Form {
 	return p.form
 }
+
+// SetValueMode sets how values are rendered
+func (p *Panel) SetValueMode(mode ValueMode) {
+	p.valueMode = mode
+}
+
+// GetValueMode returns how values are rendered
+func (p *Panel) GetValueMode() ValueMode {
+	return p.valueMode
+}
+
+// TogglePretty switches between the pretty and the raw value
+func (p *Panel) TogglePretty() ValueMode {
+	if p.valueMode == ValueModePretty {
+		p.valueMode = ValueModeText
+	} else {
+		p.valueMode = ValueModePretty
+	}
+	return p.valueMode
+}
+
+// CycleValueMode switches to the next value rendering mode
+func (p *Panel) CycleValueMode() ValueMode {
+	p.valueMode = (p.valueMode + 1) % valueModeCount
+	return p.valueMode
+}
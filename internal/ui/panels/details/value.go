@@ -0,0 +1,163 @@
+package details
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/rivo/tview"
+)
+
+// ValueMode selects how a value is rendered in the details panel
+type ValueMode int
+
+const (
+	// ValueModePretty formats and colours the value by its detected format
+	ValueModePretty ValueMode = iota
+
+	// ValueModeText shows the value as stored, coloured
+	ValueModeText
+	ValueModeHex
+	ValueModeBase64
+
+	valueModeCount
+)
+
+// String returns the display name of the mode
+func (m ValueMode) String() string {
+	switch m {
+	case ValueModeHex:
+		return "hex"
+	case ValueModeBase64:
+		return "base64"
+	case ValueModePretty:
+		return "pretty"
+	default:
+		return "raw"
+	}
+}
+
+// DefaultValueMode returns pretty for printable values and hex otherwise
+func DefaultValueMode(value []byte) ValueMode {
+	if client.IsPrintable(value) {
+		return ValueModePretty
+	}
+	return ValueModeHex
+}
+
+// RenderValue formats a raw value for a TextView with dynamic colors,
+// colouring text as format. If format cannot pretty print the value, the
+// value is shown as stored and format's error returned.
+// The result never contains color tags from the value itself.
+func RenderValue(value []byte, mode ValueMode, format client.ValueDecoder) (string, error) {
+	switch mode {
+	case ValueModeHex:
+		return tview.Escape(strings.TrimRight(hex.Dump(value), "\n")), nil
+	case ValueModeBase64:
+		return wrap(base64.StdEncoding.EncodeToString(value), 76), nil
+	case ValueModePretty:
+		pretty, err := format.Pretty(value)
+		if err != nil {
+			return highlight(string(value), format), err
+		}
+		return highlight(pretty, format), nil
+	default:
+		return highlight(string(value), format), nil
+	}
+}
+
+// tokenColors are the colours of syntax tokens; others are not coloured
+var tokenColors = map[client.TokenKind]string{
+	client.TokenKey:     "aqua",
+	client.TokenString:  "green",
+	client.TokenNumber:  "fuchsia",
+	client.TokenKeyword: "orange",
+	client.TokenComment: "gray",
+	client.TokenSection: "yellow",
+}
+
+// highlight colours text by the tokens of format. Uncoloured tokens are
+// escaped together, so that brackets split across tokens cannot form a
+// color tag.
+func highlight(text string, format client.ValueDecoder) string {
+	var b, plain strings.Builder
+	flush := func() {
+		b.WriteString(tview.Escape(printableText([]byte(plain.String()))))
+		plain.Reset()
+	}
+	for _, token := range format.Tokens(text) {
+		color, ok := tokenColors[token.Kind]
+		if !ok {
+			plain.WriteString(token.Text)
+			continue
+		}
+		flush()
+		b.WriteString("[" + color + "]" + tview.Escape(printableText([]byte(token.Text))) + "[-]")
+	}
+	flush()
+	return b.String()
+}
+
+// EscapeText escapes a key or other text for a TextView with dynamic colors
+func EscapeText(text string) string {
+	return tview.Escape(printableText([]byte(text)))
+}
+
+// printableText replaces invalid UTF-8 and control characters so that
+// binary data cannot move the cursor or switch terminal modes
+func printableText(value []byte) string {
+	text := strings.ToValidUTF8(string(value), "�")
+	if client.IsPrintable([]byte(text)) {
+		return text
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || r >= 0x20 && r != 0x7f && (r < 0x80 || r > 0x9f) {
+			return r
+		}
+		return '.'
+	}, text)
+}
+
+// wrap splits s into lines of at most width characters
+func wrap(s string, width int) string {
+	var b strings.Builder
+	for len(s) > width {
+		b.WriteString(s[:width])
+		b.WriteByte('\n')
+		s = s[width:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// DiffText returns the text used to compare values: the value itself if
+// it is printable, its hex dump otherwise
+func DiffText(value []byte) string {
+	if client.IsPrintable(value) {
+		return string(value)
+	}
+	return hex.Dump(value)
+}
+
+// RenderDiff colours a unified diff for a TextView with dynamic colors
+func RenderDiff(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		escaped := tview.Escape(printableText([]byte(line)))
+		switch {
+		case i < 2 && (strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---")):
+			lines[i] = "[::b]" + escaped + "[::-]"
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = "[aqua]" + escaped + "[-]"
+		case strings.HasPrefix(line, "+"):
+			lines[i] = "[green]" + escaped + "[-]"
+		case strings.HasPrefix(line, "-"):
+			lines[i] = "[red]" + escaped + "[-]"
+		default:
+			lines[i] = escaped
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,6 +2,7 @@ package keys
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"strings"
 	"sync"
@@ -11,22 +12,88 @@ import (
 	"github.com/rivo/tview"
 )
 
+// Node is the reference attached to every node of the keys tree
+type Node struct {
+	// Name is the path segment shown in the tree
+	Name string
+
+	// KV is the key stored at this path, nil for pure directories
+	KV *client.KeyValue
+
+	// Prefix is the key prefix of the node's children, empty for leaf keys
+	Prefix string
+
+	// Count is the number of keys under Prefix
+	Count int64
+
+	// Loaded is true once the children have been added to the tree
+	Loaded bool
+
+	// Revision is the store revision Count and the children reflect;
+	// changes at or before it are already included
+	Revision int64
+
+	// Access is what the connected user may do with the key or, for
+	// directories, the keys under Prefix
+	Access client.Access
+
+	// Protection is how the profile protects the key or directory
+	Protection client.Protection
+}
+
+// IsDir returns true if the node has (or may have) children
+func (n *Node) IsDir() bool {
+	return n.Prefix != ""
+}
+
+// Path returns the key or prefix identifying the node
+func (n *Node) Path() string {
+	if n.KV != nil {
+		return n.KV.Key
+	}
+	return n.Prefix
+}
+
 // treeNode represents a node that can be both a key and a directory
 type treeNode struct {
 	kv       *client.KeyValue // nil if this is just a directory
+	prefix   string
 	children map[string]*treeNode
 }
 
-func newTreeNode() *treeNode {
+func newTreeNode(prefix string) *treeNode {
 	return &treeNode{
+		prefix:   prefix,
 		children: make(map[string]*treeNode),
 	}
 }
 
+// count returns the number of keys in the subtree
+func (t *treeNode) count() int64 {
+	var n int64
+	for _, child := range t.children {
+		if child.kv != nil {
+			n++
+		}
+		n += child.count()
+	}
+	return n
+}
+
+// AccessFunc returns what the connected user may do with the keys from key
+// up to rangeEnd, as in etcd permissions
+type AccessFunc func(key, rangeEnd string) client.Access
+
+// ProtectionFunc returns how the profile protects a key or, for
+// directories, a prefix
+type ProtectionFunc func(path string) client.Protection
+
 // Panel represents the keys tree panel (left side)
 type Panel struct {
-	tree *tview.TreeView
-	once sync.Once
+	tree       *tview.TreeView
+	once       sync.Once
+	access     AccessFunc
+	protection ProtectionFunc
 }
 
 // New creates a new keys panel
@@ -43,6 +110,7 @@ func (p *Panel) Draw() {
 
 func (p *Panel) initialize() {
 	root := tview.NewTreeNode("etcd").
+		SetReference(&Node{Loaded: true}).
 		SetColor(tcell.ColorYellow).
 		SetExpanded(true)
 	p.tree.SetRoot(root).SetCurrentNode(root)
@@ -50,11 +118,26 @@ func (p *Panel) initialize() {
 	p.tree.SetBorder(true).SetTitle(" Keys ")
 }
 
-// LoadKeys loads all keys from etcd and builds the tree
+// SetAccessFunc sets how the access of new nodes is looked up. Nodes the
+// user cannot write are marked in the tree; without a function every node
+// is writable.
+func (p *Panel) SetAccessFunc(fn AccessFunc) {
+	p.access = fn
+}
+
+// SetProtectionFunc sets how the protection of new nodes is looked up.
+// Protected nodes are marked with a lock.
+func (p *Panel) SetProtectionFunc(fn ProtectionFunc) {
+	p.protection = fn
+}
+
+// LoadKeys builds the whole tree from a flat key list. It is used for
+// search results, where the set of keys is already known.
 func (p *Panel) LoadKeys(ctx context.Context, kvs []*client.KeyValue) error {
 	// Clear existing tree
 	root := p.tree.GetRoot()
 	root.ClearChildren()
+	root.SetReference(&Node{Loaded: true})
 
 	// Build hierarchical tree from flat keys
 	tree := buildHierarchy(kvs)
@@ -62,25 +145,170 @@ func (p *Panel) LoadKeys(ctx context.Context, kvs []*client.KeyValue) error {
 	// Add nodes to tview tree
 	p.addNodes(root, tree)
 
+	p.tree.SetCurrentNode(root)
 	return nil
 }
 
+// LoadTree replaces the tree with lazily loaded levels read at revision.
+// levels maps a prefix to its immediate children; directories whose prefix
+// is present are filled in and expanded, all others are loaded on demand.
+func (p *Panel) LoadTree(rootPrefix string, levels map[string][]*client.Child, revision int64) {
+	root := p.tree.GetRoot()
+	root.ClearChildren()
+	root.SetReference(&Node{Prefix: rootPrefix, Loaded: true, Revision: revision})
+	p.addChildren(root, rootPrefix, levels, revision)
+	p.tree.SetCurrentNode(root)
+}
+
+// SetChildren fills a directory node with its immediate children read at
+// revision and expands it.
+func (p *Panel) SetChildren(node *tview.TreeNode, children []*client.Child, revision int64) {
+	n := GetNode(node)
+	if n == nil {
+		return
+	}
+	node.ClearChildren()
+	p.addChildren(node, n.Prefix, map[string][]*client.Child{n.Prefix: children}, revision)
+	n.Loaded = true
+	n.Revision = revision
+
+	// The count may have changed since the parent was listed
+	n.Count = 0
+	for _, child := range children {
+		if child.KV != nil {
+			n.Count++
+		}
+		n.Count += child.Count
+	}
+
+	node.SetExpanded(true)
+	node.SetText(label(n, true))
+}
+
+// SetLoading marks a directory node as being loaded.
+func (p *Panel) SetLoading(node *tview.TreeNode) {
+	if n := GetNode(node); n != nil {
+		node.SetText(label(n, false) + " …")
+	}
+}
+
+// ResetLabel redraws the label of a node, e.g. after a failed load.
+func (p *Panel) ResetLabel(node *tview.TreeNode) {
+	if n := GetNode(node); n != nil {
+		node.SetText(label(n, node.IsExpanded()))
+	}
+}
+
+// Toggle expands or collapses an already loaded node.
+func (p *Panel) Toggle(node *tview.TreeNode) {
+	n := GetNode(node)
+	if n == nil || !n.IsDir() || node == p.tree.GetRoot() {
+		return
+	}
+	node.SetExpanded(!node.IsExpanded())
+	node.SetText(label(n, node.IsExpanded()))
+}
+
+// ExpandedPrefixes returns the prefixes of all loaded and expanded
+// directories, parents before children.
+func (p *Panel) ExpandedPrefixes() []string {
+	var prefixes []string
+	p.tree.GetRoot().Walk(func(node, parent *tview.TreeNode) bool {
+		n := GetNode(node)
+		if n == nil || !node.IsExpanded() || !n.Loaded {
+			return false
+		}
+		prefixes = append(prefixes, n.Prefix)
+		return true
+	})
+	return prefixes
+}
+
+// SelectedPath returns the key or prefix of the current node.
+func (p *Panel) SelectedPath() string {
+	if n := GetNode(p.tree.GetCurrentNode()); n != nil {
+		return n.Path()
+	}
+	return ""
+}
+
+// Select makes the node with the given key or prefix current if it is
+// visible in the tree. It returns false if no such node is loaded.
+func (p *Panel) Select(path string) bool {
+	var found *tview.TreeNode
+	p.tree.GetRoot().Walk(func(node, parent *tview.TreeNode) bool {
+		if found != nil {
+			return false
+		}
+		if n := GetNode(node); n != nil && parent != nil && n.Path() == path {
+			found = node
+			return false
+		}
+		return node.IsExpanded()
+	})
+	if found == nil {
+		return false
+	}
+	p.tree.SetCurrentNode(found)
+	return true
+}
+
+// GetNode returns the Node attached to a tree node.
+func GetNode(node *tview.TreeNode) *Node {
+	if node == nil {
+		return nil
+	}
+	n, _ := node.GetReference().(*Node)
+	return n
+}
+
+// addChildren adds the children of prefix below parent, descending into
+// directories present in levels.
+func (p *Panel) addChildren(parent *tview.TreeNode, prefix string, levels map[string][]*client.Child, revision int64) {
+	for _, child := range levels[prefix] {
+		n := &Node{
+			Name:     child.Name,
+			KV:       child.KV,
+			Prefix:   child.Prefix,
+			Count:    child.Count,
+			Revision: revision,
+		}
+		p.setAccess(n)
+
+		_, expand := levels[child.Prefix]
+		expand = expand && child.IsDir()
+
+		node := newNode(n, expand)
+		parent.AddChild(node)
+
+		if expand {
+			n.Loaded = true
+			p.addChildren(node, child.Prefix, levels, revision)
+		}
+	}
+}
+
 // buildHierarchy converts flat key list to hierarchical structure
 func buildHierarchy(kvs []*client.KeyValue) *treeNode {
-	root := newTreeNode()
+	root := newTreeNode("")
 
 	for _, kv := range kvs {
-		parts := strings.Split(strings.Trim(kv.Key, "/"), "/")
+		parts := strings.Split(strings.Trim(kv.Key, client.KeySeparator), client.KeySeparator)
 		current := root
+		prefix := ""
+		if strings.HasPrefix(kv.Key, client.KeySeparator) {
+			prefix = client.KeySeparator
+		}
 
 		for i, part := range parts {
 			if part == "" {
 				continue
 			}
+			prefix += part + client.KeySeparator
 
 			// Create child node if doesn't exist
 			if _, exists := current.children[part]; !exists {
-				current.children[part] = newTreeNode()
+				current.children[part] = newTreeNode(prefix)
 			}
 
 			if i == len(parts)-1 {
@@ -107,29 +335,19 @@ func (p *Panel) addNodes(parent *tview.TreeNode, node *treeNode) {
 	for _, key := range keys {
 		child := node.children[key]
 		hasChildren := len(child.children) > 0
-		isKey := child.kv != nil
 
-		// Build display text with indicator
-		displayText := key
-		if hasChildren {
-			displayText = "▶ " + key
+		n := &Node{
+			Name:   key,
+			KV:     child.kv,
+			Loaded: true,
 		}
-
-		var treeNode *tview.TreeNode
-
-		if isKey {
-			// This is an actual key (may also have children)
-			treeNode = tview.NewTreeNode(displayText).
-				SetReference(child.kv).
-				SetColor(tcell.ColorGreen).
-				SetExpanded(false)
-		} else {
-			// This is just a directory (no value)
-			treeNode = tview.NewTreeNode(displayText).
-				SetColor(tcell.ColorAqua).
-				SetExpanded(false)
+		if hasChildren {
+			n.Prefix = child.prefix
+			n.Count = child.count()
 		}
+		p.setAccess(n)
 
+		treeNode := newNode(n, false)
 		parent.AddChild(treeNode)
 
 		// Recursively add children
@@ -139,6 +357,262 @@ func (p *Panel) addNodes(parent *tview.TreeNode, node *treeNode) {
 	}
 }
 
+// setAccess looks up the access and the protection of a node
+func (p *Panel) setAccess(n *Node) {
+	if p.protection != nil {
+		n.Protection = p.protection(n.Path())
+	}
+	if p.access == nil {
+		return
+	}
+	switch {
+	case n.IsDir():
+		n.Access = p.access(n.Prefix, client.PrefixRangeEnd(n.Prefix))
+	case n.KV != nil:
+		n.Access = p.access(n.KV.Key, "")
+	}
+}
+
+// newNode creates a tree node for n
+func newNode(n *Node, expanded bool) *tview.TreeNode {
+	return tview.NewTreeNode(label(n, expanded)).
+		SetReference(n).
+		SetColor(nodeColor(n)).
+		SetExpanded(expanded)
+}
+
+// nodeColor returns the color of a node in the tree
+func nodeColor(n *Node) tcell.Color {
+	if n.Access.Read == client.CoverageNone {
+		return tcell.ColorGray
+	}
+	if n.KV != nil {
+		// This is an actual key (may also have children)
+		return tcell.ColorGreen
+	}
+	return tcell.ColorAqua
+}
+
+// label builds the display text of a node with its expansion indicator
+// and, for directories, the number of keys below it
+func label(n *Node, expanded bool) string {
+	name := n.Name
+	if name == "" {
+		// Keys starting with the separator have an empty first segment
+		name = client.KeySeparator
+	}
+
+	name = tview.Escape(name) + accessMark(n.Access) + protectionMark(n.Protection)
+
+	if !n.IsDir() {
+		return name
+	}
+
+	indicator := "▶ "
+	if expanded {
+		indicator = "▼ "
+	}
+	return fmt.Sprintf("%s%s (%d)", indicator, name, n.Count)
+}
+
+// accessMark returns the marker shown after names the user cannot fully
+// write
+func accessMark(a client.Access) string {
+	switch {
+	case a.CanWrite():
+		return ""
+	case a.Read == client.CoverageNone && a.Write == client.CoverageNone:
+		return " [gray](no access)[-]"
+	default:
+		return " [gray](" + a.String() + ")[-]"
+	}
+}
+
+// protectionMark returns the lock shown after protected names
+func protectionMark(protection client.Protection) string {
+	if protection == client.ProtectNone {
+		return ""
+	}
+	return " [red]🔒[-]"
+}
+
+// ApplyPut adds or updates a key reported by a watch. Directory counts on
+// the way are updated for new keys; unloaded directories only change their
+// count. It returns the node that changed, or nil if nothing visible did.
+func (p *Panel) ApplyPut(kv *client.KeyValue) *tview.TreeNode {
+	node, n := p.tree.GetRoot(), GetNode(p.tree.GetRoot())
+	if n == nil || !n.Loaded || kv.ModRevision <= n.Revision || !strings.HasPrefix(kv.Key, n.Prefix) {
+		return nil
+	}
+	created := kv.Version == 1
+
+	for {
+		rest := kv.Key[len(n.Prefix):]
+		i := strings.Index(rest, client.KeySeparator)
+		if i < 0 {
+			child := findChild(node, rest)
+			if child == nil {
+				cn := &Node{Name: rest, KV: kv, Revision: n.Revision}
+				p.setAccess(cn)
+				child = newNode(cn, false)
+				insertChild(node, child)
+				return child
+			}
+			cn := GetNode(child)
+			cn.KV = kv
+			child.SetColor(nodeColor(cn))
+			return child
+		}
+
+		name := rest[:i]
+		child := findChild(node, name)
+		if child == nil {
+			// A directory that had no keys when the parent was listed
+			child = newNode(&Node{Name: name, Loaded: true, Revision: n.Revision}, false)
+			insertChild(node, child)
+		}
+		cn := GetNode(child)
+		if cn.Prefix == "" {
+			// A key gains its first child
+			cn.Prefix = n.Prefix + rest[:i+len(client.KeySeparator)]
+			cn.Loaded = true
+			p.setAccess(cn)
+			child.SetColor(nodeColor(cn))
+		}
+		if kv.ModRevision <= cn.Revision {
+			return nil
+		}
+		if created {
+			cn.Count++
+		}
+		child.SetText(label(cn, child.IsExpanded()))
+
+		if !cn.Loaded {
+			return child
+		}
+		node, n = child, cn
+	}
+}
+
+// ApplyDelete removes a key reported by a watch at revision. Directories
+// left without keys are removed as well. It returns the node that changed,
+// or nil if nothing visible did.
+func (p *Panel) ApplyDelete(key string, revision int64) *tview.TreeNode {
+	root := p.tree.GetRoot()
+	node, n := root, GetNode(root)
+	if n == nil || !n.Loaded || revision <= n.Revision || !strings.HasPrefix(key, n.Prefix) {
+		return nil
+	}
+
+	// changed returns the parent of a removed node unless it is the root
+	changed := func(parent *tview.TreeNode) *tview.TreeNode {
+		if parent == root {
+			return nil
+		}
+		return parent
+	}
+
+	for {
+		rest := key[len(n.Prefix):]
+		i := strings.Index(rest, client.KeySeparator)
+		if i < 0 {
+			child := findChild(node, rest)
+			if child == nil {
+				return nil
+			}
+			cn := GetNode(child)
+			if cn.IsDir() {
+				cn.KV = nil
+				child.SetColor(nodeColor(cn))
+				return child
+			}
+			p.removeChild(node, child)
+			return changed(node)
+		}
+
+		child := findChild(node, rest[:i])
+		if child == nil {
+			return nil
+		}
+		cn := GetNode(child)
+		if revision <= cn.Revision {
+			return nil
+		}
+
+		cn.Count--
+		if cn.Count <= 0 {
+			if cn.KV == nil {
+				p.removeChild(node, child)
+				return changed(node)
+			}
+			// Only the key itself is left
+			cn.Prefix = ""
+			cn.Count = 0
+			cn.Loaded = false
+			child.ClearChildren()
+			child.SetExpanded(false)
+			child.SetText(label(cn, false))
+			return child
+		}
+		child.SetText(label(cn, child.IsExpanded()))
+
+		if !cn.Loaded {
+			return child
+		}
+		node, n = child, cn
+	}
+}
+
+// Highlight marks a node as recently changed.
+func (p *Panel) Highlight(node *tview.TreeNode) {
+	node.SetColor(tcell.ColorYellow)
+}
+
+// ResetColor restores the normal color of a node.
+func (p *Panel) ResetColor(node *tview.TreeNode) {
+	if n := GetNode(node); n != nil && node != p.tree.GetRoot() {
+		node.SetColor(nodeColor(n))
+	}
+}
+
+// removeChild removes child from parent, moving the selection to parent
+// if it was inside the removed subtree
+func (p *Panel) removeChild(parent, child *tview.TreeNode) {
+	current := p.tree.GetCurrentNode()
+	child.Walk(func(node, _ *tview.TreeNode) bool {
+		if node == current {
+			p.tree.SetCurrentNode(parent)
+			return false
+		}
+		return true
+	})
+	parent.RemoveChild(child)
+}
+
+// findChild returns the child of node with the given name
+func findChild(node *tview.TreeNode, name string) *tview.TreeNode {
+	for _, child := range node.GetChildren() {
+		if n := GetNode(child); n != nil && n.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// insertChild adds child to node keeping the children sorted by name
+func insertChild(node, child *tview.TreeNode) {
+	name := GetNode(child).Name
+	children := node.GetChildren()
+	i := sort.Search(len(children), func(i int) bool {
+		n := GetNode(children[i])
+		return n != nil && n.Name > name
+	})
+	children = append(children, nil)
+	copy(children[i+1:], children[i:])
+	children[i] = child
+	node.SetChildren(children)
+}
+
 // GetTree returns the underlying TreeView
 func (p *Panel) GetTree() *tview.TreeView {
 	return p.tree
@@ -0,0 +1,242 @@
+package keys
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	client "github.com/alex-dev-master/etcdtui/pkg/etcd"
+	"github.com/rivo/tview"
+)
+
+// newTestPanel returns a panel showing "/" at revision 10 with:
+//
+//	/a/x, /a/y     a loaded directory
+//	/d, /d/1       a key with one key below it, loaded
+//	/k             a key
+//	/u/...         an unloaded directory of two keys
+func newTestPanel() *Panel {
+	p := New()
+	p.Draw()
+	p.LoadTree("/", map[string][]*client.Child{
+		"/": {
+			{Name: "a", Prefix: "/a/", Count: 2},
+			{Name: "d", KV: &client.KeyValue{Key: "/d"}, Prefix: "/d/", Count: 1},
+			{Name: "k", KV: &client.KeyValue{Key: "/k"}},
+			{Name: "u", Prefix: "/u/", Count: 2},
+		},
+		"/a/": {
+			{Name: "x", KV: &client.KeyValue{Key: "/a/x"}},
+			{Name: "y", KV: &client.KeyValue{Key: "/a/y"}},
+		},
+		"/d/": {
+			{Name: "1", KV: &client.KeyValue{Key: "/d/1"}},
+		},
+	}, 10)
+	return p
+}
+
+// describeTree renders the tree below the root in display order: keys by
+// name, directories by prefix and count
+func describeTree(p *Panel) string {
+	var parts []string
+	root := p.GetTree().GetRoot()
+	root.Walk(func(node, parent *tview.TreeNode) bool {
+		if node == root {
+			return true
+		}
+		n := GetNode(node)
+		var part []string
+		if n.KV != nil {
+			part = append(part, n.KV.Key)
+		}
+		if n.IsDir() {
+			part = append(part, fmt.Sprintf("%s(%d)", n.Prefix, n.Count))
+		}
+		parts = append(parts, strings.Join(part, "+"))
+		return true
+	})
+	return strings.Join(parts, " ")
+}
+
+// nodePath returns the path of a changed node, "" for nil
+func nodePath(node *tview.TreeNode) string {
+	if n := GetNode(node); n != nil {
+		return n.Path()
+	}
+	return ""
+}
+
+// TestApplyPut applies watched puts to a loaded tree
+func TestApplyPut(t *testing.T) {
+	tests := []struct {
+		name    string
+		kv      *client.KeyValue
+		changed string
+		tree    string
+	}{
+		{
+			name:    "new key in a loaded directory",
+			kv:      &client.KeyValue{Key: "/a/b", Version: 1, ModRevision: 11},
+			changed: "/a/b",
+			tree:    "/a/(3) /a/b /a/x /a/y /d+/d/(1) /d/1 /k /u/(2)",
+		},
+		{
+			name:    "intermediate directories are created",
+			kv:      &client.KeyValue{Key: "/a/n/m/z", Version: 1, ModRevision: 11},
+			changed: "/a/n/m/z",
+			tree:    "/a/(3) /a/n/(1) /a/n/m/(1) /a/n/m/z /a/x /a/y /d+/d/(1) /d/1 /k /u/(2)",
+		},
+		{
+			name:    "key gains its first child",
+			kv:      &client.KeyValue{Key: "/k/c", Version: 1, ModRevision: 11},
+			changed: "/k/c",
+			tree:    "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k+/k/(1) /k/c /u/(2)",
+		},
+		{
+			name:    "unloaded directory only counts",
+			kv:      &client.KeyValue{Key: "/u/new", Version: 1, ModRevision: 11},
+			changed: "/u/",
+			tree:    "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(3)",
+		},
+		{
+			name:    "update keeps the counts",
+			kv:      &client.KeyValue{Key: "/a/x", Version: 2, ModRevision: 11},
+			changed: "/a/x",
+			tree:    "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(2)",
+		},
+		{
+			name: "stale revision is ignored",
+			kv:   &client.KeyValue{Key: "/a/b", Version: 1, ModRevision: 10},
+			tree: "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(2)",
+		},
+		{
+			name: "key outside the root is ignored",
+			kv:   &client.KeyValue{Key: "other", Version: 1, ModRevision: 11},
+			tree: "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPanel()
+			if got := nodePath(p.ApplyPut(tt.kv)); got != tt.changed {
+				t.Errorf("ApplyPut() = %q, want %q", got, tt.changed)
+			}
+			if got := describeTree(p); got != tt.tree {
+				t.Errorf("tree = %q, want %q", got, tt.tree)
+			}
+		})
+	}
+}
+
+// TestApplyPutStaleDirectory ignores puts older than the listing of the
+// directory they fall into
+func TestApplyPutStaleDirectory(t *testing.T) {
+	p := newTestPanel()
+	p.SetChildren(findChild(p.GetTree().GetRoot(), "u"), []*client.Child{
+		{Name: "1", KV: &client.KeyValue{Key: "/u/1"}},
+		{Name: "2", KV: &client.KeyValue{Key: "/u/2"}},
+	}, 20)
+
+	if got := p.ApplyPut(&client.KeyValue{Key: "/u/3", Version: 1, ModRevision: 15}); got != nil {
+		t.Errorf("ApplyPut() = %q, want nil", nodePath(got))
+	}
+	if got, want := describeTree(p), "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(2) /u/1 /u/2"; got != want {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+}
+
+// TestApplyDelete applies watched deletes to a loaded tree
+func TestApplyDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		revision int64
+		changed  string
+		tree     string
+	}{
+		{
+			name:     "key in a directory",
+			key:      "/a/x",
+			revision: 11,
+			changed:  "/a/",
+			tree:     "/a/(1) /a/y /d+/d/(1) /d/1 /k /u/(2)",
+		},
+		{
+			name:     "key at the top",
+			key:      "/k",
+			revision: 11,
+			tree:     "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /u/(2)",
+		},
+		{
+			name:     "key with children stays a directory",
+			key:      "/d",
+			revision: 11,
+			changed:  "/d/",
+			tree:     "/a/(2) /a/x /a/y /d/(1) /d/1 /k /u/(2)",
+		},
+		{
+			name:     "key loses its last child",
+			key:      "/d/1",
+			revision: 11,
+			changed:  "/d",
+			tree:     "/a/(2) /a/x /a/y /d /k /u/(2)",
+		},
+		{
+			name:     "unloaded directory only counts",
+			key:      "/u/1",
+			revision: 11,
+			changed:  "/u/",
+			tree:     "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(1)",
+		},
+		{
+			name:     "stale revision is ignored",
+			key:      "/a/x",
+			revision: 10,
+			tree:     "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPanel()
+			if got := nodePath(p.ApplyDelete(tt.key, tt.revision)); got != tt.changed {
+				t.Errorf("ApplyDelete() = %q, want %q", got, tt.changed)
+			}
+			if got := describeTree(p); got != tt.tree {
+				t.Errorf("tree = %q, want %q", got, tt.tree)
+			}
+		})
+	}
+}
+
+// TestApplyDeletePrunes removes directories left without keys and moves
+// the selection out of them
+func TestApplyDeletePrunes(t *testing.T) {
+	p := newTestPanel()
+	p.GetTree().SetCurrentNode(p.ApplyPut(&client.KeyValue{Key: "/a/n/m/z", Version: 1, ModRevision: 11}))
+
+	// The whole new branch goes with its only key
+	if got := nodePath(p.ApplyDelete("/a/n/m/z", 12)); got != "/a/" {
+		t.Errorf("ApplyDelete() = %q, want %q", got, "/a/")
+	}
+	if got, want := describeTree(p), "/a/(2) /a/x /a/y /d+/d/(1) /d/1 /k /u/(2)"; got != want {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+	if got := p.SelectedPath(); got != "/a/" {
+		t.Errorf("SelectedPath() = %q, want %q", got, "/a/")
+	}
+
+	// Emptying a top level directory removes it below the root
+	p.ApplyDelete("/a/x", 13)
+	if got := p.ApplyDelete("/a/y", 13); got != nil {
+		t.Errorf("ApplyDelete() = %q, want nil", nodePath(got))
+	}
+	if got, want := describeTree(p), "/d+/d/(1) /d/1 /k /u/(2)"; got != want {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+	if got := p.SelectedPath(); got != "/" {
+		t.Errorf("SelectedPath() = %q, want %q", got, "/")
+	}
+}
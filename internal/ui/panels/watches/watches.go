@@ -0,0 +1,322 @@
+package watches
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxLines is the number of event lines kept per watch
+const maxLines = 500
+
+// Watch is a single watch shown in the panel
+type Watch struct {
+	// ID identifies the watch for the lifetime of the panel
+	ID int
+
+	// Target is the watched key or prefix
+	Target string
+
+	// Prefix is true for watches on all keys below Target
+	Prefix bool
+
+	// Paused watches keep counting events but hold them back until resumed
+	Paused bool
+
+	// Events is the number of events received since the watch started
+	Events int
+
+	status  string
+	lines   []string
+	pending []string
+}
+
+// Label returns the watched key or prefix as shown in the list
+func (w *Watch) Label() string {
+	if w.Prefix {
+		return w.Target + "*"
+	}
+	return w.Target
+}
+
+// Callback is called with the watch an action applies to
+type Callback func(w *Watch)
+
+// Panel shows running watches with the events of the selected one
+type Panel struct {
+	flex    *tview.Flex
+	list    *tview.Table
+	log     *tview.TextView
+	watches []*Watch
+	nextID  int
+	visible bool
+	onStop  Callback
+	onDone  func()
+	mu      sync.Mutex
+}
+
+// New creates a new watches panel
+func New() *Panel {
+	p := &Panel{
+		flex: tview.NewFlex(),
+		list: tview.NewTable(),
+		log:  tview.NewTextView(),
+	}
+
+	p.list.SetSelectable(true, false)
+	p.list.SetSelectionChangedFunc(func(row, column int) {
+		p.renderLog()
+	})
+	p.list.SetInputCapture(p.handleInput)
+
+	p.log.
+		SetDynamicColors(true).
+		SetScrollable(true)
+
+	hint := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[green]p[-] pause  [green]c[-] clear  [green]x[-] stop  [green]ESC[-] back")
+
+	p.flex.
+		SetDirection(tview.FlexRow).
+		AddItem(p.list, 5, 0, true).
+		AddItem(p.log, 0, 1, false).
+		AddItem(hint, 1, 0, false)
+
+	p.flex.SetBorder(true).
+		SetTitle(" Watches (W to toggle) ").
+		SetBorderColor(tcell.ColorYellow)
+
+	p.render()
+	return p
+}
+
+// GetView returns the panel's root primitive
+func (p *Panel) GetView() tview.Primitive {
+	return p.flex
+}
+
+// GetList returns the list of watches (for focus management)
+func (p *Panel) GetList() *tview.Table {
+	return p.list
+}
+
+// IsVisible returns whether the panel is visible
+func (p *Panel) IsVisible() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.visible
+}
+
+// SetVisible sets the visibility state
+func (p *Panel) SetVisible(visible bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.visible = visible
+}
+
+// SetStopFunc sets the callback for stopping a watch
+func (p *Panel) SetStopFunc(callback Callback) {
+	p.onStop = callback
+}
+
+// SetDoneFunc sets the callback for leaving the panel
+func (p *Panel) SetDoneFunc(callback func()) {
+	p.onDone = callback
+}
+
+// Add adds a running watch and selects it
+func (p *Panel) Add(target string, prefix bool) *Watch {
+	p.nextID++
+	w := &Watch{
+		ID:     p.nextID,
+		Target: target,
+		Prefix: prefix,
+		status: "starting",
+	}
+	p.watches = append(p.watches, w)
+	p.render()
+	p.list.Select(len(p.watches)-1, 0)
+	p.renderLog()
+	return w
+}
+
+// Remove removes a stopped watch
+func (p *Panel) Remove(w *Watch) {
+	for i, other := range p.watches {
+		if other == w {
+			p.watches = append(p.watches[:i], p.watches[i+1:]...)
+			break
+		}
+	}
+	p.render()
+	if row, _ := p.list.GetSelection(); row >= len(p.watches) && len(p.watches) > 0 {
+		p.list.Select(len(p.watches)-1, 0)
+	}
+	p.renderLog()
+}
+
+// Watches returns the running watches
+func (p *Panel) Watches() []*Watch {
+	return append([]*Watch(nil), p.watches...)
+}
+
+// Find returns the watch on target, if any
+func (p *Panel) Find(target string, prefix bool) *Watch {
+	for _, w := range p.watches {
+		if w.Target == target && w.Prefix == prefix {
+			return w
+		}
+	}
+	return nil
+}
+
+// Select makes w the watch whose events are shown
+func (p *Panel) Select(w *Watch) {
+	for i, other := range p.watches {
+		if other == w {
+			p.list.Select(i, 0)
+			p.renderLog()
+			return
+		}
+	}
+}
+
+// Selected returns the watch whose events are shown
+func (p *Panel) Selected() *Watch {
+	row, _ := p.list.GetSelection()
+	if row < 0 || row >= len(p.watches) {
+		return nil
+	}
+	return p.watches[row]
+}
+
+// AddEvent counts an event of w and appends its formatted lines. Events of
+// paused watches are held back until the watch is resumed.
+func (p *Panel) AddEvent(w *Watch, text string) {
+	w.Events++
+	if w.Paused {
+		w.pending = appendLines(w.pending, text)
+	} else {
+		w.lines = appendLines(w.lines, text)
+		if w == p.Selected() {
+			_, _ = fmt.Fprintln(p.log, text)
+			p.log.ScrollToEnd()
+		}
+	}
+	p.render()
+}
+
+// SetStatus sets the status shown next to w, e.g. the last progress
+func (p *Panel) SetStatus(w *Watch, status string) {
+	w.status = status
+	p.render()
+}
+
+// TogglePause pauses or resumes w. Held back events are shown on resume.
+func (p *Panel) TogglePause(w *Watch) {
+	w.Paused = !w.Paused
+	if !w.Paused {
+		for _, line := range w.pending {
+			w.lines = appendLines(w.lines, line)
+		}
+		w.pending = nil
+	}
+	p.render()
+	p.renderLog()
+}
+
+// Clear removes the events shown for w and resets its counter
+func (p *Panel) Clear(w *Watch) {
+	w.lines = nil
+	w.pending = nil
+	w.Events = 0
+	p.render()
+	p.renderLog()
+}
+
+// handleInput handles the panel's own keys
+func (p *Panel) handleInput(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyTab, tcell.KeyBacktab:
+		if p.onDone != nil {
+			p.onDone()
+		}
+		return nil
+	}
+
+	w := p.Selected()
+	if w == nil {
+		return event
+	}
+
+	switch event.Rune() {
+	case 'p':
+		p.TogglePause(w)
+		return nil
+	case 'c':
+		p.Clear(w)
+		return nil
+	case 'x':
+		if p.onStop != nil {
+			p.onStop(w)
+		}
+		return nil
+	}
+	return event
+}
+
+// render redraws the list of watches
+func (p *Panel) render() {
+	p.list.Clear()
+	if len(p.watches) == 0 {
+		p.list.SetCell(0, 0, tview.NewTableCell("[gray]No watches. Press w on a key or directory.[-]").
+			SetSelectable(false))
+		return
+	}
+
+	for i, w := range p.watches {
+		state := "[green]●[-]"
+		if w.Paused {
+			state = "[yellow]‖[-]"
+		}
+
+		status := tview.Escape(w.status)
+		if n := len(w.pending); n > 0 {
+			status = fmt.Sprintf("paused, %d held", n)
+		}
+
+		p.list.SetCell(i, 0, tview.NewTableCell(state))
+		p.list.SetCell(i, 1, tview.NewTableCell(tview.Escape(w.Label())).SetExpansion(1).SetMaxWidth(40))
+		p.list.SetCell(i, 2, tview.NewTableCell(fmt.Sprintf("%d", w.Events)).SetAlign(tview.AlignRight))
+		p.list.SetCell(i, 3, tview.NewTableCell("[gray]"+status+"[-]"))
+	}
+}
+
+// renderLog shows the events of the selected watch
+func (p *Panel) renderLog() {
+	w := p.Selected()
+	if w == nil {
+		p.log.SetText("")
+		return
+	}
+
+	text := strings.Join(w.lines, "\n")
+	if text != "" {
+		text += "\n"
+	}
+	p.log.SetText(text)
+	p.log.ScrollToEnd()
+}
+
+// appendLines appends text and drops the oldest lines beyond maxLines
+func appendLines(lines []string, text string) []string {
+	lines = append(lines, text)
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return lines
+}
@@ -0,0 +1,378 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Coverage tells how much of a key range a user's permissions cover
+type Coverage int
+
+const (
+	// CoverageFull means every key in the range is covered
+	CoverageFull Coverage = iota
+	// CoveragePartial means some keys in the range are covered
+	CoveragePartial
+	// CoverageNone means no key in the range is covered
+	CoverageNone
+)
+
+// Access is what a user may do with a key or range of keys. The zero value
+// allows everything, as a cluster without authentication does.
+type Access struct {
+	Read  Coverage
+	Write Coverage
+}
+
+// CanRead returns true if every key in the range can be read
+func (a Access) CanRead() bool {
+	return a.Read == CoverageFull
+}
+
+// CanWrite returns true if every key in the range can be written
+func (a Access) CanWrite() bool {
+	return a.Write == CoverageFull
+}
+
+// String returns read-write, read-only, write-only, partial or none
+func (a Access) String() string {
+	switch {
+	case a.CanRead() && a.CanWrite():
+		return "read-write"
+	case a.Read == CoverageNone && a.Write == CoverageNone:
+		return "none"
+	case a.CanRead() && a.Write == CoverageNone:
+		return "read-only"
+	case a.CanWrite() && a.Read == CoverageNone:
+		return "write-only"
+	default:
+		return "partial"
+	}
+}
+
+// Identity is the user a client is connected as, with its effective
+// permissions
+type Identity struct {
+	// User is empty when connecting without credentials
+	User string
+
+	// AuthEnabled is false if the cluster does not check permissions
+	AuthEnabled bool
+
+	// Roles are the roles granted to the user
+	Roles []string
+
+	// Root is true for users with the root role, which may do everything
+	Root bool
+
+	// Permissions is the union of the permissions of the user's roles
+	Permissions []*Permission
+
+	// Probed is true if the user's roles could not be read. Access then
+	// reports ranges checked with ProbeAccess and allows everything else.
+	Probed bool
+
+	mu     sync.Mutex
+	probes map[keyRange]Access
+}
+
+// Access returns what the user may do with the keys from key up to rangeEnd
+// (exclusive). An empty rangeEnd means the single key, "\x00" all keys from
+// key on, as in etcd permissions.
+func (id *Identity) Access(key, rangeEnd string) Access {
+	if !id.AuthEnabled || id.Root {
+		return Access{}
+	}
+
+	r := newKeyRange(key, rangeEnd)
+	if id.Probed {
+		id.mu.Lock()
+		defer id.mu.Unlock()
+		return id.probes[r]
+	}
+
+	read, write := id.intervals()
+	return Access{Read: read.coverage(r), Write: write.coverage(r)}
+}
+
+// NeedsProbe returns true if the user's roles could not be read and the
+// range from key up to rangeEnd was not checked with ProbeAccess yet
+func (id *Identity) NeedsProbe(key, rangeEnd string) bool {
+	if !id.Probed || !id.AuthEnabled || id.Root {
+		return false
+	}
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	_, ok := id.probes[newKeyRange(key, rangeEnd)]
+	return !ok
+}
+
+// ReadRanges returns the parts of the range from key up to rangeEnd the user
+// can read, as key and range end pairs. The whole range is returned if the
+// permissions are unknown.
+func (id *Identity) ReadRanges(key, rangeEnd string) [][2]string {
+	r := newKeyRange(key, rangeEnd)
+	if !id.AuthEnabled || id.Root || id.Probed {
+		return [][2]string{{r.start, r.rangeEnd()}}
+	}
+
+	read, _ := id.intervals()
+	var ranges [][2]string
+	for _, part := range read.clip(r) {
+		ranges = append(ranges, [2]string{part.start, part.rangeEnd()})
+	}
+	return ranges
+}
+
+// HasWriteAccess returns false if the user may not write any key. It is
+// true while the permissions are unknown.
+func (id *Identity) HasWriteAccess() bool {
+	if !id.AuthEnabled || id.Root || id.Probed {
+		return true
+	}
+	_, write := id.intervals()
+	return len(write) > 0
+}
+
+// Describe returns the user and roles in one line
+func (id *Identity) Describe() string {
+	switch {
+	case !id.AuthEnabled:
+		return "auth disabled"
+	case id.User == "":
+		return "anonymous"
+	case id.Root:
+		return id.User + " (root)"
+	case id.Probed:
+		return id.User + " (probed)"
+	case len(id.Roles) == 0:
+		return id.User + " (no roles)"
+	default:
+		return fmt.Sprintf("%s (%d roles)", id.User, len(id.Roles))
+	}
+}
+
+// intervals returns the merged key ranges the user can read and write
+func (id *Identity) intervals() (read, write keyRanges) {
+	for _, p := range id.Permissions {
+		r := newKeyRange(p.Key, p.RangeEnd)
+		if p.PermType == PermissionRead || p.PermType == PermissionReadWrite {
+			read = append(read, r)
+		}
+		if p.PermType == PermissionWrite || p.PermType == PermissionReadWrite {
+			write = append(write, r)
+		}
+	}
+	return read.merge(), write.merge()
+}
+
+// GetIdentity returns the user the client is connected as and its
+// effective permissions. Users other than root may not read the auth status
+// or other users' roles; a denied status means authentication is enabled, and
+// denied role reads make the identity rely on ProbeAccess.
+func (c *Client) GetIdentity(ctx context.Context) (*Identity, error) {
+	id := &Identity{User: c.config.Username}
+
+	enabled, err := c.AuthEnabled(ctx)
+	switch {
+	case errors.Is(err, rpctypes.ErrPermissionDenied), errors.Is(err, rpctypes.ErrUserEmpty):
+		id.AuthEnabled = true
+	case err != nil:
+		return nil, err
+	default:
+		id.AuthEnabled = enabled
+	}
+
+	if !id.AuthEnabled || id.User == "" {
+		return id, nil
+	}
+
+	user, err := c.GetUser(ctx, id.User)
+	if errors.Is(err, rpctypes.ErrPermissionDenied) {
+		id.Probed = true
+		return id, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	id.Roles = user.Roles
+
+	for _, name := range user.Roles {
+		if name == "root" {
+			id.Root = true
+			return id, nil
+		}
+
+		role, err := c.GetRole(ctx, name)
+		if errors.Is(err, rpctypes.ErrPermissionDenied) {
+			id.Probed = true
+			id.Permissions = nil
+			return id, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		id.Permissions = append(id.Permissions, role.Permissions...)
+	}
+
+	return id, nil
+}
+
+// ProbeAccess checks what the user may do with the keys from key up to
+// rangeEnd by trying it: a count-only read, and a transaction that would
+// delete the range but compares against an impossible version, so it never
+// does. The transaction needs read access to key for its compare, so write
+// access is reported as none where key cannot be read. The result is kept in
+// id and returned by id.Access from then on.
+func (c *Client) ProbeAccess(ctx context.Context, id *Identity, key, rangeEnd string) Access {
+	r := newKeyRange(key, rangeEnd)
+	start := key
+	if start == "" {
+		start = "\x00"
+	}
+
+	var opts []clientv3.OpOption
+	if rangeEnd != "" {
+		opts = append(opts, clientv3.WithRange(rangeEnd))
+	}
+
+	denied := func(err error) Coverage {
+		if errors.Is(err, rpctypes.ErrPermissionDenied) {
+			return CoverageNone
+		}
+		return CoverageFull
+	}
+
+	var access Access
+
+	readCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	_, err := c.client.Get(readCtx, start, append(opts, clientv3.WithCountOnly())...)
+	cancel()
+	access.Read = denied(err)
+
+	writeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	_, err = c.client.Txn(writeCtx).
+		If(clientv3.Compare(clientv3.Version(start), "<", 0)).
+		Then(clientv3.OpDelete(start, opts...)).
+		Commit()
+	cancel()
+	access.Write = denied(err)
+
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	if id.probes == nil {
+		id.probes = make(map[keyRange]Access)
+	}
+	id.probes[r] = access
+	return access
+}
+
+// keyRange is the key interval [start, end). An empty end means the range
+// is unbounded, unlike in etcd requests.
+type keyRange struct {
+	start, end string
+}
+
+// newKeyRange converts a key and range end as used by etcd to a keyRange
+func newKeyRange(key, rangeEnd string) keyRange {
+	switch rangeEnd {
+	case "":
+		return keyRange{key, key + "\x00"}
+	case "\x00":
+		return keyRange{key, ""}
+	default:
+		return keyRange{key, rangeEnd}
+	}
+}
+
+// rangeEnd returns the end as etcd expects it
+func (r keyRange) rangeEnd() string {
+	if r.end == "" {
+		return "\x00"
+	}
+	return r.end
+}
+
+// endsBefore returns true if the range ends before key
+func (r keyRange) endsBefore(key string) bool {
+	return r.end != "" && r.end <= key
+}
+
+// endsAfter returns true if r reaches at least as far as other
+func (r keyRange) endsAfter(other keyRange) bool {
+	return r.end == "" || (other.end != "" && r.end >= other.end)
+}
+
+// empty returns true if the range contains no keys
+func (r keyRange) empty() bool {
+	return r.end != "" && r.end <= r.start
+}
+
+// keyRanges is a list of key ranges
+type keyRanges []keyRange
+
+// merge returns the ranges sorted by start with overlapping and adjacent
+// ranges joined
+func (rs keyRanges) merge() keyRanges {
+	sorted := make(keyRanges, 0, len(rs))
+	for _, r := range rs {
+		if !r.empty() {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start < sorted[j].start
+	})
+
+	var merged keyRanges
+	for _, r := range sorted {
+		if n := len(merged); n > 0 && (merged[n-1].end == "" || merged[n-1].end >= r.start) {
+			if !merged[n-1].endsAfter(r) {
+				merged[n-1].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// coverage returns how much of r the merged ranges cover
+func (rs keyRanges) coverage(r keyRange) Coverage {
+	parts := rs.clip(r)
+	switch {
+	case len(parts) == 0:
+		return CoverageNone
+	case len(parts) == 1 && parts[0] == r:
+		return CoverageFull
+	default:
+		return CoveragePartial
+	}
+}
+
+// clip returns the parts of the merged ranges that fall within r
+func (rs keyRanges) clip(r keyRange) keyRanges {
+	var parts keyRanges
+	for _, m := range rs {
+		if m.endsBefore(r.start) || r.endsBefore(m.start) {
+			continue
+		}
+		part := m
+		if part.start < r.start {
+			part.start = r.start
+		}
+		if part.endsAfter(r) {
+			part.end = r.end
+		}
+		if !part.empty() {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -35,8 +35,47 @@ const (
 	PermissionReadWrite
 )
 
+// String returns read, write or readwrite
+func (t PermissionType) String() string {
+	switch t {
+	case PermissionRead:
+		return "read"
+	case PermissionWrite:
+		return "write"
+	case PermissionReadWrite:
+		return "readwrite"
+	default:
+		return fmt.Sprintf("PermissionType(%d)", int(t))
+	}
+}
+
+// IsPrefix returns true if the permission covers all keys with Key as prefix
+func (p *Permission) IsPrefix() bool {
+	return p.RangeEnd != "" && p.RangeEnd == PrefixRangeEnd(p.Key)
+}
+
+// Range describes the keys the permission covers
+func (p *Permission) Range() string {
+	switch {
+	case p.RangeEnd == "":
+		return fmt.Sprintf("%q", p.Key)
+	case p.IsPrefix() && p.Key == "":
+		return "all keys"
+	case p.IsPrefix():
+		return fmt.Sprintf("prefix %q", p.Key)
+	case p.RangeEnd == "\x00":
+		return fmt.Sprintf("from %q", p.Key)
+	default:
+		return fmt.Sprintf("[%q, %q)", p.Key, p.RangeEnd)
+	}
+}
+
 // CreateUser creates a new etcd user
 func (c *Client) CreateUser(ctx context.Context, username, password string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("create user %s", username)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -49,6 +88,10 @@ func (c *Client) CreateUser(ctx context.Context, username, password string) erro
 
 // DeleteUser deletes an etcd user
 func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("delete user %s", username)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -61,6 +104,10 @@ func (c *Client) DeleteUser(ctx context.Context, username string) error {
 
 // ChangePassword changes user password
 func (c *Client) ChangePassword(ctx context.Context, username, newPassword string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("change the password of %s", username)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -73,6 +120,10 @@ func (c *Client) ChangePassword(ctx context.Context, username, newPassword strin
 
 // GrantRole grants a role to a user
 func (c *Client) GrantRole(ctx context.Context, username, role string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("grant role %s to %s", role, username)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -85,6 +136,10 @@ func (c *Client) GrantRole(ctx context.Context, username, role string) error {
 
 // RevokeRole revokes a role from a user
 func (c *Client) RevokeRole(ctx context.Context, username, role string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("revoke role %s from %s", role, username)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -110,7 +165,7 @@ func (c *Client) ListUsers(ctx context.Context) ([]*User, error) {
 		// Get user details
 		userResp, err := c.client.UserGet(ctx, username)
 		if err != nil {
-			continue
+			return users, fmt.Errorf("failed to get user %s: %w", username, err)
 		}
 
 		users = append(users, &User{
@@ -122,8 +177,74 @@ func (c *Client) ListUsers(ctx context.Context) ([]*User, error) {
 	return users, nil
 }
 
+// GetUser returns a user with its roles
+func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.UserGet(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+
+	return &User{
+		Name:  username,
+		Roles: resp.Roles,
+	}, nil
+}
+
+// GetRole returns a role with its permissions
+func (c *Client) GetRole(ctx context.Context, roleName string) (*Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.RoleGet(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role %s: %w", roleName, err)
+	}
+
+	role := &Role{
+		Name:        roleName,
+		Permissions: make([]*Permission, 0, len(resp.Perm)),
+	}
+	for _, perm := range resp.Perm {
+		role.Permissions = append(role.Permissions, &Permission{
+			Key:      string(perm.Key),
+			RangeEnd: string(perm.RangeEnd),
+			PermType: PermissionType(perm.PermType),
+		})
+	}
+	return role, nil
+}
+
+// ListRoles returns all roles with their permissions
+func (c *Client) ListRoles(ctx context.Context) ([]*Role, error) {
+	listCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.RoleList(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	roles := make([]*Role, 0, len(resp.Roles))
+	for _, name := range resp.Roles {
+		role, err := c.GetRole(ctx, name)
+		if err != nil {
+			return roles, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
 // CreateRole creates a new role
 func (c *Client) CreateRole(ctx context.Context, roleName string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("create role %s", roleName)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -136,6 +257,10 @@ func (c *Client) CreateRole(ctx context.Context, roleName string) error {
 
 // DeleteRole deletes a role
 func (c *Client) DeleteRole(ctx context.Context, roleName string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("delete role %s", roleName)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -148,6 +273,10 @@ func (c *Client) DeleteRole(ctx context.Context, roleName string) error {
 
 // GrantPermission grants permission to a role
 func (c *Client) GrantPermission(ctx context.Context, roleName, key, rangeEnd string, permType PermissionType) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("grant a permission to role %s", roleName)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -170,6 +299,10 @@ func (c *Client) GrantPermission(ctx context.Context, roleName, key, rangeEnd st
 
 // RevokePermission revokes permission from a role
 func (c *Client) RevokePermission(ctx context.Context, roleName, key, rangeEnd string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("revoke a permission of role %s", roleName)); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -180,8 +313,24 @@ func (c *Client) RevokePermission(ctx context.Context, roleName, key, rangeEnd s
 	return nil
 }
 
+// AuthEnabled returns whether authentication is enabled
+func (c *Client) AuthEnabled(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.AuthStatus(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get auth status: %w", err)
+	}
+	return resp.Enabled, nil
+}
+
 // EnableAuth enables authentication
 func (c *Client) EnableAuth(ctx context.Context) error {
+	if err := c.Guard().CheckChange("enable authentication"); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -194,6 +343,10 @@ func (c *Client) EnableAuth(ctx context.Context) error {
 
 // DisableAuth disables authentication
 func (c *Client) DisableAuth(ctx context.Context) error {
+	if err := c.Guard().CheckChange("disable authentication"); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -1,9 +1,17 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 // TestDefaultConfig verifies default configuration
@@ -31,7 +39,7 @@ func TestDefaultConfig(t *testing.T) {
 func TestKeyValueStruct(t *testing.T) {
 	kv := &KeyValue{
 		Key:            "/test/key",
-		Value:          "test value",
+		Value:          []byte("test value"),
 		CreateRevision: 1,
 		ModRevision:    2,
 		Version:        1,
@@ -42,11 +50,36 @@ func TestKeyValueStruct(t *testing.T) {
 		t.Errorf("Expected key '/test/key', got '%s'", kv.Key)
 	}
 
-	if kv.Value != "test value" {
+	if string(kv.Value) != "test value" {
 		t.Errorf("Expected value 'test value', got '%s'", kv.Value)
 	}
 }
 
+// TestIsPrintable verifies text detection for raw values
+func TestIsPrintable(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		want  bool
+	}{
+		{"empty", []byte{}, true},
+		{"ascii", []byte("hello world"), true},
+		{"multiline", []byte("a: 1\n\tb: 2\r\n"), true},
+		{"unicode", []byte("привет, 世界"), true},
+		{"color tags", []byte("[red]not a tag[-]"), true},
+		{"invalid utf8", []byte{0xff, 0xfe, 'a'}, false},
+		{"nul byte", []byte("a\x00b"), false},
+		{"escape sequence", []byte("\x1b[31mred"), false},
+		{"protobuf", []byte{0x0a, 0x03, 'f', 'o', 'o', 0x10, 0x01}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPrintable(tt.value); got != tt.want {
+			t.Errorf("IsPrintable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 // TestBuildTree verifies tree building from flat keys
 func TestBuildTree(t *testing.T) {
 	keys := []*KeyValue{
@@ -84,6 +117,26 @@ func TestEventType(t *testing.T) {
 	}
 }
 
+// TestEventTypeString verifies event type names
+func TestEventTypeString(t *testing.T) {
+	tests := []struct {
+		eventType EventType
+		want      string
+	}{
+		{EventTypePut, "put"},
+		{EventTypeDelete, "delete"},
+		{EventTypeCompacted, "compacted"},
+		{EventTypeProgress, "progress"},
+		{EventType(42), "EventType(42)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.eventType.String(); got != tt.want {
+			t.Errorf("EventType(%d).String() = %s, want %s", int(tt.eventType), got, tt.want)
+		}
+	}
+}
+
 // TestPermissionType verifies permission type constants
 func TestPermissionType(t *testing.T) {
 	if PermissionRead != 0 {
@@ -99,6 +152,98 @@ func TestPermissionType(t *testing.T) {
 	}
 }
 
+// TestPermissionRange verifies how permission key ranges are described
+func TestPermissionRange(t *testing.T) {
+	tests := []struct {
+		key, rangeEnd string
+		want          string
+	}{
+		{"/app/config", "", `"/app/config"`},
+		{"/app/", "/app0", `prefix "/app/"`},
+		{"", "\x00", "all keys"},
+		{"/logs", "\x00", `from "/logs"`},
+		{"a", "c", `["a", "c")`},
+	}
+
+	for _, tt := range tests {
+		p := &Permission{Key: tt.key, RangeEnd: tt.rangeEnd}
+		if got := p.Range(); got != tt.want {
+			t.Errorf("Range() of %q-%q = %s, want %s", tt.key, tt.rangeEnd, got, tt.want)
+		}
+	}
+}
+
+// TestPrefixRangeEnd verifies range ends computed for prefixes
+func TestPrefixRangeEnd(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", "\x00"},
+		{"/a", "/b"},
+		{"/config/", "/config0"},
+		{"a\xff", "b"},
+		{"\xff\xff", "\x00"},
+	}
+
+	for _, tt := range tests {
+		if got := PrefixRangeEnd(tt.prefix); got != tt.want {
+			t.Errorf("PrefixRangeEnd(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+// TestSnapshotVerifier verifies the sha256 trailer check of snapshots
+func TestSnapshotVerifier(t *testing.T) {
+	db := bytes.Repeat([]byte("etcd"), 512)
+	sum := sha256.Sum256(db)
+	snapshot := append(append([]byte{}, db...), sum[:]...)
+
+	write := func(data []byte, chunk int) error {
+		v := newSnapshotVerifier()
+		for len(data) > 0 {
+			n := min(chunk, len(data))
+			_, _ = v.Write(data[:n])
+			data = data[n:]
+		}
+		return v.Verify()
+	}
+
+	for _, chunk := range []int{1, 7, 32, 500, len(snapshot)} {
+		if err := write(snapshot, chunk); err != nil {
+			t.Errorf("chunk %d: unexpected error: %v", chunk, err)
+		}
+	}
+
+	corrupted := append([]byte{}, snapshot...)
+	corrupted[100] ^= 0xff
+	if err := write(corrupted, 64); !errors.Is(err, ErrSnapshotHashMismatch) {
+		t.Errorf("Expected ErrSnapshotHashMismatch, got %v", err)
+	}
+
+	if err := write(db, 64); !errors.Is(err, ErrSnapshotNoHash) {
+		t.Errorf("Expected ErrSnapshotNoHash, got %v", err)
+	}
+}
+
+// TestFormatBytes verifies human readable sizes
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+	}
+
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
 // Example test demonstrating client usage
 func ExampleNew() {
 	cfg := DefaultConfig()
@@ -135,3 +280,930 @@ func BenchmarkBuildTree(b *testing.B) {
 		_ = BuildTree(keys)
 	}
 }
+
+// TestCompareOperator verifies invalid operators fail at commit instead of panicking
+func TestCompareOperator(t *testing.T) {
+	txn := (&Client{}).NewTxn().
+		If(CompareValue("/k", "~", "v"), CompareVersion("/k", CompareGreater, 0)).
+		Then(OpPut("/k", "v2"), OpDelete("/old")).
+		Else(OpGet("/k"))
+
+	if txn.Ops() != 2 {
+		t.Errorf("Ops() = %d, want 2", txn.Ops())
+	}
+
+	if _, err := txn.Commit(context.Background()); err == nil {
+		t.Error("Expected error for invalid compare operator")
+	}
+
+	if OpDeletePrefix("/a/").Type.String() != "delete" {
+		t.Error("OpDeletePrefix should be a delete")
+	}
+}
+
+// TestProcessWatchEvents verifies resume revisions, replay skipping and typed events
+func TestProcessWatchEvents(t *testing.T) {
+	ch := make(chan clientv3.WatchResponse, 6)
+	header := func(rev int64) etcdserverpb.ResponseHeader {
+		return etcdserverpb.ResponseHeader{Revision: rev}
+	}
+	put := func(key string, rev int64) *clientv3.Event {
+		return &clientv3.Event{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: rev}}
+	}
+	del := func(key string, rev int64) *clientv3.Event {
+		return &clientv3.Event{Type: clientv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: rev}}
+	}
+
+	ch <- clientv3.WatchResponse{Header: header(10), Created: true}
+	ch <- clientv3.WatchResponse{Header: header(11), Events: []*clientv3.Event{put("/a", 10), put("/b", 11)}}
+	// A transaction deleting three keys, then a replay of one of them
+	ch <- clientv3.WatchResponse{Header: header(12), Events: []*clientv3.Event{del("/c", 12), del("/d", 12), del("/e", 12)}}
+	ch <- clientv3.WatchResponse{Header: header(12), Events: []*clientv3.Event{del("/d", 12)}}
+	ch <- clientv3.WatchResponse{Header: header(15)}
+	ch <- clientv3.WatchResponse{Header: header(20), CompactRevision: 18}
+	close(ch)
+
+	var events []*WatchEvent
+	next, delivered, err := processWatchEvents(ch, 0, func(e *WatchEvent) {
+		events = append(events, e)
+	})
+
+	var compacted *compactedError
+	if !errors.As(err, &compacted) || compacted.revision != 18 {
+		t.Fatalf("Expected compaction at 18, got %v", err)
+	}
+	if !delivered || next != 16 {
+		t.Errorf("next = %d, delivered = %v, want 16, true", next, delivered)
+	}
+	if len(events) != 5 {
+		t.Fatalf("Expected 5 events, got %d", len(events))
+	}
+	if events[0].Key != "/b" || events[0].Type != EventTypePut {
+		t.Errorf("Expected replayed /a to be skipped, got %s", events[0].Key)
+	}
+	for i, key := range []string{"/c", "/d", "/e"} {
+		if e := events[i+1]; e.Key != key || e.Type != EventTypeDelete || e.ModRevision != 12 {
+			t.Errorf("Event %d = %v %s at %d, want delete of %s at 12", i+1, e.Type, e.Key, e.ModRevision, key)
+		}
+	}
+	if events[4].Type != EventTypeProgress || events[4].Revision != 15 {
+		t.Errorf("Expected progress at 15, got %v at %d", events[4].Type, events[4].Revision)
+	}
+}
+
+// TestCompareHashes verifies that only hashes at the same compaction revision are compared
+func TestCompareHashes(t *testing.T) {
+	tests := []struct {
+		name                 string
+		hashes               []*EndpointHash
+		mismatch, incomplete bool
+	}{
+		{"equal", []*EndpointHash{{Hash: 1, CompactRevision: 5}, {Hash: 1, CompactRevision: 5}}, false, false},
+		{"different", []*EndpointHash{{Hash: 1, CompactRevision: 5}, {Hash: 2, CompactRevision: 5}}, true, false},
+		{"compaction differs", []*EndpointHash{{Hash: 1, CompactRevision: 5}, {Hash: 2, CompactRevision: 6}}, false, true},
+		{"unreachable", []*EndpointHash{{Hash: 1}, {Err: context.DeadlineExceeded}}, false, true},
+	}
+
+	for _, tt := range tests {
+		mismatch, incomplete := compareHashes(tt.hashes)
+		if mismatch != tt.mismatch || incomplete != tt.incomplete {
+			t.Errorf("%s: compareHashes() = %v, %v, want %v, %v", tt.name, mismatch, incomplete, tt.mismatch, tt.incomplete)
+		}
+	}
+
+	if got := compactionTarget(100, 1000); got != 0 {
+		t.Errorf("compactionTarget(100, 1000) = %d, want 0", got)
+	}
+	if got := compactionTarget(1500, 1000); got != 500 {
+		t.Errorf("compactionTarget(1500, 1000) = %d, want 500", got)
+	}
+}
+
+// TestLeaderOf picks the leader reported with the highest raft term
+func TestLeaderOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []*EndpointStatus
+		want     uint64
+	}{
+		{"empty", nil, 0},
+		{"no leader", []*EndpointStatus{{RaftTerm: 3}, {RaftTerm: 4}}, 0},
+		{"agreed", []*EndpointStatus{{LeaderID: 1, RaftTerm: 2}, {LeaderID: 1, RaftTerm: 2}}, 1},
+		{"newer term wins", []*EndpointStatus{{LeaderID: 1, RaftTerm: 2}, {LeaderID: 2, RaftTerm: 3}, {LeaderID: 1, RaftTerm: 2}}, 2},
+		{"newer term first", []*EndpointStatus{{LeaderID: 2, RaftTerm: 3}, {LeaderID: 1, RaftTerm: 2}}, 2},
+		{"member without leader", []*EndpointStatus{{RaftTerm: 5}, {LeaderID: 3, RaftTerm: 4}}, 3},
+		{"same term keeps the first", []*EndpointStatus{{LeaderID: 1, RaftTerm: 2}, {LeaderID: 2, RaftTerm: 2}}, 1},
+	}
+
+	for _, tt := range tests {
+		if got := leaderOf(tt.statuses); got != tt.want {
+			t.Errorf("%s: leaderOf() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestIdentityAccess verifies how permissions cover key ranges
+func TestIdentityAccess(t *testing.T) {
+	id := &Identity{
+		User:        "alice",
+		AuthEnabled: true,
+		Permissions: []*Permission{
+			{Key: "/app/", RangeEnd: PrefixRangeEnd("/app/"), PermType: PermissionReadWrite},
+			{Key: "/cfg/", RangeEnd: PrefixRangeEnd("/cfg/"), PermType: PermissionRead},
+			{Key: "/cfg/own", PermType: PermissionWrite},
+		},
+	}
+
+	tests := []struct {
+		key, rangeEnd string
+		want          string
+	}{
+		{"/app/a", "", "read-write"},
+		{"/app/b/", PrefixRangeEnd("/app/b/"), "read-write"},
+		{"/cfg/x", "", "read-only"},
+		{"/cfg/own", "", "read-write"},
+		{"/cfg/", PrefixRangeEnd("/cfg/"), "partial"},
+		{"/other/y", "", "none"},
+		{"", "\x00", "partial"},
+	}
+
+	for _, tt := range tests {
+		if got := id.Access(tt.key, tt.rangeEnd).String(); got != tt.want {
+			t.Errorf("Access(%q, %q) = %s, want %s", tt.key, tt.rangeEnd, got, tt.want)
+		}
+	}
+
+	ranges := id.ReadRanges("", "\x00")
+	if len(ranges) != 2 || ranges[0] != [2]string{"/app/", "/app0"} || ranges[1] != [2]string{"/cfg/", "/cfg0"} {
+		t.Errorf("ReadRanges() = %q, want /app/ and /cfg/", ranges)
+	}
+
+	if !id.HasWriteAccess() {
+		t.Error("HasWriteAccess() = false, want true")
+	}
+	if got := (&Identity{AuthEnabled: true, User: "bob"}).HasWriteAccess(); got {
+		t.Error("HasWriteAccess() without permissions = true, want false")
+	}
+	if got := (&Identity{}).Access("/any", ""); !got.CanRead() || !got.CanWrite() {
+		t.Errorf("Access() with auth disabled = %s, want read-write", got)
+	}
+}
+
+// TestIdentityNeedsProbe verifies which ranges are probed before their
+// access is known
+func TestIdentityNeedsProbe(t *testing.T) {
+	probed := &Identity{User: "alice", AuthEnabled: true, Probed: true}
+	if !probed.NeedsProbe("/app/a", "") {
+		t.Error("NeedsProbe() before probing = false, want true")
+	}
+
+	probed.probes = map[keyRange]Access{newKeyRange("/app/a", ""): {Write: CoverageNone}}
+	if probed.NeedsProbe("/app/a", "") {
+		t.Error("NeedsProbe() after probing = true, want false")
+	}
+	if got := probed.Access("/app/a", ""); got.CanWrite() {
+		t.Errorf("Access() after probing = %s, want read-only", got)
+	}
+	if !probed.NeedsProbe("/app/", PrefixRangeEnd("/app/")) {
+		t.Error("NeedsProbe() of another range = false, want true")
+	}
+
+	for _, id := range []*Identity{
+		{User: "alice", AuthEnabled: true},
+		{User: "root", AuthEnabled: true, Root: true, Probed: true},
+		{Probed: true},
+	} {
+		if id.NeedsProbe("/app/a", "") {
+			t.Errorf("NeedsProbe() of %s = true, want false", id.Describe())
+		}
+	}
+}
+
+// TestEvaluatorCheck verifies access answers for range end edge cases
+func TestEvaluatorCheck(t *testing.T) {
+	users := []*User{
+		{Name: "alice", Roles: []string{"app", "cfg"}},
+		{Name: "bob", Roles: []string{"split", "gone"}},
+		{Name: "carol", Roles: []string{"all"}},
+		{Name: "admin", Roles: []string{"root"}},
+		{Name: "nobody"},
+	}
+	roles := []*Role{
+		{Name: "app", Permissions: []*Permission{
+			{Key: "/app/", RangeEnd: PrefixRangeEnd("/app/"), PermType: PermissionReadWrite},
+		}},
+		{Name: "cfg", Permissions: []*Permission{
+			{Key: "/cfg/", RangeEnd: PrefixRangeEnd("/cfg/"), PermType: PermissionRead},
+			{Key: "/cfg/own", PermType: PermissionWrite},
+			{Key: "/z", RangeEnd: "/a", PermType: PermissionReadWrite},
+		}},
+		{Name: "split", Permissions: []*Permission{
+			{Key: "/a", RangeEnd: "/m", PermType: PermissionRead},
+			{Key: "/m", RangeEnd: "/q", PermType: PermissionRead},
+			{Key: "/x/", RangeEnd: "\x00", PermType: PermissionWrite},
+		}},
+		{Name: "all", Permissions: []*Permission{
+			{Key: "", RangeEnd: "\x00", PermType: PermissionRead},
+		}},
+	}
+	e := NewEvaluator(users, roles)
+
+	tests := []struct {
+		name          string
+		user          string
+		key, rangeEnd string
+		want          string
+		readGrants    int
+		writeGrants   int
+	}{
+		{"key in prefix", "alice", "/app/a", "", "read-write", 1, 1},
+		{"prefix itself", "alice", "/app/", PrefixRangeEnd("/app/"), "read-write", 1, 1},
+		{"key equal to prefix range end", "alice", "/app0", "", "none", 0, 0},
+		{"prefix without separator", "alice", "/app", PrefixRangeEnd("/app"), "partial", 1, 1},
+		{"single key permission", "alice", "/cfg/own", "", "read-write", 1, 1},
+		{"single key does not cover its prefix", "alice", "/cfg/own/", PrefixRangeEnd("/cfg/own/"), "read-only", 1, 0},
+		{"write on part of prefix", "alice", "/cfg/", PrefixRangeEnd("/cfg/"), "partial", 1, 1},
+		{"inverted permission range is ignored", "alice", "/m", "", "none", 0, 0},
+		{"adjacent ranges join", "bob", "/a", "/q", "read-only", 2, 0},
+		{"range end is exclusive", "bob", "/q", "", "none", 0, 0},
+		{"range beyond permissions", "bob", "/a", "/qq", "partial", 2, 0},
+		{"from key", "bob", "/x/2024", "", "write-only", 0, 1},
+		{"from key covers later keys", "bob", "/y", "\x00", "write-only", 0, 1},
+		{"from key range before it", "bob", "/x", "\x00", "partial", 0, 1},
+		{"all keys", "carol", "", "\x00", "read-only", 1, 0},
+		{"all keys cover empty key", "carol", "\xff\xff", "", "read-only", 1, 0},
+		{"root", "admin", "", "\x00", "read-write", 1, 1},
+		{"no roles", "nobody", "/app/a", "", "none", 0, 0},
+	}
+
+	for _, tt := range tests {
+		check, err := e.Check(tt.user, tt.key, tt.rangeEnd)
+		if err != nil {
+			t.Errorf("%s: Check() error: %v", tt.name, err)
+			continue
+		}
+		if got := check.Access.String(); got != tt.want {
+			t.Errorf("%s: Check(%q, %q) = %s, want %s", tt.name, tt.key, tt.rangeEnd, got, tt.want)
+		}
+		if len(check.ReadGrants) != tt.readGrants || len(check.WriteGrants) != tt.writeGrants {
+			t.Errorf("%s: got %d read and %d write grants, want %d and %d",
+				tt.name, len(check.ReadGrants), len(check.WriteGrants), tt.readGrants, tt.writeGrants)
+		}
+	}
+
+	check, err := e.Check("bob", "/a", "")
+	if err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+	if len(check.MissingRoles) != 1 || check.MissingRoles[0] != "gone" {
+		t.Errorf("MissingRoles = %v, want [gone]", check.MissingRoles)
+	}
+	if got := check.ReadGrants[0].String(); got != `role split: read ["/a", "/m")` {
+		t.Errorf("Grant.String() = %s", got)
+	}
+
+	if _, err := e.Check("mallory", "/a", ""); !errors.Is(err, ErrUnknownUser) {
+		t.Errorf("Check() of unknown user error = %v, want ErrUnknownUser", err)
+	}
+}
+
+// TestWriteExport verifies the flat, nested and dump export formats
+func TestWriteExport(t *testing.T) {
+	kvs := []*KeyValue{
+		{Key: "/app/", Value: []byte("root")},
+		{Key: "/app/db", Value: []byte("postgres")},
+		{Key: "/app/db/host", Value: []byte("localhost")},
+		{Key: "/app/bin", Value: []byte{0xff, 0x00}, ModRevision: 7, CreateRevision: 3, Version: 2, Lease: 42},
+		{Key: "/app/tricky", Value: []byte("base64:not encoded")},
+	}
+
+	tests := []struct {
+		name string
+		opts ExportOptions
+		want string
+	}{
+		{"flat json", ExportOptions{Prefix: "/app/", Format: ExportJSON}, `{
+  "": "root",
+  "bin": "base64:/wA=",
+  "db": "postgres",
+  "db/host": "localhost",
+  "tricky": "base64:YmFzZTY0Om5vdCBlbmNvZGVk"
+}
+`},
+		{"nested json", ExportOptions{Prefix: "/app/", Format: ExportJSON, Nested: true}, `{
+  ".": "root",
+  "bin": "base64:/wA=",
+  "db": {
+    ".": "postgres",
+    "host": "localhost"
+  },
+  "tricky": "base64:YmFzZTY0Om5vdCBlbmNvZGVk"
+}
+`},
+		{"nested yaml", ExportOptions{Prefix: "/app/", Format: ExportYAML, Nested: true}, `.: root
+bin: base64:/wA=
+db:
+  .: postgres
+  host: localhost
+tricky: base64:YmFzZTY0Om5vdCBlbmNvZGVk
+`},
+		{"dump", ExportOptions{Prefix: "/app/bin", Format: ExportDump}, `{"prefix":"L2FwcC9iaW4=","revision":9,"count":1}
+{"key":"L2FwcC9iaW4=","create_revision":3,"mod_revision":7,"version":2,"value":"/wA=","lease":42}
+`},
+	}
+
+	for _, tt := range tests {
+		selected := kvs
+		if tt.opts.Format == ExportDump {
+			selected = kvs[3:4]
+		}
+		var buf bytes.Buffer
+		if err := WriteExport(&buf, selected, 9, tt.opts); err != nil {
+			t.Errorf("%s: WriteExport() error: %v", tt.name, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: WriteExport() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+
+	for _, kv := range kvs {
+		value, err := DecodeValue(EncodeValue(kv.Value))
+		if err != nil || !bytes.Equal(value, kv.Value) {
+			t.Errorf("DecodeValue(EncodeValue(%q)) = %q, %v", kv.Value, value, err)
+		}
+	}
+
+	if _, err := ParseExportFormat("xml"); err == nil {
+		t.Error("ParseExportFormat(xml) error = nil, want error")
+	}
+}
+
+func TestReadImport(t *testing.T) {
+	kvs := []*KeyValue{
+		{Key: "/app/", Value: []byte("root")},
+		{Key: "/app/bin", Value: []byte{0xff, 0x00}},
+		{Key: "/app/db", Value: []byte("postgres")},
+		{Key: "/app/db/host", Value: []byte("localhost")},
+		{Key: "/app/tricky", Value: []byte("base64:not encoded")},
+	}
+
+	// Every export reads back as the keys relative to the prefix
+	for _, opts := range []ExportOptions{
+		{Prefix: "/app/", Format: ExportJSON},
+		{Prefix: "/app/", Format: ExportJSON, Nested: true},
+		{Prefix: "/app/", Format: ExportYAML},
+		{Prefix: "/app/", Format: ExportYAML, Nested: true},
+		{Prefix: "/app/", Format: ExportDump},
+	} {
+		var buf bytes.Buffer
+		if err := WriteExport(&buf, kvs, 9, opts); err != nil {
+			t.Fatalf("WriteExport(%+v) error: %v", opts, err)
+		}
+		entries, err := ReadImport(&buf, opts.Format)
+		if err != nil {
+			t.Errorf("ReadImport(%s, nested %v) error: %v", opts.Format, opts.Nested, err)
+			continue
+		}
+		if len(entries) != len(kvs) {
+			t.Errorf("ReadImport(%s, nested %v) returned %d entries, want %d", opts.Format, opts.Nested, len(entries), len(kvs))
+			continue
+		}
+		for i, e := range entries {
+			if want := strings.TrimPrefix(kvs[i].Key, "/app/"); e.Key != want || !bytes.Equal(e.Value, kvs[i].Value) {
+				t.Errorf("ReadImport(%s, nested %v)[%d] = %q: %q, want %q: %q", opts.Format, opts.Nested, i, e.Key, e.Value, want, kvs[i].Value)
+			}
+		}
+	}
+
+	// Hand written YAML with scalars that are not strings
+	entries, err := ReadImport(strings.NewReader("port: 8080\ndebug: true\nempty:\n"), ExportYAML)
+	if err != nil {
+		t.Fatalf("ReadImport(yaml scalars) error: %v", err)
+	}
+	got := make(map[string]string)
+	for _, e := range entries {
+		got[e.Key] = string(e.Value)
+	}
+	if got["port"] != "8080" || got["debug"] != "true" || got["empty"] != "" {
+		t.Errorf("ReadImport(yaml scalars) = %v", got)
+	}
+
+	invalid := []struct {
+		name   string
+		format ExportFormat
+		data   string
+	}{
+		{"list", ExportJSON, `{"a": [1, 2]}`},
+		{"duplicate", ExportJSON, `{"a/b": "1", "a": {"b": "2"}}`},
+		{"bad base64", ExportJSON, `{"a": "base64:%%%"}`},
+		{"truncated dump", ExportDump, `{"prefix":"L2FwcC8=","revision":9,"count":2}
+{"key":"L2FwcC9h","value":"MQ=="}
+`},
+		{"key outside dump prefix", ExportDump, `{"prefix":"L2FwcC8=","revision":9,"count":1}
+{"key":"L290aGVy","value":"MQ=="}
+`},
+	}
+	for _, tt := range invalid {
+		if _, err := ReadImport(strings.NewReader(tt.data), tt.format); err == nil {
+			t.Errorf("ReadImport(%s) error = nil, want error", tt.name)
+		}
+	}
+
+	for path, want := range map[string]ExportFormat{"a.json": ExportJSON, "a.YML": ExportYAML, "a.jsonl": ExportDump} {
+		if got, err := DetectImportFormat(path); err != nil || got != want {
+			t.Errorf("DetectImportFormat(%s) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+	if _, err := DetectImportFormat("a.txt"); err == nil {
+		t.Error("DetectImportFormat(a.txt) error = nil, want error")
+	}
+}
+
+func TestBuildImportPlan(t *testing.T) {
+	current := []*KeyValue{
+		{Key: "/dst/same", Value: []byte("1"), ModRevision: 3},
+		{Key: "/dst/changed", Value: []byte("old"), ModRevision: 4},
+		{Key: "/dst/extra", Value: []byte("x"), ModRevision: 5},
+	}
+	entries := []*ImportEntry{
+		{Key: "same", Value: []byte("1")},
+		{Key: "changed", Value: []byte("new")},
+		{Key: "added", Value: []byte("2")},
+	}
+
+	tests := []struct {
+		name string
+		opts ImportOptions
+		want map[string]ImportAction
+	}{
+		{"keep extra keys", ImportOptions{Prefix: "/dst/"}, map[string]ImportAction{
+			"/dst/added": ImportCreate, "/dst/changed": ImportUpdate, "/dst/same": ImportUnchanged,
+		}},
+		{"delete extra keys", ImportOptions{Prefix: "/dst/", Delete: true}, map[string]ImportAction{
+			"/dst/added": ImportCreate, "/dst/changed": ImportUpdate, "/dst/extra": ImportDelete, "/dst/same": ImportUnchanged,
+		}},
+	}
+
+	for _, tt := range tests {
+		plan := BuildImportPlan(current, 10, entries, tt.opts)
+		if len(plan.Changes) != len(tt.want) {
+			t.Errorf("%s: %d changes, want %d", tt.name, len(plan.Changes), len(tt.want))
+			continue
+		}
+		for i, c := range plan.Changes {
+			if i > 0 && plan.Changes[i-1].Key >= c.Key {
+				t.Errorf("%s: changes not sorted at %s", tt.name, c.Key)
+			}
+			if c.Action != tt.want[c.Key] {
+				t.Errorf("%s: %s is %s, want %s", tt.name, c.Key, c.Action, tt.want[c.Key])
+			}
+			if (c.Current == nil) != (c.Action == ImportCreate) {
+				t.Errorf("%s: %s has current %v", tt.name, c.Key, c.Current)
+			}
+		}
+		if len(plan.Pending()) != len(tt.want)-1 {
+			t.Errorf("%s: %d pending changes, want %d", tt.name, len(plan.Pending()), len(tt.want)-1)
+		}
+	}
+}
+
+// TestDetectValueFormat verifies format detection by content and key
+func TestDetectValueFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+	}{
+		{"json object", "/a", `{"a": 1, "b": [true, null]}`, "json"},
+		{"json list", "/a", " [1, 2]\n", "json"},
+		{"json scalar", "/a", `"text"`, "text"},
+		{"broken json", "/a", `{"a": 1`, "text"},
+		{"xml", "/a", `<?xml version="1.0"?><a x="1"><b>t</b></a>`, "xml"},
+		{"unclosed xml", "/a", `<a><b></a>`, "text"},
+		{"yaml mapping", "/a", "name: app\nports:\n  - 80\n", "yaml"},
+		{"yaml list", "/a", "- a\n- b\n", "yaml"},
+		{"ini that starts like yaml", "/a", "[a]\nb = c\n", "ini"},
+		{"env", "/a", "# comment\nexport HOST=db\nPORT=5432\n", "env"},
+		{"toml", "/a", "title = \"x\"\n\n[server]\nport = 80\n", "toml"},
+		{"ini", "/a", "; comment\n[server]\nname = my host\nport: 80\n", "ini"},
+		{"plain text", "/a", "hello world", "text"},
+		{"sentence with colon", "/a", "Note: see below", "text"},
+		{"single line list", "/a", "- see below", "text"},
+		{"yaml flow mapping", "/a", "{a: 1, b: 2}", "yaml"},
+		{"yaml single nested key", "/a", "server:\n  port: 80\n", "yaml"},
+		{"number", "/a", "42", "text"},
+		{"empty", "/a", "", "text"},
+		{"binary", "/a", "\x00\x01{}", "text"},
+		{"key extension", "/app/config.toml", "a = 1", "toml"},
+		{"key extension with other content", "/app/config.yaml", `{"a": 1}`, "yaml"},
+		{"invalid for key extension", "/app/config.json", "a: 1\nb: 2", "yaml"},
+	}
+
+	for _, tt := range tests {
+		if got := DetectValueFormat(tt.key, []byte(tt.value)).Name(); got != tt.want {
+			t.Errorf("%s: detected %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestValueDecoderPretty verifies pretty printing and error lines
+func TestValueDecoderPretty(t *testing.T) {
+	tests := []struct {
+		format  string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{"json", `{"a":[1,2]}`, "{\n  \"a\": [\n    1,\n    2\n  ]\n}", ""},
+		{"json", "{\n\"a\": 1,\n}", "", "line 3"},
+		{"xml", `<a x="1"><b>t</b><c></c></a>`, "<a x=\"1\">\n  <b>t</b>\n  <c/>\n</a>", ""},
+		{"xml", "<a>\n<b>\n</a>", "", "line 3"},
+		{"yaml", "a:   1\nb:\n    - x\n", "a: 1\nb:\n  - x", ""},
+		{"yaml", "a: 1\n b: 2\n", "", "line 2"},
+		{"toml", "a = 1\nb = \n", "", "line 2"},
+		{"ini", "[s]\na = 1\noops\n", "", "line 3"},
+		{"env", "A=1\nB 2\n", "", "line 2"},
+	}
+
+	for _, tt := range tests {
+		got, err := ValueDecoderByName(tt.format).Pretty([]byte(tt.value))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s %q: error %v, want %s", tt.format, tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.format, tt.value, err)
+		} else if got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.format, tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestValueDecoderTokens verifies that tokens cover the text and classify
+// keys
+func TestValueDecoderTokens(t *testing.T) {
+	tests := []struct {
+		format string
+		text   string
+		key    string
+	}{
+		{"json", "{\n  \"name\": \"x\",\n  \"n\": -1.5e3\n}", `"name"`},
+		{"xml", `<a id="1"><!-- c --><b>t</b></a>`, "id"},
+		{"yaml", "# c\nitems:\n  - name: \"a # b\"  # note\n", "name"},
+		{"toml", "[server]\nport = 80 # c\n", "port"},
+		{"ini", "[s]\nhost=db\n", "host"},
+		{"env", "export HOST='db'\n", "HOST"},
+		{"json", `{"broken": `, `"broken"`},
+	}
+
+	for _, tt := range tests {
+		tokens := ValueDecoderByName(tt.format).Tokens(tt.text)
+		var b strings.Builder
+		found := false
+		for _, tok := range tokens {
+			b.WriteString(tok.Text)
+			if tok.Kind == TokenKey && tok.Text == tt.key {
+				found = true
+			}
+		}
+		if b.String() != tt.text {
+			t.Errorf("%s: tokens give %q, want %q", tt.format, b.String(), tt.text)
+		}
+		if !found {
+			t.Errorf("%s: key %s not found in %v", tt.format, tt.key, tokens)
+		}
+	}
+}
+
+// csvDecoder is a decoder registered by TestRegisterValueDecoder
+type csvDecoder struct{ plainDecoder }
+
+func (csvDecoder) Name() string             { return "csv" }
+func (csvDecoder) Extensions() []string     { return []string{".csv"} }
+func (csvDecoder) Detect(value []byte) bool { return bytes.Count(value, []byte(",")) > 1 }
+
+// TestRegisterValueDecoder verifies that registered decoders are tried first
+func TestRegisterValueDecoder(t *testing.T) {
+	defer func(saved []ValueDecoder) {
+		decodersMu.Lock()
+		decoders = saved
+		decodersMu.Unlock()
+	}(ValueDecoders())
+
+	value := []byte("a,b,c")
+	if got := DetectValueFormat("/a", value).Name(); got != "text" {
+		t.Fatalf("detected %s before registering", got)
+	}
+
+	RegisterValueDecoder(csvDecoder{})
+	if got := DetectValueFormat("/a", value).Name(); got != "csv" {
+		t.Errorf("detected %s, want csv", got)
+	}
+	if ValueDecoderByName("csv") == nil {
+		t.Error("csv decoder not found by name")
+	}
+
+	// Registering a name again replaces the decoder
+	count := len(ValueDecoders())
+	RegisterValueDecoder(csvDecoder{})
+	if len(ValueDecoders()) != count {
+		t.Errorf("%d decoders after registering again, want %d", len(ValueDecoders()), count)
+	}
+}
+
+// TestValidator verifies rule matching, format checks and error lines
+func TestValidator(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"type": "object", "required": ["port"], "properties": {"port": {"type": "integer", "minimum": 1}}}`))
+	if err != nil {
+		t.Fatalf("ParseSchema: %v", err)
+	}
+	v, err := NewValidator([]ValidationRule{
+		{Prefix: "/config/", Format: "json", Schema: schema},
+		{Glob: "/services/*/settings.yaml", Format: "yaml"},
+		{Prefix: "/any/", Schema: schema},
+	})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  []string
+	}{
+		{"valid json", "/config/app", `{"port": 80}`, nil},
+		{"syntax error", "/config/app", "{\n  \"port\": 80,\n}", []string{"line 3: not valid JSON"}},
+		{"schema error", "/config/app", "{\n  \"port\": 0\n}", []string{"line 2: /port: must be at least 1"}},
+		{"wrong type", "/config/app", "{\n  \"port\": \"80\"\n}", []string{"line 2: /port: must be integer, not string"}},
+		{"missing property", "/config/app", `{}`, []string{`line 1: missing required property "port"`}},
+		{"yaml glob", "/services/web/settings.yaml", "a: 1\n b: 2\n", []string{"line 2: not valid YAML"}},
+		{"glob stays in segment", "/services/web/x/settings.yaml", "a: 1\n b: 2\n", nil},
+		{"schema alone with yaml", "/any/x", "port: 80\n", nil},
+		{"schema alone with yaml error", "/any/x", "name: x\nport: -1\n", []string{"line 2: /port: must be at least 1"}},
+		{"binary", "/config/app", "\x00\x01", []string{"binary value is not valid JSON"}},
+		{"no rule", "/other", "{", nil},
+	}
+
+	for _, tt := range tests {
+		err := v.Validate(tt.key, []byte(tt.value))
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("%s: got %v, want a validation error", tt.name, err)
+			continue
+		}
+		if len(validationErr.Issues) != len(tt.want) {
+			t.Errorf("%s: got issues %v, want %v", tt.name, validationErr.Issues, tt.want)
+			continue
+		}
+		for i, want := range tt.want {
+			if got := validationErr.Issues[i].String(); !strings.HasPrefix(got, want) {
+				t.Errorf("%s: issue %q, want prefix %q", tt.name, got, want)
+			}
+		}
+	}
+
+	var nilValidator *Validator
+	if err := nilValidator.Validate("/config/app", []byte("{")); err != nil {
+		t.Errorf("nil validator: %v", err)
+	}
+
+	for _, rules := range [][]ValidationRule{
+		{{Format: "json"}},
+		{{Prefix: "/a", Glob: "/a/*", Format: "json"}},
+		{{Prefix: "/a"}},
+		{{Prefix: "/a", Format: "csv"}},
+		{{Glob: "/a/[", Format: "json"}},
+		{{Prefix: "/a", Format: "toml", Schema: schema}},
+	} {
+		if _, err := NewValidator(rules); err == nil {
+			t.Errorf("NewValidator(%+v) accepted invalid rules", rules)
+		}
+	}
+}
+
+// TestSchemaKeywords verifies the supported JSON Schema keywords
+func TestSchemaKeywords(t *testing.T) {
+	tests := []struct {
+		schema string
+		value  string
+		valid  bool
+	}{
+		{`{"type": ["string", "null"]}`, `null`, true},
+		{`{"type": "integer"}`, `1.0`, true},
+		{`{"type": "integer"}`, `1.5`, false},
+		{`{"enum": ["a", 1]}`, `1`, true},
+		{`{"enum": ["a", 1]}`, `"b"`, false},
+		{`{"const": {"a": [1]}}`, `{"a": [1]}`, true},
+		{`{"additionalProperties": false, "properties": {"a": true}}`, `{"a": 1, "b": 2}`, false},
+		{`{"additionalProperties": {"type": "number"}}`, `{"a": 1}`, true},
+		{`{"items": {"type": "string"}, "minItems": 2}`, `["a"]`, false},
+		{`{"uniqueItems": true}`, `[1, 2, 1]`, false},
+		{`{"maxProperties": 1}`, `{"a": 1, "b": 2}`, false},
+		{`{"minLength": 2, "maxLength": 3}`, `"héé"`, true},
+		{`{"pattern": "^[a-z]+$"}`, `"abc1"`, false},
+		{`{"exclusiveMinimum": 0, "maximum": 10}`, `0`, false},
+		{`{"minimum": 0, "exclusiveMinimum": true}`, `0`, false},
+		{`{"multipleOf": 0.5}`, `2.5`, true},
+		{`{"anyOf": [{"type": "string"}, {"type": "number"}]}`, `true`, false},
+		{`{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `1`, false},
+		{`{"not": {"type": "string"}}`, `1`, true},
+		{`{"allOf": [{"minimum": 1}, {"maximum": 2}]}`, `3`, false},
+		{`{"$defs": {"port": {"type": "integer"}}, "properties": {"p": {"$ref": "#/$defs/port"}}}`, `{"p": "x"}`, false},
+		{`{"definitions": {"node": {"properties": {"next": {"$ref": "#/definitions/node"}}, "required": ["v"]}}, "$ref": "#/definitions/node"}`, `{"v": 1, "next": {"v": 2, "next": {}}}`, false},
+		{`false`, `1`, false},
+		{"type: object\nrequired: [a]\n", `{"a": 1}`, true},
+		{`{"$schema": "http://json-schema.org/draft-07/schema#", "title": "port", "description": "TCP port", "default": 80, "type": "integer"}`, `80`, true},
+	}
+
+	for _, tt := range tests {
+		schema, err := ParseSchema([]byte(tt.schema))
+		if err != nil {
+			t.Errorf("ParseSchema(%s): %v", tt.schema, err)
+			continue
+		}
+		v, err := NewValidator([]ValidationRule{{Prefix: "/", Format: "json", Schema: schema}})
+		if err != nil {
+			t.Fatalf("NewValidator: %v", err)
+		}
+		if err := v.Validate("/k", []byte(tt.value)); (err == nil) != tt.valid {
+			t.Errorf("%s with %s: got %v, want valid %v", tt.schema, tt.value, err, tt.valid)
+		}
+	}
+
+	for _, bad := range []string{`[]`, `{"type": "text"}`, `{"minimum": "1"}`, `{"pattern": "("}`, `{"$ref": "#/$defs/missing"}`, `{"anyOf": []}`} {
+		if _, err := ParseSchema([]byte(bad)); err == nil {
+			t.Errorf("ParseSchema(%s) accepted an invalid schema", bad)
+		}
+	}
+}
+
+// TestSchemaUnsupportedKeywords verifies that schemas using keywords the
+// validator does not check are rejected instead of accepting any value
+func TestSchemaUnsupportedKeywords(t *testing.T) {
+	tests := []struct {
+		schema  string
+		keyword string
+	}{
+		{`{"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}`, "patternProperties"},
+		{`{"if": {"properties": {"tls": {"const": true}}}, "then": {"required": ["cert"]}}`, "if"},
+		{`{"type": "string", "format": "email"}`, "format"},
+		{`{"properties": {"a": {"dependentRequired": {"a": ["b"]}}}}`, "dependentRequired"},
+		{`{"$defs": {"x": {"prefixItems": [true]}}}`, "prefixItems"},
+		{`{"x-vendor": 1}`, "x-vendor"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseSchema([]byte(tt.schema))
+		if err == nil || !strings.Contains(err.Error(), tt.keyword+" is not supported") {
+			t.Errorf("ParseSchema(%s) error = %v, want %s not supported", tt.schema, err, tt.keyword)
+		}
+	}
+}
+
+// TestImportPlanInvalid verifies that imports check created and updated
+// values only
+func TestImportPlanInvalid(t *testing.T) {
+	v, err := NewValidator([]ValidationRule{{Prefix: "/dst/", Format: "json"}})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+	current := []*KeyValue{
+		{Key: "/dst/old", Value: []byte("not json"), ModRevision: 2},
+		{Key: "/dst/same", Value: []byte("also not json"), ModRevision: 3},
+	}
+	entries := []*ImportEntry{
+		{Key: "bad", Value: []byte("{")},
+		{Key: "good", Value: []byte(`{"a": 1}`)},
+		{Key: "same", Value: []byte("also not json")},
+	}
+
+	plan := BuildImportPlan(current, 5, entries, ImportOptions{Prefix: "/dst/", Delete: true})
+	invalid := plan.Invalid(v)
+	if len(invalid) != 1 || invalid[0].Key != "/dst/bad" {
+		t.Errorf("invalid values %v, want /dst/bad only", invalid)
+	}
+	if plan.Invalid(nil) != nil {
+		t.Error("a nil validator rejected values")
+	}
+}
+
+func TestGuard(t *testing.T) {
+	g, err := NewGuard(false, []ProtectedPrefix{
+		{Prefix: "/config/", Protection: ProtectConfirm},
+		{Prefix: "/config/db/", Protection: ProtectNoDelete},
+		{Prefix: "/locked/", Protection: ProtectNoWrite},
+	})
+	if err != nil {
+		t.Fatalf("NewGuard: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		check     func(string, bool) error
+		key       string
+		confirmed bool
+		want      error
+	}{
+		{"free put", g.CheckPut, "/app/x", false, nil},
+		{"free delete", g.CheckDelete, "/app/x", false, nil},
+		{"unconfirmed put", g.CheckPut, "/config/app", false, ErrNotConfirmed},
+		{"confirmed put", g.CheckPut, "/config/app", true, nil},
+		{"unconfirmed delete", g.CheckDelete, "/config/app", false, ErrNotConfirmed},
+		{"nested put needs confirmation", g.CheckPut, "/config/db/url", false, ErrNotConfirmed},
+		{"nested delete refused", g.CheckDelete, "/config/db/url", true, ErrProtected},
+		{"no-write put", g.CheckPut, "/locked/a", true, ErrProtected},
+		{"no-write delete", g.CheckDelete, "/locked/a", true, ErrProtected},
+		{"range above protected prefix", g.CheckDeletePrefix, "/", true, ErrProtected},
+		{"range inside confirm prefix", g.CheckDeletePrefix, "/config/web/", false, ErrNotConfirmed},
+		{"range elsewhere", g.CheckDeletePrefix, "/app/", false, nil},
+	}
+
+	for _, tt := range tests {
+		err := tt.check(tt.key, tt.confirmed)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if p := g.Protection("/config/db/url"); p.Prefix != "/config/db/" || p.Protection != ProtectNoDelete {
+		t.Errorf("Protection = %+v, want the no-delete prefix", p)
+	}
+
+	readOnly, err := NewGuard(true, nil)
+	if err != nil {
+		t.Fatalf("NewGuard: %v", err)
+	}
+	if err := readOnly.CheckPut("/app/x", true); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("read-only put: got %v, want ErrReadOnly", err)
+	}
+	if err := readOnly.CheckChange("add a member"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("read-only change: got %v, want ErrReadOnly", err)
+	}
+	if err := g.CheckChange("add a member"); err != nil {
+		t.Errorf("change with protected prefixes refused: %v", err)
+	}
+
+	var nilGuard *Guard
+	if err := nilGuard.CheckDeletePrefix("", false); err != nil || nilGuard.ReadOnly() || nilGuard.Protects() {
+		t.Errorf("nil guard refused: %v", err)
+	}
+
+	if _, err := NewGuard(false, []ProtectedPrefix{{Prefix: "/x/"}}); err == nil {
+		t.Error("NewGuard accepted a prefix without protection")
+	}
+	if _, err := ParseProtection("no-read"); err == nil {
+		t.Error("ParseProtection accepted no-read")
+	}
+}
+
+func TestImportPlanRefused(t *testing.T) {
+	g, err := NewGuard(false, []ProtectedPrefix{
+		{Prefix: "/dst/keep/", Protection: ProtectNoDelete},
+		{Prefix: "/dst/ask/", Protection: ProtectConfirm},
+	})
+	if err != nil {
+		t.Fatalf("NewGuard: %v", err)
+	}
+	current := []*KeyValue{
+		{Key: "/dst/keep/old", Value: []byte("1"), ModRevision: 2},
+		{Key: "/dst/keep/same", Value: []byte("2"), ModRevision: 3},
+	}
+	entries := []*ImportEntry{
+		{Key: "keep/same", Value: []byte("2")},
+		{Key: "keep/new", Value: []byte("3")},
+		{Key: "ask/new", Value: []byte("4")},
+	}
+
+	plan := BuildImportPlan(current, 5, entries, ImportOptions{Prefix: "/dst/", Delete: true})
+	refused := plan.Refused(g, false)
+	if len(refused) != 2 || refused[0].Key != "/dst/ask/new" || refused[1].Key != "/dst/keep/old" {
+		t.Fatalf("refused %v, want /dst/ask/new and /dst/keep/old", refused)
+	}
+	if !errors.Is(refused[0], ErrNotConfirmed) || !errors.Is(refused[1], ErrProtected) {
+		t.Errorf("refused %v, want a missing confirmation and a protected delete", refused)
+	}
+	if refused := plan.Refused(g, true); len(refused) != 1 {
+		t.Errorf("confirmed plan refused %v, want the delete only", refused)
+	}
+	if plan.Refused(nil, false) != nil {
+		t.Error("a nil guard refused changes")
+	}
+}
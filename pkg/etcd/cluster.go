@@ -0,0 +1,367 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// ClusterStatus represents the status of etcd cluster
+type ClusterStatus struct {
+	Members []*MemberStatus
+
+	// Leader is the name of the raft leader, empty if there is none
+	Leader string
+
+	// LeaderID is the member ID of the raft leader, 0 if there is none
+	LeaderID uint64
+
+	// ClusterID identifies the cluster
+	ClusterID uint64
+
+	// IsHealthy is true if there is a leader and every started member
+	// answered without reporting errors
+	IsHealthy bool
+}
+
+// Member is a member of the etcd cluster
+type Member struct {
+	ID         uint64
+	Name       string
+	PeerURLs   []string
+	ClientURLs []string
+	IsLearner  bool
+}
+
+// Started returns true if the member has joined and published its name
+// and client URLs
+func (m *Member) Started() bool {
+	return m.Name != "" && len(m.ClientURLs) > 0
+}
+
+// MemberStatus represents status of a single etcd member
+type MemberStatus struct {
+	Member
+
+	Endpoint string
+	IsLeader bool
+
+	// Status is the status reported by the member's first client URL, nil
+	// for members that have not started yet
+	Status *EndpointStatus
+}
+
+// Role returns leader, follower, learner, unstarted or unreachable
+func (m *MemberStatus) Role() string {
+	switch {
+	case !m.Started():
+		return "unstarted"
+	case m.Status != nil && m.Status.Err != nil:
+		return "unreachable"
+	case m.IsLeader:
+		return "leader"
+	case m.IsLearner:
+		return "learner"
+	default:
+		return "follower"
+	}
+}
+
+// EndpointStatus represents the status reported by a single endpoint
+type EndpointStatus struct {
+	Endpoint string
+
+	// MemberID is the ID of the member serving the endpoint
+	MemberID uint64
+
+	// LeaderID is the member the endpoint believes to be the raft leader
+	LeaderID uint64
+
+	Version          string
+	DBSize           int64
+	DBSizeInUse      int64
+	DBSizeQuota      int64
+	RaftTerm         uint64
+	RaftIndex        uint64
+	RaftAppliedIndex uint64
+	IsLearner        bool
+
+	// Errors holds alarms and other problems reported by the member
+	Errors []string
+
+	// Err is set if the endpoint could not be queried
+	Err error
+}
+
+// GetEndpointStatus queries the status of a single endpoint. Failures are
+// reported in the returned status rather than as an error.
+func (c *Client) GetEndpointStatus(ctx context.Context, endpoint string) *EndpointStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	status := &EndpointStatus{Endpoint: endpoint}
+
+	resp, err := c.client.Status(ctx, endpoint)
+	if err != nil {
+		status.Err = fmt.Errorf("failed to get status of %s: %w", endpoint, err)
+		return status
+	}
+
+	if resp.Header != nil {
+		status.MemberID = resp.Header.MemberId
+	}
+	status.LeaderID = resp.Leader
+	status.Version = resp.Version
+	status.DBSize = resp.DbSize
+	status.DBSizeInUse = resp.DbSizeInUse
+	status.DBSizeQuota = resp.DbSizeQuota
+	status.RaftTerm = resp.RaftTerm
+	status.RaftIndex = resp.RaftIndex
+	status.RaftAppliedIndex = resp.RaftAppliedIndex
+	status.IsLearner = resp.IsLearner
+	status.Errors = resp.Errors
+
+	return status
+}
+
+// GetClusterStatus retrieves the members of the etcd cluster together with
+// the status of each member's endpoint. The leader is taken from what the
+// members report, preferring the highest raft term.
+func (c *Client) GetClusterStatus(ctx context.Context) (*ClusterStatus, error) {
+	listCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// Get member list
+	memberList, err := c.client.MemberList(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member list: %w", err)
+	}
+
+	status := &ClusterStatus{
+		Members: make([]*MemberStatus, 0, len(memberList.Members)),
+	}
+	if memberList.Header != nil {
+		status.ClusterID = memberList.Header.ClusterId
+	}
+
+	for _, member := range memberList.Members {
+		memberStatus := &MemberStatus{Member: *newMember(member)}
+
+		if len(member.ClientURLs) > 0 {
+			memberStatus.Endpoint = member.ClientURLs[0]
+		}
+
+		status.Members = append(status.Members, memberStatus)
+	}
+
+	// Query all members at once so one unreachable member does not delay
+	// the others
+	var wg sync.WaitGroup
+	for _, m := range status.Members {
+		if m.Endpoint == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(m *MemberStatus) {
+			defer wg.Done()
+			m.Status = c.GetEndpointStatus(ctx, m.Endpoint)
+		}(m)
+	}
+	wg.Wait()
+
+	statuses := make([]*EndpointStatus, 0, len(status.Members))
+	for _, m := range status.Members {
+		if m.Status != nil && m.Status.Err == nil {
+			statuses = append(statuses, m.Status)
+		}
+	}
+
+	// Advertised client URLs may not be reachable from here; fall back to
+	// the configured endpoints to find the leader
+	if len(statuses) == 0 {
+		for _, endpoint := range c.client.Endpoints() {
+			if s := c.GetEndpointStatus(ctx, endpoint); s.Err == nil {
+				statuses = append(statuses, s)
+				break
+			}
+		}
+	}
+
+	status.LeaderID = leaderOf(statuses)
+
+	status.IsHealthy = status.LeaderID != 0
+	for _, m := range status.Members {
+		if m.ID == status.LeaderID && status.LeaderID != 0 {
+			m.IsLeader = true
+			status.Leader = m.Name
+		}
+		if m.Status != nil && (m.Status.Err != nil || len(m.Status.Errors) > 0) {
+			status.IsHealthy = false
+		}
+	}
+
+	return status, nil
+}
+
+// leaderOf returns the leader reported with the highest raft term, 0 if no
+// endpoint knows a leader
+func leaderOf(statuses []*EndpointStatus) uint64 {
+	var leader, term uint64
+	for _, s := range statuses {
+		if s.LeaderID != 0 && (leader == 0 || s.RaftTerm > term) {
+			leader, term = s.LeaderID, s.RaftTerm
+		}
+	}
+	return leader
+}
+
+// ListMembers returns the members of the cluster
+func (c *Client) ListMembers(ctx context.Context) ([]*Member, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.MemberList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member list: %w", err)
+	}
+	return newMembers(resp.Members), nil
+}
+
+// AddMember adds a member with the given peer URLs, as a non-voting learner
+// if requested. It returns the new member and the resulting member list. The
+// member has to be started with the returned cluster configuration to join.
+func (c *Client) AddMember(ctx context.Context, peerURLs []string, learner bool) (*Member, []*Member, error) {
+	if err := c.Guard().CheckChange("add a member"); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if len(peerURLs) == 0 {
+		return nil, nil, fmt.Errorf("at least one peer URL is required")
+	}
+
+	add := c.client.MemberAdd
+	if learner {
+		add = c.client.MemberAddAsLearner
+	}
+
+	resp, err := add(ctx, peerURLs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	return newMember(resp.Member), newMembers(resp.Members), nil
+}
+
+// RemoveMember removes a member from the cluster
+func (c *Client) RemoveMember(ctx context.Context, id uint64) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("remove member %x", id)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if _, err := c.client.MemberRemove(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove member %x: %w", id, err)
+	}
+	return nil
+}
+
+// PromoteMember promotes a learner to a voting member. etcd refuses this
+// until the learner has caught up with the leader.
+func (c *Client) PromoteMember(ctx context.Context, id uint64) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("promote member %x", id)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if _, err := c.client.MemberPromote(ctx, id); err != nil {
+		return fmt.Errorf("failed to promote member %x: %w", id, err)
+	}
+	return nil
+}
+
+// MoveLeader transfers leadership to another voting member. The request
+// has to be served by the current leader, so it is sent to its endpoint.
+func (c *Client) MoveLeader(ctx context.Context, transfereeID uint64) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("move the leader to %x", transfereeID)); err != nil {
+		return err
+	}
+
+	status, err := c.GetClusterStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	var leader *MemberStatus
+	for _, m := range status.Members {
+		if m.IsLeader {
+			leader = m
+		}
+	}
+	switch {
+	case leader == nil:
+		return fmt.Errorf("failed to move leader: cluster has no leader")
+	case leader.ID == transfereeID:
+		return nil
+	case leader.Endpoint == "":
+		return fmt.Errorf("failed to move leader: leader %x has no client URL", leader.ID)
+	}
+
+	cli, err := c.dialEndpoint(leader.Endpoint)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if _, err := cli.MoveLeader(ctx, transfereeID); err != nil {
+		return fmt.Errorf("failed to move leader to %x: %w", transfereeID, err)
+	}
+	return nil
+}
+
+// InitialCluster returns the initial cluster setting for starting the
+// added member newID under name, in the form etcd expects
+func InitialCluster(members []*Member, newID uint64, name string) string {
+	var parts []string
+	for _, m := range members {
+		memberName := m.Name
+		if m.ID == newID {
+			memberName = name
+		}
+		for _, u := range m.PeerURLs {
+			parts = append(parts, memberName+"="+u)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// newMember converts a member from the etcd API
+func newMember(m *etcdserverpb.Member) *Member {
+	return &Member{
+		ID:         m.ID,
+		Name:       m.Name,
+		PeerURLs:   m.PeerURLs,
+		ClientURLs: m.ClientURLs,
+		IsLearner:  m.IsLearner,
+	}
+}
+
+// newMembers converts a member list from the etcd API
+func newMembers(members []*etcdserverpb.Member) []*Member {
+	result := make([]*Member, 0, len(members))
+	for _, m := range members {
+		result = append(result, newMember(m))
+	}
+	return result
+}
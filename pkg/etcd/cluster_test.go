@@ -0,0 +1,945 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alex-dev-master/etcdtui/internal/etcdtest"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// newTestClient connects to members and closes the client when the test
+// ends
+func newTestClient(t *testing.T, members ...*etcdtest.Member) *Client {
+	t.Helper()
+	return newTestUserClient(t, "", "", members...)
+}
+
+// newTestUserClient connects to members as user and closes the client when
+// the test ends
+func newTestUserClient(t *testing.T, user, password string, members ...*etcdtest.Member) *Client {
+	t.Helper()
+	cfg := testConfig(members...)
+	cfg.Username, cfg.Password = user, password
+	return newTestConfigClient(t, cfg)
+}
+
+// newTestGuardedClient connects to members with changes checked by guard
+// and closes the client when the test ends
+func newTestGuardedClient(t *testing.T, guard *Guard, members ...*etcdtest.Member) *Client {
+	t.Helper()
+	cfg := testConfig(members...)
+	cfg.Guard = guard
+	return newTestConfigClient(t, cfg)
+}
+
+// testConfig returns the default configuration with the client URLs of
+// members as endpoints
+func testConfig(members ...*etcdtest.Member) *Config {
+	cfg := DefaultConfig()
+	cfg.Endpoints = nil
+	for _, m := range members {
+		cfg.Endpoints = append(cfg.Endpoints, m.ClientURL)
+	}
+	return cfg
+}
+
+// newTestConfigClient connects with cfg and closes the client when the test
+// ends
+func newTestConfigClient(t *testing.T, cfg *Config) *Client {
+	t.Helper()
+	cli, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	return cli
+}
+
+// eventually retries fn until it returns nil or the timeout expires
+func eventually(t *testing.T, what string, fn func() error) {
+	t.Helper()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		err := fn()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: %v", what, err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// TestMemberManagement adds, promotes and removes members and moves the
+// leader on an embedded three member cluster
+func TestMemberManagement(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 3)
+	cli := newTestClient(t, members...)
+
+	ctx := context.Background()
+
+	list, err := cli.ListMembers(ctx)
+	if err != nil {
+		t.Fatalf("ListMembers() error: %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("ListMembers() returned %d members, want 3", len(list))
+	}
+
+	// Move the leader to a follower
+	status, err := cli.GetClusterStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterStatus() error: %v", err)
+	}
+	var transferee uint64
+	for _, m := range status.Members {
+		if !m.IsLeader {
+			transferee = m.ID
+			break
+		}
+	}
+	if err := cli.MoveLeader(ctx, transferee); err != nil {
+		t.Fatalf("MoveLeader() error: %v", err)
+	}
+	eventually(t, "leader did not move", func() error {
+		status, err := cli.GetClusterStatus(ctx)
+		if err != nil {
+			return err
+		}
+		if status.LeaderID != transferee {
+			return fmt.Errorf("leader is %x, want %x", status.LeaderID, transferee)
+		}
+		return nil
+	})
+
+	// Add a learner and start it. etcd refuses membership changes until all
+	// members have been connected for a few seconds.
+	learner := &etcdtest.Member{Name: "learner", PeerURL: etcdtest.FreeURL(t), ClientURL: etcdtest.FreeURL(t)}
+	var added *Member
+	eventually(t, "learner was not added", func() error {
+		added, list, err = cli.AddMember(ctx, []string{learner.PeerURL}, true)
+		if errors.Is(err, rpctypes.ErrUnhealthy) {
+			return err
+		}
+		if err != nil {
+			t.Fatalf("AddMember() error: %v", err)
+		}
+		return nil
+	})
+	if !added.IsLearner || added.Started() || len(list) != 4 {
+		t.Fatalf("AddMember() = %+v with %d members, want unstarted learner of 4", added, len(list))
+	}
+
+	etcdtest.StartMember(t, learner, InitialCluster(list, added.ID, learner.Name), embed.ClusterStateFlagExisting)
+
+	eventually(t, "learner was not promoted", func() error {
+		err := cli.PromoteMember(ctx, added.ID)
+		if errors.Is(err, rpctypes.ErrMemberLearnerNotReady) {
+			return err
+		}
+		if err != nil {
+			t.Fatalf("PromoteMember() error: %v", err)
+		}
+		return nil
+	})
+
+	list, err = cli.ListMembers(ctx)
+	if err != nil {
+		t.Fatalf("ListMembers() error: %v", err)
+	}
+	for _, m := range list {
+		if m.ID == added.ID && (m.IsLearner || m.Name != learner.Name) {
+			t.Errorf("Promoted member = %+v, want started voting member", m)
+		}
+	}
+
+	// Remove the new member again
+	if err := cli.RemoveMember(ctx, added.ID); err != nil {
+		t.Fatalf("RemoveMember() error: %v", err)
+	}
+	list, err = cli.ListMembers(ctx)
+	if err != nil {
+		t.Fatalf("ListMembers() error: %v", err)
+	}
+	if len(list) != 3 {
+		t.Errorf("ListMembers() after remove returned %d members, want 3", len(list))
+	}
+
+	if err := cli.RemoveMember(ctx, added.ID); err == nil {
+		t.Error("Expected error removing a member twice")
+	}
+}
+
+// TestLimitedUser lists and probes keys as a user that may only read and
+// write some prefixes
+func TestLimitedUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+
+	root := newTestClient(t, members[0])
+
+	for _, key := range []string{"/app/a", "/app/b/c", "/cfg/x", "/other/y"} {
+		if err := root.Put(ctx, key, "1"); err != nil {
+			t.Fatalf("Put(%s) error: %v", key, err)
+		}
+	}
+	steps := []struct {
+		what string
+		fn   func() error
+	}{
+		{"create root", func() error { return root.CreateUser(ctx, "root", "secret") }},
+		{"grant root", func() error { return root.GrantRole(ctx, "root", "root") }},
+		{"create alice", func() error { return root.CreateUser(ctx, "alice", "pw") }},
+		{"create app", func() error { return root.CreateRole(ctx, "app") }},
+		{"create cfg", func() error { return root.CreateRole(ctx, "cfg") }},
+		{"grant app", func() error {
+			return root.GrantPermission(ctx, "app", "/app/", PrefixRangeEnd("/app/"), PermissionReadWrite)
+		}},
+		{"grant cfg", func() error {
+			return root.GrantPermission(ctx, "cfg", "/cfg/", PrefixRangeEnd("/cfg/"), PermissionRead)
+		}},
+		{"grant alice app", func() error { return root.GrantRole(ctx, "alice", "app") }},
+		{"grant alice cfg", func() error { return root.GrantRole(ctx, "alice", "cfg") }},
+		{"enable auth", func() error { return root.EnableAuth(ctx) }},
+	}
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			t.Fatalf("%s: %v", step.what, err)
+		}
+	}
+
+	admin := newTestUserClient(t, "root", "secret", members[0])
+
+	evaluator, err := admin.LoadEvaluator(ctx)
+	if err != nil {
+		t.Fatalf("LoadEvaluator() error: %v", err)
+	}
+	check, err := evaluator.Check("alice", "/cfg/", PrefixRangeEnd("/cfg/"))
+	if err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+	if got := check.Access.String(); got != "read-only" || len(check.ReadGrants) != 1 || check.ReadGrants[0].Role != "cfg" {
+		t.Errorf("Check(/cfg/) = %s with %d read grants, want read-only from cfg", got, len(check.ReadGrants))
+	}
+
+	alice := newTestUserClient(t, "alice", "pw", members[0])
+
+	if err := alice.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck() error: %v", err)
+	}
+
+	id, err := alice.GetIdentity(ctx)
+	if err != nil {
+		t.Fatalf("GetIdentity() error: %v", err)
+	}
+	if !id.AuthEnabled || id.Root || id.Probed || len(id.Roles) != 2 {
+		t.Fatalf("GetIdentity() = %+v, want two roles read", id)
+	}
+
+	if _, _, err := alice.ListChildren(ctx, ""); !errors.Is(err, rpctypes.ErrPermissionDenied) {
+		t.Errorf("ListChildren() error = %v, want permission denied", err)
+	}
+	children, _, err := alice.ListReadableChildren(ctx, id, "", 0)
+	if err != nil {
+		t.Fatalf("ListReadableChildren() error: %v", err)
+	}
+	if len(children) != 1 || children[0].Prefix != "/" || children[0].Count != 3 {
+		t.Fatalf("ListReadableChildren() = %+v, want / with 3 keys", children)
+	}
+
+	for _, tt := range []struct {
+		prefix string
+		want   string
+	}{
+		{"/app/", "read-write"},
+		{"/cfg/", "read-only"},
+		{"/other/", "none"},
+	} {
+		if got := id.Access(tt.prefix, PrefixRangeEnd(tt.prefix)).String(); got != tt.want {
+			t.Errorf("Access(%s) = %s, want %s", tt.prefix, got, tt.want)
+		}
+		probed := &Identity{User: "alice", AuthEnabled: true, Probed: true}
+		if got := alice.ProbeAccess(ctx, probed, tt.prefix, PrefixRangeEnd(tt.prefix)).String(); got != tt.want {
+			t.Errorf("ProbeAccess(%s) = %s, want %s", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+// TestApplyImport imports into a prefix in batches and stops at a batch
+// whose keys changed after planning
+func TestApplyImport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+
+	cli := newTestClient(t, members[0])
+
+	for key, value := range map[string]string{"/dst/same": "1", "/dst/changed": "old", "/dst/extra": "x"} {
+		if err := cli.Put(ctx, key, value); err != nil {
+			t.Fatalf("Put(%s) error: %v", key, err)
+		}
+	}
+
+	var entries []*ImportEntry
+	for i := range 5 {
+		entries = append(entries, &ImportEntry{Key: fmt.Sprintf("new%d", i), Value: []byte("v")})
+	}
+	entries = append(entries, &ImportEntry{Key: "same", Value: []byte("1")}, &ImportEntry{Key: "changed", Value: []byte("new")})
+	opts := ImportOptions{Prefix: "/dst/", Delete: true, MaxTxnOps: 2}
+
+	plan, err := cli.PlanImport(ctx, entries, opts, nil)
+	if err != nil {
+		t.Fatalf("PlanImport() error: %v", err)
+	}
+	if got := plan.Summary(); got != "5 to create, 1 to update, 1 to delete, 1 unchanged" {
+		t.Fatalf("Summary() = %s", got)
+	}
+
+	// A key changed after planning rejects its batch
+	if err := cli.Put(ctx, "/dst/new3", "concurrent"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	result, err := cli.ApplyImport(ctx, plan, opts, nil)
+	if !errors.Is(err, ErrImportConflict) {
+		t.Fatalf("ApplyImport() error = %v, want conflict", err)
+	}
+	if result.Applied != 4 || result.Txns != 2 || len(result.Conflicts) != 1 || result.Conflicts[0] != "/dst/new3" {
+		t.Fatalf("ApplyImport() = %+v, want 4 keys in 2 transactions and a conflict on /dst/new3", result)
+	}
+
+	// Planning again picks up the concurrent change and the applied batches
+	plan, err = cli.PlanImport(ctx, entries, opts, nil)
+	if err != nil {
+		t.Fatalf("PlanImport() error: %v", err)
+	}
+	var applied []int
+	result, err = cli.ApplyImport(ctx, plan, opts, func(done, total int) { applied = append(applied, done) })
+	if err != nil {
+		t.Fatalf("ApplyImport() error: %v", err)
+	}
+	if result.Applied != 3 || result.Txns != 2 || len(applied) != 2 || applied[1] != 3 {
+		t.Fatalf("ApplyImport() = %+v, want the remaining 3 keys in 2 transactions", result)
+	}
+
+	kvs, _, err := cli.ListAll(ctx, ListOptions{Prefix: "/dst/"}, nil)
+	if err != nil {
+		t.Fatalf("ListAll() error: %v", err)
+	}
+	got := make(map[string]string)
+	for _, kv := range kvs {
+		got[kv.Key] = string(kv.Value)
+	}
+	if len(got) != 7 || got["/dst/changed"] != "new" || got["/dst/new3"] != "v" || got["/dst/extra"] != "" {
+		t.Errorf("keys after import = %v", got)
+	}
+}
+
+// TestApplyImportInvalid stops an import with values failing validation
+// before anything is written
+func TestApplyImportInvalid(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+	cli := newTestClient(t, members[0])
+
+	entries := []*ImportEntry{
+		{Key: "ok.json", Value: []byte(`{"a": 1}`)},
+		{Key: "broken.json", Value: []byte(`{"a": `)},
+	}
+	validator, err := NewValidator([]ValidationRule{{Prefix: "/dst/", Format: "json"}})
+	if err != nil {
+		t.Fatalf("NewValidator() error: %v", err)
+	}
+	opts := ImportOptions{Prefix: "/dst/", Validator: validator}
+
+	plan, err := cli.PlanImport(ctx, entries, opts, nil)
+	if err != nil {
+		t.Fatalf("PlanImport() error: %v", err)
+	}
+	result, err := cli.ApplyImport(ctx, plan, opts, nil)
+	if !errors.Is(err, ErrInvalidValue) || result.Applied != 0 || result.Txns != 0 {
+		t.Fatalf("ApplyImport() = %+v, %v, want nothing applied and a validation error", result, err)
+	}
+
+	count, err := cli.GetKeyCountWithPrefix(ctx, "/dst/")
+	if err != nil || count != 0 {
+		t.Errorf("keys after refused import = %d, %v, want none", count, err)
+	}
+
+	// Without the validator both values are written
+	opts.Validator = nil
+	if result, err := cli.ApplyImport(ctx, plan, opts, nil); err != nil || result.Applied != 2 {
+		t.Errorf("ApplyImport() without validator = %+v, %v, want 2 keys", result, err)
+	}
+}
+
+// TestGuardedClient refuses the changes of a client's guard before anything
+// is sent, and allows confirm-protected changes once confirmed
+func TestGuardedClient(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+	admin := newTestClient(t, members[0])
+
+	for _, key := range []string{"/app/a", "/cfg/a", "/secret/a"} {
+		if err := admin.Put(ctx, key, "v"); err != nil {
+			t.Fatalf("Put(%s) error: %v", key, err)
+		}
+	}
+	lease, err := admin.PutWithTTL(ctx, "/cfg/leased", "v", time.Minute)
+	if err != nil {
+		t.Fatalf("PutWithTTL() error: %v", err)
+	}
+
+	guard, err := NewGuard(false, []ProtectedPrefix{
+		{Prefix: "/cfg/", Protection: ProtectConfirm},
+		{Prefix: "/secret/", Protection: ProtectNoWrite},
+	})
+	if err != nil {
+		t.Fatalf("NewGuard() error: %v", err)
+	}
+	cli := newTestGuardedClient(t, guard, members[0])
+	confirmedCtx := WithConfirmation(ctx)
+
+	if err := cli.Put(ctx, "/app/b", "v"); err != nil {
+		t.Errorf("Put(/app/b) error: %v", err)
+	}
+	if err := cli.Put(ctx, "/secret/a", "x"); !errors.Is(err, ErrProtected) {
+		t.Errorf("Put(/secret/a) error = %v, want protected", err)
+	}
+	if err := cli.Put(confirmedCtx, "/secret/a", "x"); !errors.Is(err, ErrProtected) {
+		t.Errorf("confirmed Put(/secret/a) error = %v, want protected", err)
+	}
+	if err := cli.Put(ctx, "/cfg/a", "x"); !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("Put(/cfg/a) error = %v, want not confirmed", err)
+	}
+	if err := cli.Put(confirmedCtx, "/cfg/a", "x"); err != nil {
+		t.Errorf("confirmed Put(/cfg/a) error: %v", err)
+	}
+	if _, err := cli.DeletePrefix(ctx, "/"); !errors.Is(err, ErrProtected) {
+		t.Errorf("DeletePrefix(/) error = %v, want protected", err)
+	}
+
+	// A refused operation in either branch keeps the whole transaction
+	_, err = cli.NewTxn().
+		If(CompareVersion("/app/a", CompareGreater, 0)).
+		Then(OpPut("/app/c", "v")).
+		Else(OpDelete("/secret/a")).
+		Commit(ctx)
+	if !errors.Is(err, ErrProtected) {
+		t.Errorf("Commit() error = %v, want protected", err)
+	}
+	if _, err := admin.Get(ctx, "/app/c"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get(/app/c) error = %v, want the refused transaction not applied", err)
+	}
+
+	opts := ImportOptions{Prefix: "/secret/"}
+	plan, err := cli.PlanImport(ctx, []*ImportEntry{{Key: "b", Value: []byte("v")}}, opts, nil)
+	if err != nil {
+		t.Fatalf("PlanImport() error: %v", err)
+	}
+	if result, err := cli.ApplyImport(ctx, plan, opts, nil); !errors.Is(err, ErrProtected) || result.Applied != 0 {
+		t.Errorf("ApplyImport() = %+v, %v, want nothing applied and a protected error", result, err)
+	}
+
+	// Revoking a lease deletes its keys, so they must be allowed too
+	if err := cli.RevokeLease(ctx, lease.ID); !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("RevokeLease() error = %v, want not confirmed", err)
+	}
+	if _, err := admin.Get(ctx, "/cfg/leased"); err != nil {
+		t.Errorf("Get(/cfg/leased) error after refused revoke: %v", err)
+	}
+	if err := cli.RevokeLease(confirmedCtx, lease.ID); err != nil {
+		t.Errorf("confirmed RevokeLease() error: %v", err)
+	}
+}
+
+// TestReadOnlyClient refuses every change of a read-only client and still
+// reads
+func TestReadOnlyClient(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := WithConfirmation(context.Background())
+	admin := newTestClient(t, members[0])
+
+	if err := admin.Put(ctx, "/app/a", "v"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	lease, err := admin.GrantLease(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("GrantLease() error: %v", err)
+	}
+
+	guard, err := NewGuard(true, nil)
+	if err != nil {
+		t.Fatalf("NewGuard() error: %v", err)
+	}
+	cli := newTestGuardedClient(t, guard, members[0])
+
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"Put", func() error { return cli.Put(ctx, "/app/a", "x") }},
+		{"Delete", func() error { return cli.Delete(ctx, "/app/a") }},
+		{"Commit", func() error {
+			_, err := cli.NewTxn().Then(OpPut("/app/b", "v")).Commit(ctx)
+			return err
+		}},
+		{"PutIfModRevision", func() error {
+			_, _, err := cli.PutIfModRevision(ctx, "/app/b", "v", 0, 0)
+			return err
+		}},
+		{"GrantLease", func() error {
+			_, err := cli.GrantLease(ctx, time.Minute)
+			return err
+		}},
+		{"RevokeLease", func() error { return cli.RevokeLease(ctx, lease.ID) }},
+		{"AddMember", func() error {
+			_, _, err := cli.AddMember(ctx, []string{"http://127.0.0.1:1"}, true)
+			return err
+		}},
+		{"CreateUser", func() error { return cli.CreateUser(ctx, "bob", "pw") }},
+		{"EnableAuth", func() error { return cli.EnableAuth(ctx) }},
+		{"CompactHistory", func() error { return cli.CompactHistory(ctx, 1) }},
+	}
+	for _, tt := range changes {
+		if err := tt.change(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s() error = %v, want read-only", tt.name, err)
+		}
+	}
+
+	if kv, err := cli.Get(ctx, "/app/a"); err != nil || string(kv.Value) != "v" {
+		t.Errorf("Get() = %+v, %v, want the unchanged key", kv, err)
+	}
+	if info, err := cli.GetLeaseInfo(ctx, lease.ID); err != nil || info.TTL <= 0 {
+		t.Errorf("GetLeaseInfo() = %+v, %v, want the lease alive", info, err)
+	}
+}
+
+// TestPutIfModRevision writes keys only while they are at the revision
+// read and reports the current key on a conflict
+func TestPutIfModRevision(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+
+	cli := newTestClient(t, members[0])
+
+	// Revision 0 creates only missing keys
+	ok, _, err := cli.PutIfModRevision(ctx, "/cas", "v1", 0, 0)
+	if err != nil || !ok {
+		t.Fatalf("PutIfModRevision(create) = %v, %v", ok, err)
+	}
+	ok, current, err := cli.PutIfModRevision(ctx, "/cas", "clobber", 0, 0)
+	if err != nil || ok || current == nil || string(current.Value) != "v1" {
+		t.Fatalf("PutIfModRevision(existing) = %v, %+v, %v; want conflict with v1", ok, current, err)
+	}
+
+	// An edit of a stale read reports the newer value
+	read, err := cli.Get(ctx, "/cas")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if err := cli.Put(ctx, "/cas", "v2"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	ok, current, err = cli.PutIfModRevision(ctx, "/cas", "mine", read.ModRevision, 0)
+	if err != nil || ok || current == nil || string(current.Value) != "v2" {
+		t.Fatalf("PutIfModRevision(stale) = %v, %+v, %v; want conflict with v2", ok, current, err)
+	}
+	ok, _, err = cli.PutIfModRevision(ctx, "/cas", "mine", current.ModRevision, 0)
+	if err != nil || !ok {
+		t.Fatalf("PutIfModRevision(current) = %v, %v", ok, err)
+	}
+
+	// A deleted key is reported as nil
+	if err := cli.Delete(ctx, "/cas"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	ok, current, err = cli.PutIfModRevision(ctx, "/cas", "again", read.ModRevision, 0)
+	if err != nil || ok || current != nil {
+		t.Fatalf("PutIfModRevision(deleted) = %v, %+v, %v; want conflict without a key", ok, current, err)
+	}
+}
+
+// TestListIterator pages through more keys than fit a page while the range
+// changes, and checks every key is read once at the revision of the first
+// page
+func TestListIterator(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+
+	cli := newTestClient(t, members[0])
+
+	const count = 25
+	var want []string
+	for i := range count {
+		key := fmt.Sprintf("/list/%02d", i)
+		if err := cli.Put(ctx, key, "v"); err != nil {
+			t.Fatalf("Put(%s) error: %v", key, err)
+		}
+		want = append(want, key)
+	}
+
+	it := cli.NewListIterator(ListOptions{Prefix: "/list/", BatchSize: 10, KeysOnly: true})
+	var (
+		got      []string
+		pages    int
+		revision int64
+	)
+	for it.Next(ctx) {
+		page := it.Page()
+		if pages == 0 {
+			revision = page.Revision
+
+			// Changes after the first page must not show up in later ones
+			if err := cli.Delete(ctx, "/list/15"); err != nil {
+				t.Fatalf("Delete() error: %v", err)
+			}
+			if err := cli.Put(ctx, "/list/99", "v"); err != nil {
+				t.Fatalf("Put() error: %v", err)
+			}
+		}
+		if page.Revision != revision {
+			t.Errorf("page %d Revision = %d, want %d", pages, page.Revision, revision)
+		}
+		for _, kv := range page.KVs {
+			got = append(got, kv.Key)
+		}
+		pages++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Next() error: %v", err)
+	}
+
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+	if loaded, total := it.Progress(); loaded != count || total != count {
+		t.Errorf("Progress() = %d, %d, want %d, %d", loaded, total, count, count)
+	}
+
+	// ListAll reports progress per page and reads at a pinned revision
+	var progress []int64
+	kvs, rev, err := cli.ListAll(ctx, ListOptions{Prefix: "/list/", BatchSize: 10, Revision: revision}, func(loaded, total int64) {
+		progress = append(progress, loaded)
+		if total != count {
+			t.Errorf("progress total = %d, want %d", total, count)
+		}
+	})
+	if err != nil {
+		t.Fatalf("ListAll() error: %v", err)
+	}
+	if rev != revision {
+		t.Errorf("ListAll() revision = %d, want %d", rev, revision)
+	}
+	got = got[:0]
+	for _, kv := range kvs {
+		got = append(got, kv.Key)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ListAll() keys = %v, want %v", got, want)
+	}
+	if fmt.Sprint(progress) != "[10 20 25]" {
+		t.Errorf("progress = %v, want [10 20 25]", progress)
+	}
+}
+
+// TestListChildren lists nested prefixes with pages smaller than the
+// children and subtrees, and checks the children and their key counts
+func TestListChildren(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	batchSize := childrenBatchSize
+	childrenBatchSize = 3
+	t.Cleanup(func() { childrenBatchSize = batchSize })
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+
+	cli := newTestClient(t, members[0])
+
+	keys := []string{"/app", "/app/a", "/app/b", "/app/c", "/app/d", "/app/e", "/top"}
+	for i := range 10 {
+		keys = append(keys, fmt.Sprintf("/app/big/%02d", i))
+	}
+	keys = append(keys, "/app/big/nested/x", "/app/big/nested/y", "/app/e/f", "/app/z/1")
+	for _, key := range keys {
+		if err := cli.Put(ctx, key, "v"); err != nil {
+			t.Fatalf("Put(%s) error: %v", key, err)
+		}
+	}
+
+	tests := []struct {
+		prefix string
+		want   string // name, key presence and count of every child
+	}{
+		{"/", "app key+dir 19, top key 0"},
+		{"/app/", "a key 0, b key 0, big dir 12, c key 0, d key 0, e key+dir 1, z dir 1"},
+		{"/app/big/", "00 key 0, 01 key 0, 02 key 0, 03 key 0, 04 key 0, 05 key 0, 06 key 0, 07 key 0, 08 key 0, 09 key 0, nested dir 2"},
+		{"/app/big/nested/", "x key 0, y key 0"},
+		{"/missing/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			children, revision, err := cli.ListChildren(ctx, tt.prefix)
+			if err != nil {
+				t.Fatalf("ListChildren() error: %v", err)
+			}
+			if revision == 0 {
+				t.Error("ListChildren() revision = 0")
+			}
+			if got := describeChildren(children); got != tt.want {
+				t.Errorf("ListChildren() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// A pinned revision hides later keys
+	_, revision, err := cli.ListChildren(ctx, "/app/")
+	if err != nil {
+		t.Fatalf("ListChildren() error: %v", err)
+	}
+	if err := cli.Put(ctx, "/app/big/new", "v"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	children, _, err := cli.ListChildrenAtRevision(ctx, "/app/", revision)
+	if err != nil {
+		t.Fatalf("ListChildrenAtRevision() error: %v", err)
+	}
+	if got, want := describeChildren(children), tests[1].want; got != want {
+		t.Errorf("ListChildrenAtRevision() = %q, want %q", got, want)
+	}
+}
+
+// describeChildren renders children as "name kind count" for comparison
+func describeChildren(children []*Child) string {
+	var parts []string
+	for _, ch := range children {
+		kind := "dir"
+		switch {
+		case ch.KV != nil && ch.IsDir():
+			kind = "key+dir"
+		case ch.KV != nil:
+			kind = "key"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %d", ch.Name, kind, ch.Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// TestHistory walks back through versions of a key, stops at the limit and
+// the compaction boundary, and restores an old version keeping the lease
+func TestHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+
+	cli := newTestClient(t, members[0])
+
+	var revisions []int64
+	for i := 1; i <= 5; i++ {
+		if err := cli.Put(ctx, "/hist", fmt.Sprintf("v%d", i)); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+		kv, err := cli.Get(ctx, "/hist")
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		revisions = append(revisions, kv.ModRevision)
+
+		// Unrelated writes between versions must not show up
+		if err := cli.Put(ctx, "/other", "x"); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+
+	values := func(h *KeyHistory) string {
+		var vs []string
+		for _, kv := range h.Versions {
+			vs = append(vs, string(kv.Value))
+		}
+		return strings.Join(vs, " ")
+	}
+
+	history, err := cli.History(ctx, "/hist", 0)
+	if err != nil {
+		t.Fatalf("History() error: %v", err)
+	}
+	if got := values(history); got != "v5 v4 v3 v2 v1" || !history.Complete() {
+		t.Errorf("History() = %q, complete %v; want all five versions", got, history.Complete())
+	}
+
+	history, err = cli.History(ctx, "/hist", 2)
+	if err != nil {
+		t.Fatalf("History(limit) error: %v", err)
+	}
+	if got := values(history); got != "v5 v4" || !history.Truncated || history.Compacted {
+		t.Errorf("History(limit) = %q, truncated %v, compacted %v; want v5 v4 truncated", got, history.Truncated, history.Compacted)
+	}
+
+	// Versions before the compaction revision are gone
+	if err := cli.CompactHistory(ctx, revisions[2]); err != nil {
+		t.Fatalf("CompactHistory() error: %v", err)
+	}
+	history, err = cli.History(ctx, "/hist", 0)
+	if err != nil {
+		t.Fatalf("History(compacted) error: %v", err)
+	}
+	if got := values(history); got != "v5 v4 v3" || !history.Compacted || history.Truncated {
+		t.Errorf("History(compacted) = %q, truncated %v, compacted %v; want v5 v4 v3 compacted", got, history.Truncated, history.Compacted)
+	}
+
+	// Restoring keeps the lease of the current version
+	lease, err := cli.PutWithTTL(ctx, "/hist", "v6", time.Minute)
+	if err != nil {
+		t.Fatalf("PutWithTTL() error: %v", err)
+	}
+	history, err = cli.History(ctx, "/hist", 0)
+	if err != nil {
+		t.Fatalf("History() error: %v", err)
+	}
+	current, old := history.Versions[0], history.Versions[2]
+
+	if ok, err := cli.RestoreVersion(ctx, old, old); err != nil || ok {
+		t.Errorf("RestoreVersion(stale) = %v, %v; want false", ok, err)
+	}
+	if ok, err := cli.RestoreVersion(ctx, old, current); err != nil || !ok {
+		t.Fatalf("RestoreVersion() = %v, %v; want true", ok, err)
+	}
+	restored, err := cli.Get(ctx, "/hist")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if string(restored.Value) != "v4" || restored.Lease != lease.ID {
+		t.Errorf("restored = %q with lease %d, want v4 with lease %d", restored.Value, restored.Lease, lease.ID)
+	}
+}
+
+// TestLeases grants leases, attaches keys to them and lists them with their
+// keys, including more leases than are looked up at once
+func TestLeases(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded etcd cluster")
+	}
+
+	members := etcdtest.StartCluster(t, 1)
+	ctx := context.Background()
+
+	cli := newTestClient(t, members[0])
+
+	withKeys, err := cli.PutWithTTL(ctx, "/lease/b", "v", time.Minute)
+	if err != nil {
+		t.Fatalf("PutWithTTL() error: %v", err)
+	}
+	if ok, _, err := cli.PutIfModRevision(ctx, "/lease/a", "v", 0, withKeys.ID); err != nil || !ok {
+		t.Fatalf("PutIfModRevision(lease) = %v, %v", ok, err)
+	}
+
+	orphans := make(map[int64]bool)
+	for range leaseLookups + 4 {
+		lease, err := cli.GrantLease(ctx, time.Minute)
+		if err != nil {
+			t.Fatalf("GrantLease() error: %v", err)
+		}
+		if lease.GrantedTTL != 60 {
+			t.Errorf("GrantLease() GrantedTTL = %d, want 60", lease.GrantedTTL)
+		}
+		orphans[lease.ID] = true
+	}
+
+	info, err := cli.GetLeaseInfoWithKeys(ctx, withKeys.ID)
+	if err != nil {
+		t.Fatalf("GetLeaseInfoWithKeys() error: %v", err)
+	}
+	if got := strings.Join(info.Keys, " "); got != "/lease/a /lease/b" || info.IsOrphan() {
+		t.Errorf("GetLeaseInfoWithKeys() keys = %q, orphan %v; want /lease/a /lease/b", got, info.IsOrphan())
+	}
+	if info.TTL <= 0 || info.TTL > 60 || info.GrantedTTL != 60 {
+		t.Errorf("GetLeaseInfoWithKeys() TTL = %d of %d, want up to 60 of 60", info.TTL, info.GrantedTTL)
+	}
+
+	infos, err := cli.ListLeaseInfos(ctx)
+	if err != nil {
+		t.Fatalf("ListLeaseInfos() error: %v", err)
+	}
+	if len(infos) != len(orphans)+1 {
+		t.Fatalf("ListLeaseInfos() returned %d leases, want %d", len(infos), len(orphans)+1)
+	}
+	for i, info := range infos {
+		if i > 0 && info.ID <= infos[i-1].ID {
+			t.Errorf("ListLeaseInfos() not ordered by ID: %d after %d", info.ID, infos[i-1].ID)
+		}
+		if info.IsOrphan() != orphans[info.ID] {
+			t.Errorf("lease %d IsOrphan() = %v, want %v", info.ID, info.IsOrphan(), orphans[info.ID])
+		}
+	}
+
+	if ttl, err := cli.KeepAliveOnce(ctx, withKeys.ID); err != nil || ttl != 60 {
+		t.Errorf("KeepAliveOnce() = %d, %v; want 60", ttl, err)
+	}
+
+	// Revoking drops the lease from the list and deletes its keys
+	if err := cli.RevokeLease(ctx, withKeys.ID); err != nil {
+		t.Fatalf("RevokeLease() error: %v", err)
+	}
+	if _, err := cli.Get(ctx, "/lease/a"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() after revoke error = %v, want ErrKeyNotFound", err)
+	}
+	infos, err = cli.ListLeaseInfos(ctx)
+	if err != nil {
+		t.Fatalf("ListLeaseInfos() error: %v", err)
+	}
+	for _, info := range infos {
+		if !orphans[info.ID] {
+			t.Errorf("ListLeaseInfos() after revoke includes lease %d", info.ID)
+		}
+	}
+	if len(infos) != len(orphans) {
+		t.Errorf("ListLeaseInfos() after revoke returned %d leases, want %d", len(infos), len(orphans))
+	}
+}
@@ -20,6 +20,9 @@ type Config struct {
 	DialTimeout    time.Duration
 	RequestTimeout time.Duration
 	KeepAlive      time.Duration
+
+	// Guard refuses the changes the client must not make (optional)
+	Guard *Guard
 }
 
 // TLSConfig represents TLS/SSL configuration
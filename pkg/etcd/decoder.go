@@ -0,0 +1,146 @@
+package client
+
+import (
+	"path"
+	"strings"
+	"sync"
+)
+
+// TokenKind classifies a piece of a value for syntax colouring
+type TokenKind int
+
+const (
+	TokenText TokenKind = iota
+	TokenKey
+	TokenString
+	TokenNumber
+	TokenKeyword
+	TokenPunct
+	TokenComment
+
+	// TokenSection is a TOML or INI section header or an XML tag name
+	TokenSection
+)
+
+// Token is a piece of a value with its kind. Concatenating the tokens of a
+// text gives the text back.
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// ValueDecoder recognises, pretty prints and tokenizes one value format
+type ValueDecoder interface {
+	// Name identifies the format, e.g. "json"
+	Name() string
+
+	// Extensions are the key and file extensions of the format, the
+	// preferred one first
+	Extensions() []string
+
+	// Detect reports whether a value looks like the format
+	Detect(value []byte) bool
+
+	// Pretty formats a value for reading. It fails, with the line of the
+	// first problem where known, if the value is not valid.
+	Pretty(value []byte) (string, error)
+
+	// Tokens splits a text, pretty or as stored, for syntax colouring. It
+	// must accept any text, including invalid values.
+	Tokens(text string) []Token
+}
+
+// PlainText is the format of values no other decoder recognises
+var PlainText ValueDecoder = plainDecoder{}
+
+var (
+	decodersMu sync.RWMutex
+
+	// decoders are tried in order; JSON comes before YAML, which accepts
+	// JSON too, and env before TOML, which accepts simple env files
+	decoders = []ValueDecoder{
+		jsonDecoder{},
+		xmlDecoder{},
+		yamlDecoder{},
+		envDecoder{},
+		tomlDecoder{},
+		iniDecoder{},
+	}
+)
+
+// RegisterValueDecoder adds a decoder, tried before the built-in ones. A
+// decoder with the same name is replaced in place.
+func RegisterValueDecoder(d ValueDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	for i, existing := range decoders {
+		if existing.Name() == d.Name() {
+			decoders[i] = d
+			return
+		}
+	}
+	decoders = append([]ValueDecoder{d}, decoders...)
+}
+
+// ValueDecoders returns the registered decoders in detection order
+func ValueDecoders() []ValueDecoder {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	return append([]ValueDecoder(nil), decoders...)
+}
+
+// ValueDecoderByName returns the decoder of a format, or nil
+func ValueDecoderByName(name string) ValueDecoder {
+	if name == PlainText.Name() {
+		return PlainText
+	}
+	for _, d := range ValueDecoders() {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// DetectValueFormat returns the decoder for a value. A known extension of
+// the key wins if the value is valid in that format; otherwise the first
+// decoder that recognises the value is used, and PlainText if none does.
+func DetectValueFormat(key string, value []byte) ValueDecoder {
+	if !IsPrintable(value) {
+		return PlainText
+	}
+
+	list := ValueDecoders()
+	if ext := strings.ToLower(path.Ext(key)); ext != "" {
+		for _, d := range list {
+			for _, e := range d.Extensions() {
+				if e != ext {
+					continue
+				}
+				if _, err := d.Pretty(value); err == nil {
+					return d
+				}
+			}
+		}
+	}
+
+	for _, d := range list {
+		if d.Detect(value) {
+			return d
+		}
+	}
+	return PlainText
+}
+
+// plainDecoder shows values as they are
+type plainDecoder struct{}
+
+func (plainDecoder) Name() string                        { return "text" }
+func (plainDecoder) Extensions() []string                { return []string{".txt"} }
+func (plainDecoder) Detect([]byte) bool                  { return true }
+func (plainDecoder) Pretty(value []byte) (string, error) { return string(value), nil }
+
+func (plainDecoder) Tokens(text string) []Token {
+	return []Token{{Kind: TokenText, Text: text}}
+}
@@ -0,0 +1,722 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// Built-in decoders. TOML, INI and env values are shown as written; their
+// Pretty only checks them.
+type (
+	jsonDecoder struct{}
+	xmlDecoder  struct{}
+	yamlDecoder struct{}
+	tomlDecoder struct{}
+	iniDecoder  struct{}
+	envDecoder  struct{}
+)
+
+func (jsonDecoder) Name() string         { return "json" }
+func (jsonDecoder) Extensions() []string { return []string{".json"} }
+
+func (jsonDecoder) Detect(value []byte) bool {
+	trimmed := bytes.TrimSpace(value)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
+}
+
+func (jsonDecoder) Pretty(value []byte) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(value), "", "  "); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := bytes.Count(value[:min(int(syntaxErr.Offset), len(value))], []byte("\n")) + 1
+			return "", fmt.Errorf("line %d: %w", line, err)
+		}
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (jsonDecoder) Tokens(text string) []Token {
+	return lexValue(text, true, false)
+}
+
+func (xmlDecoder) Name() string         { return "xml" }
+func (xmlDecoder) Extensions() []string { return []string{".xml"} }
+
+func (d xmlDecoder) Detect(value []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(value), []byte("<")) {
+		return false
+	}
+	_, err := d.Pretty(value)
+	return err == nil
+}
+
+func (xmlDecoder) Pretty(value []byte) (string, error) {
+	// Token checks that elements nest; RawToken keeps prefixes as written
+	check := xml.NewDecoder(bytes.NewReader(value))
+	for {
+		if _, err := check.Token(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", xmlError(err)
+		}
+	}
+
+	var tokens []xml.Token
+	dec := xml.NewDecoder(bytes.NewReader(value))
+	for {
+		t, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", xmlError(err)
+		}
+		tokens = append(tokens, xml.CopyToken(t))
+	}
+
+	var (
+		b        strings.Builder
+		depth    int
+		elements int
+	)
+	line := func(s string) {
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch t := tokens[i].(type) {
+		case xml.StartElement:
+			elements++
+			open := "<" + xmlName(t.Name)
+			for _, attr := range t.Attr {
+				open += fmt.Sprintf(` %s="%s"`, xmlName(attr.Name), xmlAttrEscaper.Replace(attr.Value))
+			}
+
+			// Elements without children stay on one line
+			text, j := "", i+1
+			if j < len(tokens) {
+				if data, ok := tokens[j].(xml.CharData); ok {
+					text = strings.TrimSpace(string(data))
+					j++
+				}
+			}
+			if j < len(tokens) {
+				if _, ok := tokens[j].(xml.EndElement); ok {
+					if text == "" {
+						line(open + "/>")
+					} else {
+						line(open + ">" + xmlTextEscaper.Replace(text) + "</" + xmlName(t.Name) + ">")
+					}
+					i = j
+					continue
+				}
+			}
+			line(open + ">")
+			depth++
+		case xml.EndElement:
+			depth--
+			line("</" + xmlName(t.Name) + ">")
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); text != "" {
+				line(xmlTextEscaper.Replace(text))
+			}
+		case xml.Comment:
+			line("<!--" + string(t) + "-->")
+		case xml.ProcInst:
+			if len(t.Inst) == 0 {
+				line("<?" + t.Target + "?>")
+			} else {
+				line("<?" + t.Target + " " + string(t.Inst) + "?>")
+			}
+		case xml.Directive:
+			line("<!" + string(t) + ">")
+		}
+	}
+	if elements == 0 {
+		return "", errors.New("no XML element")
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (xmlDecoder) Tokens(text string) []Token {
+	var tl tokenList
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], "<!--"):
+			end := strings.Index(text[i:], "-->")
+			if end < 0 {
+				end = len(text) - i - 3
+			}
+			tl.add(TokenComment, text[i:i+end+3])
+			i += end + 3
+		case text[i] == '<':
+			i = tl.xmlTag(text, i)
+		default:
+			end := strings.IndexByte(text[i:], '<')
+			if end < 0 {
+				end = len(text) - i
+			}
+			tl.add(TokenText, text[i:i+end])
+			i += end
+		}
+	}
+	return tl
+}
+
+// xmlTag adds the tokens of the tag starting at text[i] and returns the
+// position after it
+func (tl *tokenList) xmlTag(text string, i int) int {
+	j := i + 1
+	if j < len(text) && strings.IndexByte("/?!", text[j]) >= 0 {
+		j++
+	}
+	tl.add(TokenPunct, text[i:j])
+
+	start := j
+	for j < len(text) && !isXMLSpace(text[j]) && strings.IndexByte("/?>", text[j]) < 0 {
+		j++
+	}
+	tl.add(TokenSection, text[start:j])
+
+	for j < len(text) {
+		c := text[j]
+		switch {
+		case c == '>':
+			tl.add(TokenPunct, ">")
+			return j + 1
+		case c == '"' || c == '\'':
+			end := scanQuoted(text, j, false)
+			tl.add(TokenString, text[j:end])
+			j = end
+		case isXMLSpace(c):
+			tl.add(TokenText, text[j:j+1])
+			j++
+		case strings.IndexByte("=/?", c) >= 0:
+			tl.add(TokenPunct, text[j:j+1])
+			j++
+		default:
+			start := j
+			for j < len(text) && !isXMLSpace(text[j]) && strings.IndexByte("=/?>\"'", text[j]) < 0 {
+				j++
+			}
+			tl.add(TokenKey, text[start:j])
+		}
+	}
+	return j
+}
+
+func (yamlDecoder) Name() string         { return "yaml" }
+func (yamlDecoder) Extensions() []string { return []string{".yaml", ".yml"} }
+
+// Detect accepts mappings and lists only, since any plain text is a YAML
+// string. A single line with one entry, such as a sentence with a colon,
+// is left as text.
+func (yamlDecoder) Detect(value []byte) bool {
+	docs, err := yamlDocuments(value)
+	if err != nil || len(docs) == 0 || len(docs[0].Content) == 0 {
+		return false
+	}
+	node := docs[0].Content[0]
+	entries := len(node.Content)
+	switch node.Kind {
+	case yaml.MappingNode:
+		entries /= 2
+	case yaml.SequenceNode:
+	default:
+		return false
+	}
+	return entries > 1 || strings.Contains(strings.TrimSpace(string(value)), "\n")
+}
+
+func (yamlDecoder) Pretty(value []byte) (string, error) {
+	docs, err := yamlDocuments(value)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// yamlDocuments parses every document of a YAML stream
+func yamlDocuments(value []byte) ([]*yaml.Node, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(value))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			return docs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		docs = append(docs, &doc)
+	}
+}
+
+func (yamlDecoder) Tokens(text string) []Token {
+	var tl tokenList
+	forEachLine(text, &tl, func(line string) {
+		indent, rest := splitIndent(line)
+		tl.add(TokenText, indent)
+
+		switch {
+		case strings.HasPrefix(rest, "#"):
+			tl.add(TokenComment, rest)
+			return
+		case rest == "---" || rest == "..." || strings.HasPrefix(rest, "--- "):
+			tl.add(TokenPunct, rest[:3])
+			tl.yamlScalar(rest[3:])
+			return
+		}
+
+		// List items, possibly nested on one line
+		for rest == "-" || strings.HasPrefix(rest, "- ") {
+			tl.add(TokenPunct, "-")
+			var space string
+			space, rest = splitIndent(rest[1:])
+			tl.add(TokenText, space)
+		}
+
+		if end := yamlKeyEnd(rest); end > 0 {
+			tl.add(TokenKey, rest[:end])
+			tl.add(TokenPunct, ":")
+			rest = rest[end+1:]
+		}
+		tl.yamlScalar(rest)
+	})
+	return tl
+}
+
+// yamlKeyEnd returns the position of the colon ending a mapping key at the
+// start of s, or -1
+func yamlKeyEnd(s string) int {
+	if s == "" || strings.IndexByte("#{[|>&*!%@`", s[0]) >= 0 {
+		return -1
+	}
+	end := 0
+	if s[0] == '"' || s[0] == '\'' {
+		end = scanQuoted(s, 0, s[0] == '"')
+	}
+	for i := end; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t') {
+			return i
+		}
+		if s[i] == '#' && i > 0 && s[i-1] == ' ' {
+			return -1
+		}
+	}
+	return -1
+}
+
+// yamlScalar adds the tokens of a value after a key or list marker
+func (tl *tokenList) yamlScalar(s string) {
+	space, s := splitIndent(s)
+	tl.add(TokenText, space)
+	if s == "" {
+		return
+	}
+
+	var value, comment string
+	switch {
+	case strings.HasPrefix(s, "#"):
+		tl.add(TokenComment, s)
+		return
+	case s[0] == '"' || s[0] == '\'':
+		end := scanQuoted(s, 0, s[0] == '"')
+		tl.add(TokenString, s[:end])
+		value, comment = "", s[end:]
+	case s[0] == '[' || s[0] == '{':
+		tl.addAll(lexValue(s, false, true))
+		return
+	default:
+		value, comment = s, ""
+		if i := strings.Index(s, " #"); i >= 0 {
+			value, comment = s[:i], s[i:]
+		}
+	}
+
+	trimmed := strings.TrimRight(value, " \t")
+	switch {
+	case trimmed == "":
+	case strings.IndexByte("|>", trimmed[0]) >= 0:
+		tl.add(TokenPunct, trimmed)
+	case strings.IndexByte("&*!", trimmed[0]) >= 0 || isYAMLKeyword(trimmed):
+		tl.add(TokenKeyword, trimmed)
+	case isNumber(trimmed):
+		tl.add(TokenNumber, trimmed)
+	default:
+		tl.add(TokenString, trimmed)
+	}
+	tl.add(TokenText, value[len(trimmed):])
+
+	space, comment = splitIndent(comment)
+	tl.add(TokenText, space)
+	tl.add(TokenComment, comment)
+}
+
+func (tomlDecoder) Name() string         { return "toml" }
+func (tomlDecoder) Extensions() []string { return []string{".toml"} }
+
+func (tomlDecoder) Detect(value []byte) bool {
+	var v map[string]interface{}
+	return bytes.ContainsRune(value, '=') && toml.Unmarshal(value, &v) == nil && len(v) > 0
+}
+
+func (tomlDecoder) Pretty(value []byte) (string, error) {
+	var v map[string]interface{}
+	if err := toml.Unmarshal(value, &v); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, _ := decodeErr.Position()
+			return "", fmt.Errorf("line %d: %s", row, decodeErr.Error())
+		}
+		return "", err
+	}
+	return string(value), nil
+}
+
+func (tomlDecoder) Tokens(text string) []Token {
+	var tl tokenList
+	forEachLine(text, &tl, func(line string) {
+		indent, rest := splitIndent(line)
+		tl.add(TokenText, indent)
+
+		switch {
+		case strings.HasPrefix(rest, "#"):
+			tl.add(TokenComment, rest)
+		case strings.HasPrefix(rest, "["):
+			end := strings.LastIndexByte(rest, ']') + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			tl.add(TokenSection, rest[:end])
+			tl.addAll(lexValue(rest[end:], false, true))
+		default:
+			if i := strings.IndexByte(rest, '='); i > 0 {
+				tl.keyValue(rest, i, false)
+				return
+			}
+			tl.addAll(lexValue(rest, false, true))
+		}
+	})
+	return tl
+}
+
+// iniSection matches a section header line of an INI file
+var iniSection = regexp.MustCompile(`^\s*\[[^\]]+\]\s*$`)
+
+func (iniDecoder) Name() string         { return "ini" }
+func (iniDecoder) Extensions() []string { return []string{".ini", ".cfg", ".conf", ".properties"} }
+
+// Detect requires a section, so that single lines of text are not INI
+func (d iniDecoder) Detect(value []byte) bool {
+	for _, line := range strings.Split(string(value), "\n") {
+		if iniSection.MatchString(line) {
+			return d.check(value) == nil
+		}
+	}
+	return false
+}
+
+func (d iniDecoder) Pretty(value []byte) (string, error) {
+	if err := d.check(value); err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// check requires every line to be blank, a comment, a section or a key
+// with a value
+func (iniDecoder) check(value []byte) error {
+	for i, line := range strings.Split(string(value), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' || iniSection.MatchString(trimmed) {
+			continue
+		}
+		if strings.IndexAny(trimmed, "=:") <= 0 {
+			return fmt.Errorf("line %d: expected a section, a comment or key = value", i+1)
+		}
+	}
+	return nil
+}
+
+func (iniDecoder) Tokens(text string) []Token {
+	var tl tokenList
+	forEachLine(text, &tl, func(line string) {
+		indent, rest := splitIndent(line)
+		tl.add(TokenText, indent)
+
+		switch {
+		case strings.HasPrefix(rest, ";") || strings.HasPrefix(rest, "#"):
+			tl.add(TokenComment, rest)
+		case strings.HasPrefix(rest, "["):
+			tl.add(TokenSection, rest)
+		default:
+			if i := strings.IndexAny(rest, "=:"); i > 0 {
+				tl.keyValue(rest, i, true)
+				return
+			}
+			tl.add(TokenText, rest)
+		}
+	})
+	return tl
+}
+
+// envLine matches an assignment of an env file
+var envLine = regexp.MustCompile(`^(export\s+)?[A-Za-z_][A-Za-z0-9_]*=`)
+
+func (envDecoder) Name() string         { return "env" }
+func (envDecoder) Extensions() []string { return []string{".env"} }
+
+func (d envDecoder) Detect(value []byte) bool {
+	return bytes.ContainsRune(value, '=') && d.check(value) == nil
+}
+
+func (d envDecoder) Pretty(value []byte) (string, error) {
+	if err := d.check(value); err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// check requires every line to be blank, a comment or NAME=value
+func (envDecoder) check(value []byte) error {
+	for i, line := range strings.Split(string(value), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || envLine.MatchString(trimmed) {
+			continue
+		}
+		return fmt.Errorf("line %d: expected NAME=value", i+1)
+	}
+	return nil
+}
+
+func (envDecoder) Tokens(text string) []Token {
+	var tl tokenList
+	forEachLine(text, &tl, func(line string) {
+		indent, rest := splitIndent(line)
+		tl.add(TokenText, indent)
+
+		if strings.HasPrefix(rest, "#") {
+			tl.add(TokenComment, rest)
+			return
+		}
+		if strings.HasPrefix(rest, "export ") {
+			tl.add(TokenKeyword, "export")
+			var space string
+			space, rest = splitIndent(rest[len("export"):])
+			tl.add(TokenText, space)
+		}
+		if i := strings.IndexByte(rest, '='); i > 0 {
+			tl.keyValue(rest, i, true)
+			return
+		}
+		tl.add(TokenText, rest)
+	})
+	return tl
+}
+
+// tokenList collects tokens, merging neighbours of the same kind
+type tokenList []Token
+
+// add appends text as a token of kind
+func (tl *tokenList) add(kind TokenKind, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(*tl); n > 0 && (*tl)[n-1].Kind == kind {
+		(*tl)[n-1].Text += text
+		return
+	}
+	*tl = append(*tl, Token{Kind: kind, Text: text})
+}
+
+// addAll appends tokens, merging them like add
+func (tl *tokenList) addAll(tokens []Token) {
+	for _, t := range tokens {
+		tl.add(t.Kind, t.Text)
+	}
+}
+
+// keyValue adds a key = value line split at sep. Plain values are strings
+// for INI and env files and lexed as TOML values otherwise.
+func (tl *tokenList) keyValue(line string, sep int, plain bool) {
+	key := strings.TrimRight(line[:sep], " \t")
+	tl.add(TokenKey, key)
+	tl.add(TokenText, line[len(key):sep])
+	tl.add(TokenPunct, line[sep:sep+1])
+
+	space, value := splitIndent(line[sep+1:])
+	tl.add(TokenText, space)
+	if !plain {
+		tl.addAll(lexValue(value, false, true))
+		return
+	}
+
+	trimmed := strings.TrimRight(value, " \t\r")
+	switch {
+	case isNumber(trimmed):
+		tl.add(TokenNumber, trimmed)
+	case isYAMLKeyword(trimmed):
+		tl.add(TokenKeyword, trimmed)
+	default:
+		tl.add(TokenString, trimmed)
+	}
+	tl.add(TokenText, value[len(trimmed):])
+}
+
+// forEachLine calls fn for every line of text, adding the line breaks
+// between them
+func forEachLine(text string, tl *tokenList, fn func(line string)) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			tl.add(TokenText, "\n")
+		}
+		fn(line)
+	}
+}
+
+// lexValue splits JSON-like text into strings, numbers, keywords and
+// punctuation. Strings followed by a colon are keys if keys is set; #
+// starts a comment up to the end of the line if comments is set.
+func lexValue(text string, keys, comments bool) []Token {
+	var tl tokenList
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"' || c == '\'':
+			end := scanQuoted(text, i, c == '"')
+			kind := TokenString
+			if keys && strings.HasPrefix(strings.TrimLeft(text[end:], " \t\r\n"), ":") {
+				kind = TokenKey
+			}
+			tl.add(kind, text[i:end])
+			i = end
+		case c == '#' && comments:
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			tl.add(TokenComment, text[i:i+end])
+			i += end
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9':
+			end := i + 1
+			for end < len(text) && strings.IndexByte("0123456789.eE+-_:TZ", text[end]) >= 0 {
+				end++
+			}
+			tl.add(TokenNumber, text[i:end])
+			i = end
+		case isWordByte(c):
+			end := i + 1
+			for end < len(text) && isWordByte(text[end]) {
+				end++
+			}
+			word := text[i:end]
+			if word == "true" || word == "false" || word == "null" {
+				tl.add(TokenKeyword, word)
+			} else {
+				tl.add(TokenText, word)
+			}
+			i = end
+		case strings.IndexByte("{}[],:=", c) >= 0:
+			tl.add(TokenPunct, text[i:i+1])
+			i++
+		default:
+			tl.add(TokenText, text[i:i+1])
+			i++
+		}
+	}
+	return tl
+}
+
+// scanQuoted returns the position after the string starting with a quote
+// at text[i], stopping at the end of the line if it is not closed
+func scanQuoted(text string, i int, escapes bool) int {
+	quote := text[i]
+	for j := i + 1; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			if escapes {
+				j++
+			}
+		case quote:
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(text)
+}
+
+// splitIndent splits leading blanks from s
+func splitIndent(s string) (string, string) {
+	rest := strings.TrimLeft(s, " \t")
+	return s[:len(s)-len(rest)], rest
+}
+
+// numberPattern matches integers and decimals, as in JSON, YAML and TOML
+var numberPattern = regexp.MustCompile(`^[-+]?(0x[0-9a-fA-F_]+|[0-9][0-9_]*(\.[0-9_]+)?([eE][-+]?[0-9]+)?)$`)
+
+func isNumber(s string) bool {
+	return numberPattern.MatchString(s)
+}
+
+func isYAMLKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "null", "~", "yes", "no", "on", "off":
+		return true
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isXMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// xmlName formats a name with its prefix as written
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// xmlError adds the line to XML syntax errors
+func xmlError(err error) error {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("line %d: %s", syntaxErr.Line, syntaxErr.Msg)
+	}
+	return err
+}
+
+var (
+	xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownUser is returned when checking the access of a user that does
+// not exist
+var ErrUnknownUser = errors.New("unknown user")
+
+// Grant is a permission of one of a user's roles that covers some of the
+// keys in a checked range
+type Grant struct {
+	Role string
+
+	// Permission is nil for the root role, which is granted everything
+	Permission *Permission
+}
+
+// String returns the role and the permission it grants
+func (g *Grant) String() string {
+	if g.Permission == nil {
+		return fmt.Sprintf("role %s: all keys, all operations", g.Role)
+	}
+	return fmt.Sprintf("role %s: %s %s", g.Role, g.Permission.PermType, g.Permission.Range())
+}
+
+// AccessCheck is the answer to whether a user may read and write a key or
+// range of keys, with the grants it is based on
+type AccessCheck struct {
+	User     string
+	Key      string
+	RangeEnd string
+
+	// Access is what the user may do with the whole range
+	Access Access
+
+	// ReadGrants and WriteGrants are the permissions that allow reading or
+	// writing at least part of the range
+	ReadGrants  []*Grant
+	WriteGrants []*Grant
+
+	// MissingRoles are roles granted to the user that do not exist
+	MissingRoles []string
+}
+
+// Range describes the checked keys as Permission.Range does
+func (c *AccessCheck) Range() string {
+	return (&Permission{Key: c.Key, RangeEnd: c.RangeEnd}).Range()
+}
+
+// Evaluator answers whether users may read or write keys from a set of
+// users and roles, without asking the cluster. It applies etcd's rules:
+// the root role may do everything, other roles add up their key ranges, and
+// a range is allowed only if every key in it is covered.
+type Evaluator struct {
+	users map[string]*User
+	roles map[string]*Role
+}
+
+// NewEvaluator creates an evaluator for the given users and roles
+func NewEvaluator(users []*User, roles []*Role) *Evaluator {
+	e := &Evaluator{
+		users: make(map[string]*User, len(users)),
+		roles: make(map[string]*Role, len(roles)),
+	}
+	for _, u := range users {
+		e.users[u.Name] = u
+	}
+	for _, r := range roles {
+		e.roles[r.Name] = r
+	}
+	return e
+}
+
+// LoadEvaluator reads all users and roles from the cluster into an
+// evaluator. Only users allowed to read other users and roles, usually
+// root, can load one.
+func (c *Client) LoadEvaluator(ctx context.Context) (*Evaluator, error) {
+	users, err := c.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := c.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewEvaluator(users, roles), nil
+}
+
+// Check returns what user may do with the keys from key up to rangeEnd
+// (exclusive). An empty rangeEnd means the single key, "\x00" all keys from
+// key on, as in etcd permissions.
+func (e *Evaluator) Check(user, key, rangeEnd string) (*AccessCheck, error) {
+	u, ok := e.users[user]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownUser, user)
+	}
+
+	check := &AccessCheck{User: user, Key: key, RangeEnd: rangeEnd}
+	target := newKeyRange(key, rangeEnd)
+
+	var read, write keyRanges
+	for _, name := range u.Roles {
+		if name == "root" {
+			root := &Grant{Role: name}
+			check.ReadGrants = []*Grant{root}
+			check.WriteGrants = []*Grant{root}
+			check.Access = Access{}
+			return check, nil
+		}
+
+		role, ok := e.roles[name]
+		if !ok {
+			check.MissingRoles = append(check.MissingRoles, name)
+			continue
+		}
+
+		for _, p := range role.Permissions {
+			r := newKeyRange(p.Key, p.RangeEnd)
+			if len(keyRanges{r}.merge().clip(target)) == 0 {
+				continue
+			}
+			grant := &Grant{Role: name, Permission: p}
+			if p.PermType == PermissionRead || p.PermType == PermissionReadWrite {
+				check.ReadGrants = append(check.ReadGrants, grant)
+				read = append(read, r)
+			}
+			if p.PermType == PermissionWrite || p.PermType == PermissionReadWrite {
+				check.WriteGrants = append(check.WriteGrants, grant)
+				write = append(write, r)
+			}
+		}
+	}
+
+	check.Access = Access{
+		Read:  read.merge().coverage(target),
+		Write: write.merge().coverage(target),
+	}
+	return check, nil
+}
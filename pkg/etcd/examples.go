@@ -21,7 +21,7 @@ Example 1: Basic CRUD operations
 
 	// Get a key
 	kv, err := client.Get(ctx, "/config/app")
-	fmt.Println(kv.Value) // "value1"
+	fmt.Println(string(kv.Value)) // "value1"
 
 	// List keys with prefix
 	kvs, err := client.List(ctx, "/config/")
@@ -29,6 +29,11 @@ Example 1: Basic CRUD operations
 		fmt.Printf("%s = %s\n", kv.Key, kv.Value)
 	}
 
+	// Values are raw bytes; check before printing binary payloads
+	if kv.IsBinary() {
+		fmt.Println(hex.Dump(kv.Value))
+	}
+
 	// Delete a key
 	err = client.Delete(ctx, "/config/app")
 
@@ -122,6 +127,17 @@ Example 5: Transactions
 		fmt.Println("Config initialized")
 	}
 
+	// Multi-key update guarded by the current revision
+	kv, _ := client.Get(ctx, "/config/version")
+	res, err := client.NewTxn().
+		If(CompareModRevision("/config/version", CompareEqual, kv.ModRevision)).
+		Then(OpPut("/config/version", "1.2"), OpDeletePrefix("/config/cache/")).
+		Else(OpGet("/config/version")).
+		Commit(ctx)
+	if err == nil && !res.Succeeded {
+		fmt.Printf("Changed concurrently: %s\n", res.Results[0].KVs[0].Value)
+	}
+
 Example 6: Cluster Status
 
 	client, _ := New(DefaultConfig())
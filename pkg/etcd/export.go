@@ -0,0 +1,269 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ExportFormat is the file format of an export
+type ExportFormat string
+
+const (
+	// ExportJSON writes a JSON object of keys and values
+	ExportJSON ExportFormat = "json"
+	// ExportYAML writes a YAML mapping of keys and values
+	ExportYAML ExportFormat = "yaml"
+	// ExportDump writes one etcdctl style JSON record per key, with
+	// revisions, lease and base64 encoded key and value
+	ExportDump ExportFormat = "dump"
+)
+
+// ExportFormats lists the supported export formats
+var ExportFormats = []ExportFormat{ExportJSON, ExportYAML, ExportDump}
+
+// ParseExportFormat returns the export format with the given name
+func ParseExportFormat(name string) (ExportFormat, error) {
+	for _, f := range ExportFormats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q, expected json, yaml or dump", name)
+}
+
+// Base64ValuePrefix marks values in JSON and YAML exports that are base64
+// encoded because they are not printable text
+const Base64ValuePrefix = "base64:"
+
+// NestedValueKey holds the value of a key that also has keys below it in
+// nested exports
+const NestedValueKey = "."
+
+// ExportOptions configures an export
+type ExportOptions struct {
+	// Prefix selects the keys to export. Keys are written relative to it,
+	// except in dumps, which keep full keys.
+	Prefix string
+
+	// Format is the file format, ExportJSON by default
+	Format ExportFormat
+
+	// Nested writes JSON and YAML as objects nested by path segment
+	// instead of a flat key to value mapping
+	Nested bool
+
+	// Revision reads the keys at a revision. When zero, the current
+	// revision is used; all keys are read at the same revision either way.
+	Revision int64
+}
+
+// ExportResult describes a finished export
+type ExportResult struct {
+	// Keys is the number of keys written
+	Keys int
+
+	// Revision is the store revision the keys were read at
+	Revision int64
+}
+
+// DumpHeader is the first line of a dump
+type DumpHeader struct {
+	Prefix   []byte `json:"prefix"`
+	Revision int64  `json:"revision"`
+	Count    int    `json:"count"`
+}
+
+// DumpRecord is a key in a dump, with the fields etcdctl uses for keys in
+// its JSON output
+type DumpRecord struct {
+	Key            []byte `json:"key"`
+	CreateRevision int64  `json:"create_revision"`
+	ModRevision    int64  `json:"mod_revision"`
+	Version        int64  `json:"version"`
+	Value          []byte `json:"value"`
+	Lease          int64  `json:"lease,omitempty"`
+}
+
+// Export reads the keys with opts.Prefix page by page at a single revision
+// and writes them to w. progress is called after every page.
+func (c *Client) Export(ctx context.Context, w io.Writer, opts ExportOptions, progress ListProgressFunc) (*ExportResult, error) {
+	kvs, revision, err := c.ListAll(ctx, ListOptions{Prefix: opts.Prefix, Revision: opts.Revision}, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := WriteExport(w, kvs, revision, opts); err != nil {
+		return nil, err
+	}
+	return &ExportResult{Keys: len(kvs), Revision: revision}, nil
+}
+
+// ExportFile exports into path. The data is written to a temporary file in
+// the same directory and renamed, so path is either the complete export or
+// left untouched.
+func (c *Client) ExportFile(ctx context.Context, path string, opts ExportOptions, progress ListProgressFunc) (*ExportResult, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".part-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary export file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Remove the temporary file on any failure below
+	success := false
+	defer func() {
+		if !success {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	result, err := c.Export(ctx, tmp, opts, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close export file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("failed to move export into place: %w", err)
+	}
+	success = true
+
+	return result, nil
+}
+
+// WriteExport writes kvs read at revision in the format selected by opts.
+// All keys must start with opts.Prefix.
+func WriteExport(w io.Writer, kvs []*KeyValue, revision int64, opts ExportOptions) error {
+	switch opts.Format {
+	case ExportDump:
+		return writeDump(w, kvs, revision, opts.Prefix)
+	case ExportJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(exportTree(kvs, opts)); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		return nil
+	case ExportYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(exportTree(kvs, opts)); err != nil {
+			return fmt.Errorf("failed to write YAML: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("failed to write YAML: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown export format %q", opts.Format)
+	}
+}
+
+// EncodeValue returns a value as it is written to JSON and YAML exports:
+// printable text as is, anything else base64 encoded after
+// Base64ValuePrefix. Text that starts with the prefix is encoded as well,
+// so it is not mistaken for an encoded value.
+func EncodeValue(value []byte) string {
+	if IsPrintable(value) && !strings.HasPrefix(string(value), Base64ValuePrefix) {
+		return string(value)
+	}
+	return Base64ValuePrefix + base64.StdEncoding.EncodeToString(value)
+}
+
+// DecodeValue reverses EncodeValue
+func DecodeValue(s string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(s, Base64ValuePrefix)
+	if !ok {
+		return []byte(s), nil
+	}
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 value: %w", err)
+	}
+	return value, nil
+}
+
+// exportTree returns the keys as a flat map from relative key to value, or
+// nested by path segment
+func exportTree(kvs []*KeyValue, opts ExportOptions) map[string]any {
+	root := make(map[string]any)
+	for _, kv := range kvs {
+		rel := strings.TrimPrefix(kv.Key, opts.Prefix)
+		value := EncodeValue(kv.Value)
+
+		if !opts.Nested {
+			root[rel] = value
+			continue
+		}
+		if rel == "" {
+			root[NestedValueKey] = value
+			continue
+		}
+
+		node := root
+		segments := strings.Split(rel, KeySeparator)
+		for _, segment := range segments[:len(segments)-1] {
+			switch child := node[segment].(type) {
+			case map[string]any:
+				node = child
+			case string:
+				// A key that also has keys below it
+				next := map[string]any{NestedValueKey: child}
+				node[segment] = next
+				node = next
+			default:
+				next := make(map[string]any)
+				node[segment] = next
+				node = next
+			}
+		}
+
+		last := segments[len(segments)-1]
+		if child, ok := node[last].(map[string]any); ok {
+			child[NestedValueKey] = value
+		} else {
+			node[last] = value
+		}
+	}
+	return root
+}
+
+// writeDump writes a header line followed by one record per key
+func writeDump(w io.Writer, kvs []*KeyValue, revision int64, prefix string) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	if err := enc.Encode(&DumpHeader{Prefix: []byte(prefix), Revision: revision, Count: len(kvs)}); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+	for _, kv := range kvs {
+		record := &DumpRecord{
+			Key:            []byte(kv.Key),
+			CreateRevision: kv.CreateRevision,
+			ModRevision:    kv.ModRevision,
+			Version:        kv.Version,
+			Value:          kv.Value,
+			Lease:          kv.Lease,
+		}
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write dump: %w", err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultHistoryLimit is the number of versions read by History when no
+// limit is given
+const DefaultHistoryLimit = 100
+
+// KeyHistory holds previous versions of a key, newest first
+type KeyHistory struct {
+	Key string
+
+	// Versions holds the current version followed by older ones
+	Versions []*KeyValue
+
+	// Compacted is true if older versions exist but were removed by a
+	// compaction
+	Compacted bool
+
+	// Truncated is true if the walk stopped at the limit
+	Truncated bool
+}
+
+// Complete returns true if every version since the key was created is
+// included
+func (h *KeyHistory) Complete() bool {
+	return !h.Compacted && !h.Truncated
+}
+
+// History walks back through the versions of key, starting at its current
+// ModRevision and reading the revision before each version until the key's
+// CreateRevision, the compaction boundary or limit versions are reached.
+func (c *Client) History(ctx context.Context, key string, limit int) (*KeyHistory, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+
+	current, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &KeyHistory{
+		Key:      key,
+		Versions: []*KeyValue{current},
+	}
+
+	kv := current
+	for kv.ModRevision > kv.CreateRevision {
+		if len(history.Versions) >= limit {
+			history.Truncated = true
+			break
+		}
+
+		prev, err := c.getAt(ctx, key, kv.ModRevision-1)
+		if errors.Is(err, rpctypes.ErrCompacted) {
+			history.Compacted = true
+			break
+		}
+		if err != nil {
+			return history, err
+		}
+		if prev == nil {
+			// Should not happen between create and mod revision
+			break
+		}
+
+		history.Versions = append(history.Versions, prev)
+		kv = prev
+	}
+
+	return history, nil
+}
+
+// getAt reads key at a revision and returns nil if it did not exist
+func (c *Client) getAt(ctx context.Context, key string, revision int64) (*KeyValue, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.Get(ctx, key, clientv3.WithRev(revision))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key %s at revision %d: %w", key, revision, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	return newKeyValue(resp.Kvs[0]), nil
+}
+
+// RestoreVersion writes the value of an older version back to its key,
+// keeping the lease of the current version. The write only happens if the
+// key is still at the ModRevision of current; otherwise false is returned
+// and nothing is changed.
+func (c *Client) RestoreVersion(ctx context.Context, version, current *KeyValue) (bool, error) {
+	res, err := c.NewTxn().
+		If(CompareModRevision(version.Key, CompareEqual, current.ModRevision)).
+		Then(OpPutWithLease(version.Key, string(version.Value), current.Lease)).
+		Commit(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore %s to revision %d: %w", version.Key, version.ModRevision, err)
+	}
+	return res.Succeeded, nil
+}
@@ -0,0 +1,519 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ErrImportConflict is returned when keys changed between planning an import
+// and applying it
+var ErrImportConflict = errors.New("keys changed since the import was planned")
+
+// ImportEntry is a key read from an import file
+type ImportEntry struct {
+	// Key is relative to the prefix the data was exported from
+	Key   string
+	Value []byte
+}
+
+// DetectImportFormat returns the format of an export file from its extension
+func DetectImportFormat(path string) (ExportFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ExportJSON, nil
+	case ".yaml", ".yml":
+		return ExportYAML, nil
+	case ".jsonl", ".dump":
+		return ExportDump, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s from its extension, expected .json, .yaml or .jsonl", path)
+}
+
+// ReadImportFile reads the entries of an export file. An empty format is
+// detected from the file extension.
+func ReadImportFile(path string, format ExportFormat) ([]*ImportEntry, error) {
+	if format == "" {
+		var err error
+		if format, err = DetectImportFormat(path); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return ReadImport(f, format)
+}
+
+// ReadImport reads the entries of an export in format. JSON and YAML may be
+// flat or nested by path segment, in the layout written by WriteExport.
+// Entries are returned sorted by key.
+func ReadImport(r io.Reader, format ExportFormat) ([]*ImportEntry, error) {
+	var (
+		entries []*ImportEntry
+		err     error
+	)
+
+	switch format {
+	case ExportDump:
+		entries, err = readDump(r)
+	case ExportJSON, "":
+		var root map[string]any
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		if err := dec.Decode(&root); err != nil {
+			return nil, fmt.Errorf("failed to read JSON: %w", err)
+		}
+		entries, err = importTree(root)
+	case ExportYAML:
+		var root map[string]any
+		if err := yaml.NewDecoder(r).Decode(&root); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read YAML: %w", err)
+		}
+		entries, err = importTree(root)
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// importTree flattens a flat or nested mapping into entries
+func importTree(root map[string]any) ([]*ImportEntry, error) {
+	var entries []*ImportEntry
+	seen := make(map[string]bool)
+
+	var walk func(node map[string]any, path string) error
+	walk = func(node map[string]any, path string) error {
+		for name, v := range node {
+			if child, ok := asMapping(v); ok {
+				if err := walk(child, path+name+KeySeparator); err != nil {
+					return err
+				}
+				continue
+			}
+
+			key := path + name
+			if name == NestedValueKey {
+				// The value of the key the nested keys are below
+				key = strings.TrimSuffix(path, KeySeparator)
+			}
+
+			text, err := scalarText(v)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", key, err)
+			}
+			value, err := DecodeValue(text)
+			if err != nil {
+				return fmt.Errorf("key %q: %w", key, err)
+			}
+			if seen[key] {
+				return fmt.Errorf("key %q appears more than once", key)
+			}
+			seen[key] = true
+			entries = append(entries, &ImportEntry{Key: key, Value: value})
+		}
+		return nil
+	}
+
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// asMapping returns v as a mapping if it is one. YAML mappings with keys
+// that are not strings are converted.
+func asMapping(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[fmt.Sprint(k)] = v
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// scalarText returns the text of a scalar value. Numbers and booleans
+// become their literal text and null an empty value.
+func scalarText(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	case []any:
+		return "", fmt.Errorf("lists are not supported, values must be strings")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// readDump reads a header line followed by one record per key. Keys are
+// made relative to the prefix in the header.
+func readDump(r io.Reader) ([]*ImportEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	var (
+		header  *DumpHeader
+		entries []*ImportEntry
+		line    int
+	)
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		if header == nil {
+			header = &DumpHeader{}
+			if err := json.Unmarshal(data, header); err != nil {
+				return nil, fmt.Errorf("line %d: invalid dump header: %w", line, err)
+			}
+			continue
+		}
+
+		var record DumpRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("line %d: invalid dump record: %w", line, err)
+		}
+		key, ok := strings.CutPrefix(string(record.Key), string(header.Prefix))
+		if !ok {
+			return nil, fmt.Errorf("line %d: key %q is outside the dump prefix %q", line, record.Key, header.Prefix)
+		}
+		entries = append(entries, &ImportEntry{Key: key, Value: record.Value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dump: %w", err)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("dump is empty")
+	}
+	if len(entries) != header.Count {
+		return nil, fmt.Errorf("dump is incomplete: %d of %d keys", len(entries), header.Count)
+	}
+	return entries, nil
+}
+
+// ImportAction is what an import does with a key
+type ImportAction int
+
+const (
+	ImportCreate ImportAction = iota
+	ImportUpdate
+	ImportDelete
+	ImportUnchanged
+)
+
+// String returns the name of the action
+func (a ImportAction) String() string {
+	switch a {
+	case ImportCreate:
+		return "create"
+	case ImportUpdate:
+		return "update"
+	case ImportDelete:
+		return "delete"
+	case ImportUnchanged:
+		return "unchanged"
+	default:
+		return "unknown"
+	}
+}
+
+// ImportOptions configures an import
+type ImportOptions struct {
+	// Prefix is the destination; imported keys are stored below it
+	Prefix string
+
+	// Delete removes keys with Prefix that are not in the imported data,
+	// so the prefix ends up with exactly the imported keys
+	Delete bool
+
+	// MaxTxnOps is the most keys written per transaction,
+	// DefaultMaxTxnOps when zero
+	MaxTxnOps int
+
+	// Validator checks the values of created and updated keys before
+	// anything is written; nil skips validation
+	Validator *Validator
+}
+
+// ImportChange is the planned change of a single key
+type ImportChange struct {
+	Action ImportAction
+
+	// Key is the full destination key
+	Key string
+
+	// Value is the imported value, nil for deletes
+	Value []byte
+
+	// Current is the key as it was when planning, nil for creates
+	Current *KeyValue
+}
+
+// ImportPlan lists what an import changes, compared to the keys under the
+// destination prefix at Revision
+type ImportPlan struct {
+	Prefix   string
+	Revision int64
+
+	// Changes holds one entry per key, unchanged ones included, sorted by
+	// key
+	Changes []*ImportChange
+}
+
+// Count returns the number of keys with the given action
+func (p *ImportPlan) Count(action ImportAction) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Pending returns the changes that write to the store
+func (p *ImportPlan) Pending() []*ImportChange {
+	var out []*ImportChange
+	for _, c := range p.Changes {
+		if c.Action != ImportUnchanged {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Invalid returns the validation errors of the values the plan writes
+func (p *ImportPlan) Invalid(v *Validator) []*ValidationError {
+	var out []*ValidationError
+	for _, c := range p.Pending() {
+		if c.Action == ImportDelete {
+			continue
+		}
+		var validationErr *ValidationError
+		if errors.As(v.Validate(c.Key, c.Value), &validationErr) {
+			out = append(out, validationErr)
+		}
+	}
+	return out
+}
+
+// Refused returns why g refuses the changes of the plan it does not allow
+func (p *ImportPlan) Refused(g *Guard, confirmed bool) []*ProtectionError {
+	var out []*ProtectionError
+	for _, c := range p.Pending() {
+		err := g.CheckPut(c.Key, confirmed)
+		if c.Action == ImportDelete {
+			err = g.CheckDelete(c.Key, confirmed)
+		}
+		var protectionErr *ProtectionError
+		if errors.As(err, &protectionErr) {
+			out = append(out, protectionErr)
+		}
+	}
+	return out
+}
+
+// Summary describes the plan in one line
+func (p *ImportPlan) Summary() string {
+	return fmt.Sprintf("%d to create, %d to update, %d to delete, %d unchanged",
+		p.Count(ImportCreate), p.Count(ImportUpdate), p.Count(ImportDelete), p.Count(ImportUnchanged))
+}
+
+// PlanImport reads the keys under opts.Prefix at a single revision and
+// compares them with entries
+func (c *Client) PlanImport(ctx context.Context, entries []*ImportEntry, opts ImportOptions, progress ListProgressFunc) (*ImportPlan, error) {
+	current, revision, err := c.ListAll(ctx, ListOptions{Prefix: opts.Prefix}, progress)
+	if err != nil {
+		return nil, err
+	}
+	return BuildImportPlan(current, revision, entries, opts), nil
+}
+
+// BuildImportPlan compares entries with the current keys under opts.Prefix
+// read at revision
+func BuildImportPlan(current []*KeyValue, revision int64, entries []*ImportEntry, opts ImportOptions) *ImportPlan {
+	plan := &ImportPlan{Prefix: opts.Prefix, Revision: revision}
+
+	existing := make(map[string]*KeyValue, len(current))
+	for _, kv := range current {
+		existing[kv.Key] = kv
+	}
+
+	imported := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		key := opts.Prefix + e.Key
+		imported[key] = true
+
+		change := &ImportChange{Action: ImportCreate, Key: key, Value: e.Value}
+		if kv, ok := existing[key]; ok {
+			change.Current = kv
+			change.Action = ImportUpdate
+			if bytes.Equal(kv.Value, e.Value) {
+				change.Action = ImportUnchanged
+			}
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	if opts.Delete {
+		for _, kv := range current {
+			if !imported[kv.Key] {
+				plan.Changes = append(plan.Changes, &ImportChange{Action: ImportDelete, Key: kv.Key, Current: kv})
+			}
+		}
+	}
+
+	sort.Slice(plan.Changes, func(i, j int) bool { return plan.Changes[i].Key < plan.Changes[j].Key })
+	return plan
+}
+
+// ImportResult describes an applied import
+type ImportResult struct {
+	// Applied is the number of keys written or deleted
+	Applied int
+
+	// Txns is the number of committed transactions
+	Txns int
+
+	// Revision is the store revision after the last transaction
+	Revision int64
+
+	// Conflicts lists the keys of the rejected batch that changed since
+	// planning
+	Conflicts []string
+}
+
+// ImportProgressFunc is called after each transaction of an import with
+// the number of keys applied so far and in total
+type ImportProgressFunc func(applied, total int)
+
+// ApplyImport writes the pending changes of plan in transactions of at most
+// opts.MaxTxnOps keys. Every key is compared against the state it had when
+// planning: a missing key must still be missing and an existing one must
+// have its planned mod revision. If a key changed, its transaction is
+// rejected and ErrImportConflict returned together with the result, which
+// then lists the changed keys; earlier transactions stay applied. If
+// opts.Validator rejects a value, nothing is written and the error matches
+// ErrInvalidValue; the same goes for changes the client's guard refuses,
+// matching ErrReadOnly, ErrProtected or ErrNotConfirmed.
+func (c *Client) ApplyImport(ctx context.Context, plan *ImportPlan, opts ImportOptions, progress ImportProgressFunc) (*ImportResult, error) {
+	batchSize := opts.MaxTxnOps
+	if batchSize <= 0 {
+		batchSize = DefaultMaxTxnOps
+	}
+
+	pending := plan.Pending()
+	result := &ImportResult{Revision: plan.Revision}
+
+	if refused := plan.Refused(c.Guard(), confirmed(ctx)); len(refused) > 0 {
+		errs := make([]error, len(refused))
+		for i, err := range refused {
+			errs[i] = err
+		}
+		return result, fmt.Errorf("%d of %d changes refused, nothing applied:\n%w", len(refused), len(pending), errors.Join(errs...))
+	}
+
+	if invalid := plan.Invalid(opts.Validator); len(invalid) > 0 {
+		errs := make([]error, len(invalid))
+		for i, err := range invalid {
+			errs[i] = err
+		}
+		return result, fmt.Errorf("%d of %d values failed validation, nothing applied:\n%w", len(invalid), len(pending), errors.Join(errs...))
+	}
+
+	for start := 0; start < len(pending); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		batch := pending[start:min(start+batchSize, len(pending))]
+
+		txn := c.NewTxn()
+		for _, change := range batch {
+			switch change.Action {
+			case ImportCreate:
+				txn.If(CompareVersion(change.Key, CompareEqual, 0)).
+					Then(OpPut(change.Key, string(change.Value)))
+			case ImportUpdate:
+				// Keep the lease of the key being replaced
+				txn.If(CompareModRevision(change.Key, CompareEqual, change.Current.ModRevision)).
+					Then(OpPutWithLease(change.Key, string(change.Value), change.Current.Lease))
+			case ImportDelete:
+				txn.If(CompareModRevision(change.Key, CompareEqual, change.Current.ModRevision)).
+					Then(OpDelete(change.Key))
+			}
+			// Read the key back if the transaction is rejected to tell
+			// which keys changed
+			txn.Else(OpGet(change.Key))
+		}
+
+		resp, err := txn.Commit(ctx)
+		if err != nil {
+			return result, err
+		}
+		if !resp.Succeeded {
+			result.Conflicts = importConflicts(batch, resp.Results)
+			return result, fmt.Errorf("%w: %s (%d of %d keys applied)",
+				ErrImportConflict, strings.Join(result.Conflicts, ", "), result.Applied, len(pending))
+		}
+
+		result.Applied += len(batch)
+		result.Txns++
+		result.Revision = resp.Revision
+		if progress != nil {
+			progress(result.Applied, len(pending))
+		}
+	}
+
+	return result, nil
+}
+
+// importConflicts returns the keys of batch whose state differs from the
+// plan, given the results of reading them back
+func importConflicts(batch []*ImportChange, results []*OpResult) []string {
+	var keys []string
+	for i, change := range batch {
+		if i >= len(results) {
+			break
+		}
+		var modRevision int64
+		if kvs := results[i].KVs; len(kvs) > 0 {
+			modRevision = kvs[0].ModRevision
+		}
+		var planned int64
+		if change.Current != nil {
+			planned = change.Current.ModRevision
+		}
+		if modRevision != planned {
+			keys = append(keys, change.Key)
+		}
+	}
+	return keys
+}
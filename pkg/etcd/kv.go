@@ -2,21 +2,38 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+// ErrKeyNotFound is returned when a requested key does not exist
+var ErrKeyNotFound = errors.New("key not found")
+
 // KeyValue represents a key-value pair with metadata
 type KeyValue struct {
 	Key            string
-	Value          string
+	Value          []byte
 	CreateRevision int64
 	ModRevision    int64
 	Version        int64
 	Lease          int64
 }
 
+// newKeyValue converts an etcd key-value pair to KeyValue
+func newKeyValue(kv *mvccpb.KeyValue) *KeyValue {
+	return &KeyValue{
+		Key:            string(kv.Key),
+		Value:          kv.Value,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Version:        kv.Version,
+		Lease:          kv.Lease,
+	}
+}
+
 // Get retrieves a single key from etcd
 func (c *Client) Get(ctx context.Context, key string) (*KeyValue, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
@@ -28,22 +45,19 @@ func (c *Client) Get(ctx context.Context, key string) (*KeyValue, error) {
 	}
 
 	if len(resp.Kvs) == 0 {
-		return nil, fmt.Errorf("key not found: %s", key)
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 	}
 
 	kv := resp.Kvs[0]
-	return &KeyValue{
-		Key:            string(kv.Key),
-		Value:          string(kv.Value),
-		CreateRevision: kv.CreateRevision,
-		ModRevision:    kv.ModRevision,
-		Version:        kv.Version,
-		Lease:          int64(kv.Lease),
-	}, nil
+	return newKeyValue(kv), nil
 }
 
 // Put stores a key-value pair in etcd
 func (c *Client) Put(ctx context.Context, key, value string) error {
+	if err := c.checkPut(ctx, key); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -56,6 +70,10 @@ func (c *Client) Put(ctx context.Context, key, value string) error {
 
 // Delete removes a key from etcd
 func (c *Client) Delete(ctx context.Context, key string) error {
+	if err := c.checkDelete(ctx, key); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -66,7 +84,8 @@ func (c *Client) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
-// List retrieves all keys with the given prefix
+// List retrieves all keys with the given prefix in a single request.
+// Use NewListIterator or ListAll for large keyspaces.
 func (c *Client) List(ctx context.Context, prefix string) ([]*KeyValue, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
@@ -78,14 +97,7 @@ func (c *Client) List(ctx context.Context, prefix string) ([]*KeyValue, error) {
 
 	kvs := make([]*KeyValue, 0, len(resp.Kvs))
 	for _, kv := range resp.Kvs {
-		kvs = append(kvs, &KeyValue{
-			Key:            string(kv.Key),
-			Value:          string(kv.Value),
-			CreateRevision: kv.CreateRevision,
-			ModRevision:    kv.ModRevision,
-			Version:        kv.Version,
-			Lease:          int64(kv.Lease),
-		})
+		kvs = append(kvs, newKeyValue(kv))
 	}
 
 	return kvs, nil
@@ -102,22 +114,19 @@ func (c *Client) GetWithRevision(ctx context.Context, key string, revision int64
 	}
 
 	if len(resp.Kvs) == 0 {
-		return nil, fmt.Errorf("key not found at revision %d: %s", revision, key)
+		return nil, fmt.Errorf("%w at revision %d: %s", ErrKeyNotFound, revision, key)
 	}
 
 	kv := resp.Kvs[0]
-	return &KeyValue{
-		Key:            string(kv.Key),
-		Value:          string(kv.Value),
-		CreateRevision: kv.CreateRevision,
-		ModRevision:    kv.ModRevision,
-		Version:        kv.Version,
-		Lease:          int64(kv.Lease),
-	}, nil
+	return newKeyValue(kv), nil
 }
 
 // DeletePrefix removes all keys with the given prefix
 func (c *Client) DeletePrefix(ctx context.Context, prefix string) (int64, error) {
+	if err := c.checkDeletePrefix(ctx, prefix); err != nil {
+		return 0, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -3,19 +3,42 @@ package client
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+// leaseLookups is the number of leases ListLeaseInfos looks up at once
+const leaseLookups = 16
+
 // LeaseInfo represents information about a lease
 type LeaseInfo struct {
-	ID  int64
+	ID int64
+
+	// TTL is the remaining time to live in seconds, -1 if the lease expired
 	TTL int64
+
+	// GrantedTTL is the TTL in seconds the lease was granted with
+	GrantedTTL int64
+
+	// Keys holds the keys attached to the lease when requested
+	Keys []string
+}
+
+// IsOrphan returns true if no keys are attached to the lease. Only
+// meaningful when the keys were requested.
+func (l *LeaseInfo) IsOrphan() bool {
+	return len(l.Keys) == 0
 }
 
 // PutWithTTL stores a key-value pair with TTL
 func (c *Client) PutWithTTL(ctx context.Context, key, value string, ttl time.Duration) (*LeaseInfo, error) {
+	if err := c.checkPut(ctx, key); err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -37,8 +60,32 @@ func (c *Client) PutWithTTL(ctx context.Context, key, value string, ttl time.Dur
 	}, nil
 }
 
+// GrantLease creates a lease with the given TTL
+func (c *Client) GrantLease(ctx context.Context, ttl time.Duration) (*LeaseInfo, error) {
+	if err := c.Guard().CheckChange("grant a lease"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.Grant(ctx, int64(ttl.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant lease: %w", err)
+	}
+
+	return &LeaseInfo{
+		ID:         int64(resp.ID),
+		TTL:        resp.TTL,
+		GrantedTTL: resp.TTL,
+	}, nil
+}
+
 // KeepAlive keeps a lease alive by renewing it periodically
 func (c *Client) KeepAlive(ctx context.Context, leaseID int64) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	if err := c.Guard().CheckChange(fmt.Sprintf("keep lease %d alive", leaseID)); err != nil {
+		return nil, err
+	}
 	ch, err := c.client.KeepAlive(ctx, clientv3.LeaseID(leaseID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to keep alive lease %d: %w", leaseID, err)
@@ -46,8 +93,25 @@ func (c *Client) KeepAlive(ctx context.Context, leaseID int64) (<-chan *clientv3
 	return ch, nil
 }
 
-// RevokeLease revokes a lease, deleting all associated keys
+// RevokeLease revokes a lease, deleting all associated keys. With
+// protected prefixes the keys are read first, and the lease is kept if the
+// client's guard refuses deleting any of them.
 func (c *Client) RevokeLease(ctx context.Context, leaseID int64) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("revoke lease %d", leaseID)); err != nil {
+		return err
+	}
+	if c.Guard().Protects() {
+		info, err := c.GetLeaseInfoWithKeys(ctx, leaseID)
+		if err != nil {
+			return err
+		}
+		for _, key := range info.Keys {
+			if err := c.checkDelete(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -69,11 +133,92 @@ func (c *Client) GetLeaseInfo(ctx context.Context, leaseID int64) (*LeaseInfo, e
 	}
 
 	return &LeaseInfo{
-		ID:  int64(resp.ID),
-		TTL: resp.TTL,
+		ID:         int64(resp.ID),
+		TTL:        resp.TTL,
+		GrantedTTL: resp.GrantedTTL,
 	}, nil
 }
 
+// GetLeaseInfoWithKeys retrieves information about a lease including the
+// keys attached to it
+func (c *Client) GetLeaseInfoWithKeys(ctx context.Context, leaseID int64) (*LeaseInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.TimeToLive(ctx, clientv3.LeaseID(leaseID), clientv3.WithAttachedKeys())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease info %d: %w", leaseID, err)
+	}
+
+	info := &LeaseInfo{
+		ID:         int64(resp.ID),
+		TTL:        resp.TTL,
+		GrantedTTL: resp.GrantedTTL,
+		Keys:       make([]string, 0, len(resp.Keys)),
+	}
+	for _, key := range resp.Keys {
+		info.Keys = append(info.Keys, string(key))
+	}
+	sort.Strings(info.Keys)
+	return info, nil
+}
+
+// ListLeaseInfos returns all active leases ordered by ID with their TTLs
+// and attached keys. Leases that expire while being listed are left out.
+func (c *Client) ListLeaseInfos(ctx context.Context) ([]*LeaseInfo, error) {
+	ids, err := c.ListLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// Look up several leases at once, but few enough not to flood the
+	// cluster when there are thousands of them
+	found := make([]*LeaseInfo, len(ids))
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, leaseLookups)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id int64) {
+			defer wg.Done()
+			found[i], errs[i] = c.GetLeaseInfoWithKeys(ctx, id)
+			<-sem
+		}(i, id)
+	}
+	wg.Wait()
+
+	infos := make([]*LeaseInfo, 0, len(ids))
+	for i, info := range found {
+		if errs[i] != nil {
+			return infos, errs[i]
+		}
+		if info.TTL < 0 {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// KeepAliveOnce renews a lease once and returns its new TTL
+func (c *Client) KeepAliveOnce(ctx context.Context, leaseID int64) (int64, error) {
+	if err := c.Guard().CheckChange(fmt.Sprintf("keep lease %d alive", leaseID)); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.KeepAliveOnce(ctx, clientv3.LeaseID(leaseID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to keep alive lease %d: %w", leaseID, err)
+	}
+	return resp.TTL, nil
+}
+
 // ListLeases returns all active leases
 func (c *Client) ListLeases(ctx context.Context) ([]int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultListBatchSize is the number of keys fetched per page when
+// ListOptions.BatchSize is not set
+const DefaultListBatchSize int64 = 1000
+
+// childrenBatchSize is the number of keys fetched per page by ListChildren
+var childrenBatchSize = DefaultListBatchSize
+
+// ListOptions configures a paginated range read
+type ListOptions struct {
+	// Prefix lists all keys starting with this prefix. It is ignored when
+	// RangeEnd is set.
+	Prefix string
+
+	// StartKey is the first key to read (inclusive). Defaults to Prefix.
+	StartKey string
+
+	// RangeEnd is the end of the range (exclusive). When empty, the end of
+	// Prefix is used.
+	RangeEnd string
+
+	// BatchSize is the maximum number of keys fetched per request
+	BatchSize int64
+
+	// KeysOnly skips values, which keeps pages small for large values
+	KeysOnly bool
+
+	// Revision pins the read to a revision. When zero, the revision of the
+	// first page is used for all following pages so the listing is consistent.
+	Revision int64
+}
+
+// ListPage is a single page returned by a ListIterator
+type ListPage struct {
+	// KVs holds the keys of this page in key order
+	KVs []*KeyValue
+
+	// Revision is the store revision the page was read at
+	Revision int64
+
+	// Total is the number of keys remaining in the range when the page was
+	// read, including the keys of this page
+	Total int64
+
+	// NextKey is the cursor for the following page; empty when done
+	NextKey string
+}
+
+// ListIterator walks a key range page by page
+type ListIterator struct {
+	client   *Client
+	opts     ListOptions
+	next     string
+	rangeEnd string
+	revision int64
+	page     *ListPage
+	loaded   int64
+	total    int64
+	done     bool
+	err      error
+}
+
+// NewListIterator creates an iterator over the range described by opts.
+// No request is made until Next is called.
+func (c *Client) NewListIterator(opts ListOptions) *ListIterator {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultListBatchSize
+	}
+
+	start := opts.StartKey
+	if start == "" {
+		start = opts.Prefix
+	}
+	if start == "" {
+		// etcd rejects empty keys; "\x00" is the first possible key
+		start = "\x00"
+	}
+
+	rangeEnd := opts.RangeEnd
+	if rangeEnd == "" {
+		rangeEnd = PrefixRangeEnd(opts.Prefix)
+	}
+
+	return &ListIterator{
+		client:   c,
+		opts:     opts,
+		next:     start,
+		rangeEnd: rangeEnd,
+		revision: opts.Revision,
+	}
+}
+
+// Next fetches the next page. It returns false when the range is exhausted,
+// the context is cancelled or a request fails; check Err afterwards.
+func (it *ListIterator) Next(ctx context.Context) bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, it.client.timeout)
+	defer cancel()
+
+	// Fetch one extra key to learn whether another page follows
+	opts := []clientv3.OpOption{
+		clientv3.WithRange(it.rangeEnd),
+		clientv3.WithLimit(it.opts.BatchSize + 1),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+	}
+	if it.opts.KeysOnly {
+		opts = append(opts, clientv3.WithKeysOnly())
+	}
+	if it.revision > 0 {
+		opts = append(opts, clientv3.WithRev(it.revision))
+	}
+
+	resp, err := it.client.client.Get(reqCtx, it.next, opts...)
+	if err != nil {
+		it.err = fmt.Errorf("failed to list keys from %s: %w", it.next, err)
+		return false
+	}
+
+	if it.revision == 0 {
+		it.revision = resp.Header.Revision
+	}
+	if it.total == 0 {
+		it.total = resp.Count
+	}
+
+	kvs := resp.Kvs
+	page := &ListPage{
+		Revision: it.revision,
+		Total:    resp.Count,
+	}
+
+	if int64(len(kvs)) > it.opts.BatchSize {
+		page.NextKey = string(kvs[it.opts.BatchSize].Key)
+		kvs = kvs[:it.opts.BatchSize]
+	} else {
+		it.done = true
+	}
+
+	page.KVs = make([]*KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		page.KVs = append(page.KVs, newKeyValue(kv))
+	}
+
+	it.next = page.NextKey
+	it.loaded += int64(len(page.KVs))
+	it.page = page
+	return true
+}
+
+// Page returns the page fetched by the last successful call to Next
+func (it *ListIterator) Page() *ListPage {
+	return it.page
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+// Revision returns the revision the listing is pinned to (zero before the
+// first page)
+func (it *ListIterator) Revision() int64 {
+	return it.revision
+}
+
+// Progress returns the number of keys loaded so far and the total number of
+// keys in the range as reported by the first page
+func (it *ListIterator) Progress() (loaded, total int64) {
+	return it.loaded, it.total
+}
+
+// ListProgressFunc is called after every page with the number of keys loaded
+// so far and the total number of keys in the range
+type ListProgressFunc func(loaded, total int64)
+
+// ListAll reads the whole range page by page and returns all keys together
+// with the revision they were read at. Cancelling ctx stops the listing.
+func (c *Client) ListAll(ctx context.Context, opts ListOptions, progress ListProgressFunc) ([]*KeyValue, int64, error) {
+	it := c.NewListIterator(opts)
+
+	var kvs []*KeyValue
+	for it.Next(ctx) {
+		kvs = append(kvs, it.Page().KVs...)
+		if progress != nil {
+			progress(it.Progress())
+		}
+	}
+
+	return kvs, it.Revision(), it.Err()
+}
+
+// PrefixRangeEnd returns the range end that selects every key with the given
+// prefix. An empty prefix selects the whole keyspace.
+func PrefixRangeEnd(prefix string) string {
+	return clientv3.GetPrefixRangeEnd(prefix)
+}
+
+// KeySeparator separates path segments of keys shown as a tree
+const KeySeparator = "/"
+
+// Child is an immediate child of a key prefix as returned by ListChildren.
+// A child can be a key, a directory of further keys, or both.
+type Child struct {
+	// Name is the path segment below the parent prefix
+	Name string
+
+	// KV is the key stored exactly at parent prefix + Name (without value),
+	// nil if there is no such key
+	KV *KeyValue
+
+	// Prefix is the prefix of the keys below this child, empty if the child
+	// has no children
+	Prefix string
+
+	// Count is the number of keys under Prefix
+	Count int64
+}
+
+// IsDir returns true if the child has keys below it
+func (ch *Child) IsDir() bool {
+	return ch.Prefix != ""
+}
+
+// ListChildren returns the immediate children of prefix using keys-only
+// range reads. Subtrees are skipped with a single count request each, so the
+// cost depends on the number of children rather than the number of keys.
+// The returned revision is the one all reads were pinned to.
+func (c *Client) ListChildren(ctx context.Context, prefix string) ([]*Child, int64, error) {
+	return c.ListChildrenAtRevision(ctx, prefix, 0)
+}
+
+// ListChildrenAtRevision is like ListChildren but reads at the given
+// revision, so several levels can be listed consistently. Zero reads at the
+// current revision.
+func (c *Client) ListChildrenAtRevision(ctx context.Context, prefix string, revision int64) ([]*Child, int64, error) {
+	return c.listChildren(ctx, prefix, [][2]string{{prefix, PrefixRangeEnd(prefix)}}, revision)
+}
+
+// ListReadableChildren is like ListChildrenAtRevision but only reads the
+// parts of prefix id may read, so users with access to some subtrees can
+// browse to them. Counts include readable keys only. A nil id reads all of
+// prefix.
+func (c *Client) ListReadableChildren(ctx context.Context, id *Identity, prefix string, revision int64) ([]*Child, int64, error) {
+	if id == nil {
+		return c.ListChildrenAtRevision(ctx, prefix, revision)
+	}
+	return c.listChildren(ctx, prefix, id.ReadRanges(prefix, PrefixRangeEnd(prefix)), revision)
+}
+
+// listChildren returns the children of prefix found in the given key and
+// range end pairs, which must lie within prefix
+func (c *Client) listChildren(ctx context.Context, prefix string, ranges [][2]string, revision int64) ([]*Child, int64, error) {
+	var (
+		children []*Child
+		byName   = make(map[string]*Child)
+	)
+
+	child := func(name string) *Child {
+		ch, ok := byName[name]
+		if !ok {
+			ch = &Child{Name: name}
+			byName[name] = ch
+			children = append(children, ch)
+		}
+		return ch
+	}
+
+	for _, r := range ranges {
+		start, rangeEnd := r[0], r[1]
+		if start == "" {
+			start = "\x00"
+		}
+		window := keyRanges{newKeyRange(r[0], rangeEnd)}
+
+		for {
+			opts := []clientv3.OpOption{
+				clientv3.WithRange(rangeEnd),
+				clientv3.WithKeysOnly(),
+				clientv3.WithLimit(childrenBatchSize),
+				clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+			}
+			if revision > 0 {
+				opts = append(opts, clientv3.WithRev(revision))
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			resp, err := c.client.Get(reqCtx, start, opts...)
+			cancel()
+			if err != nil {
+				return nil, revision, fmt.Errorf("failed to list children of %s: %w", prefix, err)
+			}
+			if revision == 0 {
+				revision = resp.Header.Revision
+			}
+
+			next := ""
+			for _, kv := range resp.Kvs {
+				rest := strings.TrimPrefix(string(kv.Key), prefix)
+				i := strings.Index(rest, KeySeparator)
+				if i < 0 {
+					child(rest).KV = newKeyValue(kv)
+					continue
+				}
+
+				// First key of a subtree: count the part of it within the
+				// range and jump past it
+				ch := child(rest[:i])
+				ch.Prefix = prefix + rest[:i+len(KeySeparator)]
+				for _, part := range window.clip(newKeyRange(ch.Prefix, PrefixRangeEnd(ch.Prefix))) {
+					count, err := c.countRange(ctx, part.start, part.rangeEnd(), revision)
+					if err != nil {
+						return nil, revision, err
+					}
+					ch.Count += count
+				}
+				next = PrefixRangeEnd(ch.Prefix)
+				break
+			}
+
+			if next == "" {
+				if !resp.More || len(resp.Kvs) == 0 {
+					break
+				}
+				next = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+			}
+
+			// "\x00" as a range end means there is nothing left to read
+			if next == "\x00" || (rangeEnd != "\x00" && next >= rangeEnd) {
+				break
+			}
+			start = next
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+
+	return children, revision, nil
+}
+
+// countRange counts the keys from key up to rangeEnd at a revision
+func (c *Client) countRange(ctx context.Context, key, rangeEnd string, revision int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if key == "" {
+		key = "\x00"
+	}
+	resp, err := c.client.Get(ctx, key, clientv3.WithRange(rangeEnd), clientv3.WithCountOnly(), clientv3.WithRev(revision))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count keys from %s: %w", key, err)
+	}
+	return resp.Count, nil
+}
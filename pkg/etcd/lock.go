@@ -17,6 +17,10 @@ type Lock struct {
 
 // AcquireLock acquires a distributed lock with the given key and TTL
 func (c *Client) AcquireLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if err := c.checkPut(ctx, key); err != nil {
+		return nil, err
+	}
+
 	// Create a session with TTL
 	session, err := concurrency.NewSession(c.client, concurrency.WithTTL(int(ttl.Seconds())))
 	if err != nil {
@@ -59,6 +63,10 @@ func (c *Client) ReleaseLock(ctx context.Context, lock *Lock) error {
 
 // TryLock attempts to acquire a lock without blocking
 func (c *Client) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if err := c.checkPut(ctx, key); err != nil {
+		return nil, err
+	}
+
 	session, err := concurrency.NewSession(c.client, concurrency.WithTTL(int(ttl.Seconds())))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
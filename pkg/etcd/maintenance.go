@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultCompactionRetention is the number of revisions kept by default
+// when compacting
+const DefaultCompactionRetention int64 = 1000
+
+// Alarm types raised by etcd members
+const (
+	AlarmNoSpace = "NOSPACE"
+	AlarmCorrupt = "CORRUPT"
+)
+
+// Alarm is an alarm raised by a member
+type Alarm struct {
+	MemberID uint64
+	Type     string
+}
+
+// EndpointHash is the hash of the key-value store of one endpoint
+type EndpointHash struct {
+	Endpoint string
+	MemberID uint64
+
+	// Hash covers all revisions from CompactRevision up to HashRevision
+	Hash            uint32
+	CompactRevision int64
+	HashRevision    int64
+
+	// Err is set if the endpoint could not be queried
+	Err error
+}
+
+// HashCheck is the result of comparing the hashes of all members at the
+// same revision
+type HashCheck struct {
+	Revision int64
+	Hashes   []*EndpointHash
+
+	// Mismatch is true if two members compacted at the same revision
+	// report different hashes, i.e. their data differs
+	Mismatch bool
+
+	// Incomplete is true if a member could not be queried or members
+	// were compacted at different revisions, so not all hashes compare
+	Incomplete bool
+}
+
+// CurrentRevision returns the current revision of the store
+func (c *Client) CurrentRevision(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.Get(ctx, "\x00", clientv3.WithCountOnly())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current revision: %w", err)
+	}
+	return resp.Header.Revision, nil
+}
+
+// CompactHistory compacts etcd history up to a given revision
+func (c *Client) CompactHistory(ctx context.Context, revision int64) error {
+	if err := c.Guard().CheckChange("compact history"); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.Compact(ctx, revision)
+	if err != nil {
+		return fmt.Errorf("failed to compact history: %w", err)
+	}
+
+	return nil
+}
+
+// CompactionRevision returns the current revision and the revision to
+// compact to so that the latest retention revisions are kept. The target is
+// 0 if there are no more than retention revisions.
+func (c *Client) CompactionRevision(ctx context.Context, retention int64) (current, target int64, err error) {
+	current, err = c.CurrentRevision(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return current, compactionTarget(current, retention), nil
+}
+
+// compactionTarget returns current minus retention, or 0 if that leaves
+// nothing to compact
+func compactionTarget(current, retention int64) int64 {
+	if retention < 0 || current-retention <= 1 {
+		return 0
+	}
+	return current - retention
+}
+
+// Defragment defragments the backend database of the member serving
+// endpoint. The member blocks reads and writes while it runs, and it is not
+// bounded by the request timeout; cancel ctx to stop waiting.
+func (c *Client) Defragment(ctx context.Context, endpoint string) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("defragment %s", endpoint)); err != nil {
+		return err
+	}
+
+	if _, err := c.client.Defragment(ctx, endpoint); err != nil {
+		return fmt.Errorf("failed to defragment %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// ListAlarms returns the alarms raised by all members
+func (c *Client) ListAlarms(ctx context.Context) ([]*Alarm, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.AlarmList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alarms: %w", err)
+	}
+
+	alarms := make([]*Alarm, 0, len(resp.Alarms))
+	for _, a := range resp.Alarms {
+		alarms = append(alarms, &Alarm{MemberID: a.MemberID, Type: a.Alarm.String()})
+	}
+	return alarms, nil
+}
+
+// DisarmAlarm clears an alarm of a member
+func (c *Client) DisarmAlarm(ctx context.Context, alarm *Alarm) error {
+	if err := c.Guard().CheckChange(fmt.Sprintf("disarm the %s alarm of %x", alarm.Type, alarm.MemberID)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	alarmType, ok := etcdserverpb.AlarmType_value[alarm.Type]
+	if !ok || alarmType == int32(etcdserverpb.AlarmType_NONE) {
+		return fmt.Errorf("unknown alarm type: %s", alarm.Type)
+	}
+
+	_, err := c.client.AlarmDisarm(ctx, &clientv3.AlarmMember{
+		MemberID: alarm.MemberID,
+		Alarm:    etcdserverpb.AlarmType(alarmType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disarm %s alarm of %x: %w", alarm.Type, alarm.MemberID, err)
+	}
+	return nil
+}
+
+// GetEndpointHash returns the hash of the key-value store of endpoint up to
+// revision (0 for the latest). Failures are reported in the returned hash
+// rather than as an error.
+func (c *Client) GetEndpointHash(ctx context.Context, endpoint string, revision int64) *EndpointHash {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	h := &EndpointHash{Endpoint: endpoint}
+
+	resp, err := c.client.HashKV(ctx, endpoint, revision)
+	if err != nil {
+		h.Err = fmt.Errorf("failed to get hash of %s: %w", endpoint, err)
+		return h
+	}
+
+	if resp.Header != nil {
+		h.MemberID = resp.Header.MemberId
+	}
+	h.Hash = resp.Hash
+	h.CompactRevision = resp.CompactRevision
+	h.HashRevision = resp.HashRevision
+	return h
+}
+
+// CompareHashes hashes the key-value store of every started member at the
+// current revision and compares the results
+func (c *Client) CompareHashes(ctx context.Context) (*HashCheck, error) {
+	revision, err := c.CurrentRevision(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	memberList, err := c.client.MemberList(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member list: %w", err)
+	}
+
+	check := &HashCheck{Revision: revision}
+	for _, member := range memberList.Members {
+		if len(member.ClientURLs) == 0 || member.IsLearner {
+			continue
+		}
+		check.Hashes = append(check.Hashes, &EndpointHash{Endpoint: member.ClientURLs[0], MemberID: member.ID})
+	}
+
+	var wg sync.WaitGroup
+	for i, h := range check.Hashes {
+		wg.Add(1)
+		go func(i int, member *EndpointHash) {
+			defer wg.Done()
+			h := c.GetEndpointHash(ctx, member.Endpoint, revision)
+			h.MemberID = member.MemberID
+			check.Hashes[i] = h
+		}(i, h)
+	}
+	wg.Wait()
+
+	check.Mismatch, check.Incomplete = compareHashes(check.Hashes)
+	return check, nil
+}
+
+// compareHashes reports whether hashes taken at the same compaction
+// revision differ, and whether some hashes could not be compared
+func compareHashes(hashes []*EndpointHash) (mismatch, incomplete bool) {
+	byCompaction := make(map[int64]uint32)
+	for _, h := range hashes {
+		if h.Err != nil {
+			incomplete = true
+			continue
+		}
+		if hash, ok := byCompaction[h.CompactRevision]; ok {
+			if hash != h.Hash {
+				mismatch = true
+			}
+			continue
+		}
+		byCompaction[h.CompactRevision] = h.Hash
+	}
+	if len(byCompaction) > 1 {
+		incomplete = true
+	}
+	return mismatch, incomplete
+}
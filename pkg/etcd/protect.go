@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrReadOnly is matched by the errors returned for changes refused by
+	// a read-only guard
+	ErrReadOnly = errors.New("read-only")
+
+	// ErrProtected is matched by the errors returned for changes refused by
+	// a protected prefix
+	ErrProtected = errors.New("protected")
+
+	// ErrNotConfirmed is matched by the errors returned for changes to keys
+	// protected with ProtectConfirm that were not confirmed
+	ErrNotConfirmed = errors.New("confirmation required")
+)
+
+// Protection is what a protected prefix allows for the keys under it
+type Protection int
+
+const (
+	// ProtectNone allows everything
+	ProtectNone Protection = iota
+
+	// ProtectConfirm allows writes and deletes once the user confirmed
+	// them by typing the key
+	ProtectConfirm
+
+	// ProtectNoDelete allows writes but no deletes
+	ProtectNoDelete
+
+	// ProtectNoWrite allows neither writes nor deletes
+	ProtectNoWrite
+)
+
+// ParseProtection parses the name of a protection as String returns it
+func ParseProtection(s string) (Protection, error) {
+	switch s {
+	case "confirm":
+		return ProtectConfirm, nil
+	case "no-delete":
+		return ProtectNoDelete, nil
+	case "no-write":
+		return ProtectNoWrite, nil
+	default:
+		return ProtectNone, fmt.Errorf("unknown protection %q, expected confirm, no-delete or no-write", s)
+	}
+}
+
+func (p Protection) String() string {
+	switch p {
+	case ProtectNone:
+		return "none"
+	case ProtectConfirm:
+		return "confirm"
+	case ProtectNoDelete:
+		return "no-delete"
+	case ProtectNoWrite:
+		return "no-write"
+	default:
+		return "unknown"
+	}
+}
+
+// ProtectedPrefix protects the keys starting with Prefix
+type ProtectedPrefix struct {
+	Prefix     string
+	Protection Protection
+}
+
+// ProtectionError explains why a change was refused. It matches ErrReadOnly
+// for read-only guards, ErrNotConfirmed for unconfirmed changes to keys
+// protected with ProtectConfirm and ErrProtected otherwise.
+type ProtectionError struct {
+	// Key is the key, or for ranges the prefix, that was to be changed
+	Key string
+
+	// Delete is set for deletes
+	Delete bool
+
+	// Prefix is the protected prefix refusing the change, empty for
+	// read-only guards
+	Prefix string
+
+	Protection Protection
+}
+
+func (e *ProtectionError) Error() string {
+	action := "write"
+	if e.Delete {
+		action = "delete"
+	}
+	switch {
+	case e.Prefix == "":
+		return fmt.Sprintf("cannot %s %s: the profile is read-only", action, e.Key)
+	case e.Protection == ProtectConfirm:
+		return fmt.Sprintf("%s of %s must be confirmed: %s is protected (%s)", action, e.Key, e.Prefix, e.Protection)
+	default:
+		return fmt.Sprintf("cannot %s %s: %s is protected (%s)", action, e.Key, e.Prefix, e.Protection)
+	}
+}
+
+// Is makes errors.Is match ErrReadOnly, ErrNotConfirmed or ErrProtected
+func (e *ProtectionError) Is(target error) bool {
+	switch {
+	case e.Prefix == "":
+		return target == ErrReadOnly
+	case e.Protection == ProtectConfirm:
+		return target == ErrNotConfirmed
+	default:
+		return target == ErrProtected
+	}
+}
+
+// Guard refuses the changes a connection must not make: all of them when it
+// is read-only, and those its protected prefixes forbid. A change must be
+// allowed by every prefix covering it. A nil Guard allows everything.
+//
+// A client created with Config.Guard checks it on every change it makes, so
+// callers only need the guard to show what is protected.
+type Guard struct {
+	readOnly bool
+	prefixes []ProtectedPrefix
+}
+
+// NewGuard checks the protected prefixes and returns a guard applying them
+func NewGuard(readOnly bool, prefixes []ProtectedPrefix) (*Guard, error) {
+	for i, p := range prefixes {
+		if p.Prefix == "" {
+			return nil, fmt.Errorf("protected prefix %d: prefix is required", i+1)
+		}
+		if p.Protection <= ProtectNone || p.Protection > ProtectNoWrite {
+			return nil, fmt.Errorf("protected prefix %s: invalid protection", p.Prefix)
+		}
+	}
+	return &Guard{readOnly: readOnly, prefixes: prefixes}, nil
+}
+
+// ReadOnly reports whether the guard refuses every change
+func (g *Guard) ReadOnly() bool {
+	return g != nil && g.readOnly
+}
+
+// Protects reports whether the guard has protected prefixes
+func (g *Guard) Protects() bool {
+	return g != nil && len(g.prefixes) > 0
+}
+
+// Protection returns the strictest protected prefix key is under, with
+// ProtectNone if there is none
+func (g *Guard) Protection(key string) ProtectedPrefix {
+	var out ProtectedPrefix
+	if g == nil {
+		return out
+	}
+	for _, p := range g.prefixes {
+		if strings.HasPrefix(key, p.Prefix) && p.Protection > out.Protection {
+			out = p
+		}
+	}
+	return out
+}
+
+// CheckPut returns a *ProtectionError if key may not be written. Keys
+// protected with ProtectConfirm may be written once confirmed.
+func (g *Guard) CheckPut(key string, confirmed bool) error {
+	return g.check(key, false, confirmed, func(p string) bool { return strings.HasPrefix(key, p) })
+}
+
+// CheckDelete returns a *ProtectionError if key may not be deleted
+func (g *Guard) CheckDelete(key string, confirmed bool) error {
+	return g.check(key, true, confirmed, func(p string) bool { return strings.HasPrefix(key, p) })
+}
+
+// CheckDeletePrefix returns a *ProtectionError if any key with prefix may
+// not be deleted
+func (g *Guard) CheckDeletePrefix(prefix string, confirmed bool) error {
+	return g.check(prefix, true, confirmed, func(p string) bool {
+		return strings.HasPrefix(prefix, p) || strings.HasPrefix(p, prefix)
+	})
+}
+
+// CheckChange returns an error matching ErrReadOnly if the guard is
+// read-only, for changes other than writes to keys, such as adding members
+// or users. what describes the change.
+func (g *Guard) CheckChange(what string) error {
+	if g.ReadOnly() {
+		return fmt.Errorf("cannot %s: the profile is %w", what, ErrReadOnly)
+	}
+	return nil
+}
+
+// check decides a change to the keys covered by the protected prefixes
+// matching covers. Every matching prefix must allow it, so a refusal wins
+// over a confirmation.
+func (g *Guard) check(key string, del, confirmed bool, covers func(prefix string) bool) error {
+	if g == nil {
+		return nil
+	}
+	if g.readOnly {
+		return &ProtectionError{Key: key, Delete: del, Protection: ProtectNoWrite}
+	}
+	var unconfirmed error
+	for _, p := range g.prefixes {
+		if !covers(p.Prefix) {
+			continue
+		}
+		err := &ProtectionError{Key: key, Delete: del, Prefix: p.Prefix, Protection: p.Protection}
+		switch {
+		case p.Protection == ProtectNoWrite, del && p.Protection == ProtectNoDelete:
+			return err
+		case p.Protection == ProtectConfirm && !confirmed && unconfirmed == nil:
+			unconfirmed = err
+		}
+	}
+	return unconfirmed
+}
+
+// confirmationKey is the context key of WithConfirmation
+type confirmationKey struct{}
+
+// WithConfirmation returns a context allowing changes to keys protected
+// with ProtectConfirm, once the user confirmed them
+func WithConfirmation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, confirmationKey{}, true)
+}
+
+// confirmed reports whether ctx carries a confirmation
+func confirmed(ctx context.Context) bool {
+	ok, _ := ctx.Value(confirmationKey{}).(bool)
+	return ok
+}
+
+// Guard returns the guard the client checks changes with, nil if it makes
+// every change
+func (c *Client) Guard() *Guard {
+	return c.config.Guard
+}
+
+// checkPut returns why the client's guard refuses writing key
+func (c *Client) checkPut(ctx context.Context, key string) error {
+	return c.Guard().CheckPut(key, confirmed(ctx))
+}
+
+// checkDelete returns why the client's guard refuses deleting key
+func (c *Client) checkDelete(ctx context.Context, key string) error {
+	return c.Guard().CheckDelete(key, confirmed(ctx))
+}
+
+// checkDeletePrefix returns why the client's guard refuses deleting the
+// keys with prefix
+func (c *Client) checkDeletePrefix(ctx context.Context, prefix string) error {
+	return c.Guard().CheckDeletePrefix(prefix, confirmed(ctx))
+}
@@ -0,0 +1,768 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Schema is a JSON Schema for values. The keywords checked are type, enum,
+// const, properties, required, additionalProperties, items, minItems,
+// maxItems, uniqueItems, minProperties, maxProperties, minLength,
+// maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not and local $ref
+// to "#", "#/definitions/..." and "#/$defs/...". ParseSchema rejects any
+// other keyword except annotations such as title and description, so that
+// a schema never accepts values it was written to reject.
+type Schema struct {
+	// allow is set for the boolean schemas true and false
+	allow *bool
+
+	types      []string
+	enum       []any
+	constValue any
+	hasConst   bool
+
+	properties map[string]*Schema
+	required   []string
+	additional *Schema
+	items      *Schema
+
+	minItems, maxItems   *int
+	minProps, maxProps   *int
+	minLength, maxLength *int
+	uniqueItems          bool
+	pattern              *regexp.Regexp
+
+	minimum, maximum, exclusiveMin, exclusiveMax, multipleOf *float64
+
+	allOf, anyOf, oneOf []*Schema
+	not                 *Schema
+
+	ref  string
+	root *Schema
+
+	// refs holds the schemas $ref can point to, on the root schema only
+	refs map[string]*Schema
+}
+
+// schemaAnnotations are the keywords that do not constrain values
+var schemaAnnotations = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"id":          true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+	"examples":    true,
+	"deprecated":  true,
+	"readOnly":    true,
+	"writeOnly":   true,
+}
+
+// LoadSchema reads a JSON Schema from a JSON or YAML file
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// ParseSchema parses a JSON Schema written as JSON or YAML
+func ParseSchema(data []byte) (*Schema, error) {
+	var doc any
+	if json.Valid(data) {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
+	} else if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	root := &Schema{refs: make(map[string]*Schema)}
+	if err := root.parse(doc, root, "#"); err != nil {
+		return nil, err
+	}
+	if err := root.checkRefs(make(map[*Schema]bool)); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// parse fills s from the decoded schema v found at where, a JSON pointer
+// with a leading #
+func (s *Schema) parse(v any, root *Schema, where string) error {
+	s.root = root
+	root.refs[where] = s
+
+	if allow, ok := v.(bool); ok {
+		s.allow = &allow
+		return nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("schema %s: expected an object or a boolean", where)
+	}
+
+	fail := func(keyword, expected string) error {
+		return fmt.Errorf("schema %s: %s must be %s", where, keyword, expected)
+	}
+	sub := func(keyword string, v any) (*Schema, error) {
+		child := &Schema{}
+		return child, child.parse(v, root, where+"/"+escapePointer(keyword))
+	}
+	subList := func(keyword string, v any) ([]*Schema, error) {
+		list, ok := v.([]any)
+		if !ok || len(list) == 0 {
+			return nil, fail(keyword, "a non-empty list of schemas")
+		}
+		out := make([]*Schema, len(list))
+		for i, item := range list {
+			child := &Schema{}
+			if err := child.parse(item, root, fmt.Sprintf("%s/%s/%d", where, keyword, i)); err != nil {
+				return nil, err
+			}
+			out[i] = child
+		}
+		return out, nil
+	}
+	count := func(keyword string, v any) (*int, error) {
+		n, ok := schemaNumber(v)
+		if !ok || n < 0 || n != math.Trunc(n) {
+			return nil, fail(keyword, "a non-negative integer")
+		}
+		i := int(n)
+		return &i, nil
+	}
+	number := func(keyword string, v any) (*float64, error) {
+		n, ok := schemaNumber(v)
+		if !ok {
+			return nil, fail(keyword, "a number")
+		}
+		return &n, nil
+	}
+
+	// Sort keywords so that errors do not depend on map order
+	keywords := make([]string, 0, len(m))
+	for k := range m {
+		keywords = append(keywords, k)
+	}
+	sort.Strings(keywords)
+
+	var err error
+	for _, keyword := range keywords {
+		v := m[keyword]
+		switch keyword {
+		case "type":
+			switch t := v.(type) {
+			case string:
+				s.types = []string{t}
+			case []any:
+				for _, item := range t {
+					name, ok := item.(string)
+					if !ok {
+						return fail(keyword, "a type name or a list of type names")
+					}
+					s.types = append(s.types, name)
+				}
+			default:
+				return fail(keyword, "a type name or a list of type names")
+			}
+			for _, name := range s.types {
+				switch name {
+				case "object", "array", "string", "number", "integer", "boolean", "null":
+				default:
+					return fmt.Errorf("schema %s: unknown type %q", where, name)
+				}
+			}
+		case "enum":
+			list, ok := v.([]any)
+			if !ok {
+				return fail(keyword, "a list")
+			}
+			for _, item := range list {
+				s.enum = append(s.enum, normalizeSchemaValue(item))
+			}
+		case "const":
+			s.constValue, s.hasConst = normalizeSchemaValue(v), true
+		case "properties", "definitions", "$defs":
+			props, ok := v.(map[string]any)
+			if !ok {
+				return fail(keyword, "an object of schemas")
+			}
+			children := make(map[string]*Schema, len(props))
+			for name, prop := range props {
+				child := &Schema{}
+				if err := child.parse(prop, root, where+"/"+escapePointer(keyword)+"/"+escapePointer(name)); err != nil {
+					return err
+				}
+				children[name] = child
+			}
+			if keyword == "properties" {
+				s.properties = children
+			}
+		case "required":
+			list, ok := v.([]any)
+			if !ok {
+				return fail(keyword, "a list of property names")
+			}
+			for _, item := range list {
+				name, ok := item.(string)
+				if !ok {
+					return fail(keyword, "a list of property names")
+				}
+				s.required = append(s.required, name)
+			}
+		case "additionalProperties":
+			s.additional, err = sub(keyword, v)
+		case "items":
+			s.items, err = sub(keyword, v)
+		case "not":
+			s.not, err = sub(keyword, v)
+		case "allOf":
+			s.allOf, err = subList(keyword, v)
+		case "anyOf":
+			s.anyOf, err = subList(keyword, v)
+		case "oneOf":
+			s.oneOf, err = subList(keyword, v)
+		case "minItems":
+			s.minItems, err = count(keyword, v)
+		case "maxItems":
+			s.maxItems, err = count(keyword, v)
+		case "minProperties":
+			s.minProps, err = count(keyword, v)
+		case "maxProperties":
+			s.maxProps, err = count(keyword, v)
+		case "minLength":
+			s.minLength, err = count(keyword, v)
+		case "maxLength":
+			s.maxLength, err = count(keyword, v)
+		case "uniqueItems":
+			unique, ok := v.(bool)
+			if !ok {
+				return fail(keyword, "a boolean")
+			}
+			s.uniqueItems = unique
+		case "pattern":
+			text, ok := v.(string)
+			if !ok {
+				return fail(keyword, "a string")
+			}
+			if s.pattern, err = regexp.Compile(text); err != nil {
+				return fmt.Errorf("schema %s: invalid pattern: %w", where, err)
+			}
+		case "minimum":
+			s.minimum, err = number(keyword, v)
+		case "maximum":
+			s.maximum, err = number(keyword, v)
+		case "exclusiveMinimum", "exclusiveMaximum":
+			// Draft 4 makes minimum and maximum exclusive with a boolean
+			if _, ok := v.(bool); ok {
+				continue
+			}
+			if keyword == "exclusiveMinimum" {
+				s.exclusiveMin, err = number(keyword, v)
+			} else {
+				s.exclusiveMax, err = number(keyword, v)
+			}
+		case "multipleOf":
+			if s.multipleOf, err = number(keyword, v); err == nil && *s.multipleOf <= 0 {
+				return fail(keyword, "greater than 0")
+			}
+		case "$ref":
+			ref, ok := v.(string)
+			if !ok {
+				return fail(keyword, "a string")
+			}
+			s.ref = ref
+		default:
+			if !schemaAnnotations[keyword] {
+				return fmt.Errorf("schema %s: keyword %s is not supported", where, keyword)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if exclusive, _ := m["exclusiveMinimum"].(bool); exclusive && s.minimum != nil {
+		s.exclusiveMin, s.minimum = s.minimum, nil
+	}
+	if exclusive, _ := m["exclusiveMaximum"].(bool); exclusive && s.maximum != nil {
+		s.exclusiveMax, s.maximum = s.maximum, nil
+	}
+	return nil
+}
+
+// checkRefs makes sure every $ref below s points into the schema
+func (s *Schema) checkRefs(seen map[*Schema]bool) error {
+	if s == nil || seen[s] {
+		return nil
+	}
+	seen[s] = true
+
+	if s.ref != "" && s.root.refs[s.ref] == nil {
+		return fmt.Errorf("schema: $ref %q does not point into the schema; only #, #/definitions/... and #/$defs/... are supported", s.ref)
+	}
+	children := []*Schema{s.additional, s.items, s.not}
+	children = append(children, s.allOf...)
+	children = append(children, s.anyOf...)
+	children = append(children, s.oneOf...)
+	for _, child := range s.properties {
+		children = append(children, child)
+	}
+	if s.refs != nil {
+		for _, child := range s.refs {
+			children = append(children, child)
+		}
+	}
+	for _, child := range children {
+		if err := child.checkRefs(seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxRefDepth stops validation of schemas that refer to themselves without
+// consuming the value
+const maxRefDepth = 64
+
+// validate appends the problems of n at path to issues
+func (s *Schema) validate(n *valueNode, path string, depth int, issues *[]ValidationIssue) {
+	add := func(format string, args ...any) {
+		*issues = append(*issues, ValidationIssue{Line: n.line, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.allow != nil {
+		if !*s.allow {
+			add("no value is allowed here")
+		}
+		return
+	}
+
+	if s.ref != "" {
+		if depth >= maxRefDepth {
+			add("schema $ref nesting is too deep")
+			return
+		}
+		s.root.refs[s.ref].validate(n, path, depth+1, issues)
+	}
+
+	if len(s.types) > 0 && !n.hasType(s.types) {
+		add("must be %s, not %s", strings.Join(s.types, " or "), n.kind)
+		return
+	}
+	if len(s.enum) > 0 {
+		value := n.plain()
+		found := false
+		for _, allowed := range s.enum {
+			if reflect.DeepEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(s.enum))
+			for i, allowed := range s.enum {
+				names[i] = schemaValueText(allowed)
+			}
+			add("must be one of %s", strings.Join(names, ", "))
+		}
+	}
+	if s.hasConst && !reflect.DeepEqual(n.plain(), s.constValue) {
+		add("must be %s", schemaValueText(s.constValue))
+	}
+
+	switch n.kind {
+	case "object":
+		s.validateObject(n, path, depth, issues)
+	case "array":
+		if s.minItems != nil && len(n.items) < *s.minItems {
+			add("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(n.items) > *s.maxItems {
+			add("must have at most %d items", *s.maxItems)
+		}
+		if s.uniqueItems {
+		unique:
+			for i := range n.items {
+				for j := 0; j < i; j++ {
+					if reflect.DeepEqual(n.items[i].plain(), n.items[j].plain()) {
+						add("items %d and %d are equal", j, i)
+						break unique
+					}
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range n.items {
+				s.items.validate(item, fmt.Sprintf("%s/%d", path, i), depth, issues)
+			}
+		}
+	case "string":
+		length := utf8.RuneCountInString(n.str)
+		if s.minLength != nil && length < *s.minLength {
+			add("must be at least %d characters long", *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			add("must be at most %d characters long", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(n.str) {
+			add("must match %s", s.pattern)
+		}
+	case "number":
+		switch {
+		case s.minimum != nil && n.num < *s.minimum:
+			add("must be at least %v", *s.minimum)
+		case s.exclusiveMin != nil && n.num <= *s.exclusiveMin:
+			add("must be greater than %v", *s.exclusiveMin)
+		}
+		switch {
+		case s.maximum != nil && n.num > *s.maximum:
+			add("must be at most %v", *s.maximum)
+		case s.exclusiveMax != nil && n.num >= *s.exclusiveMax:
+			add("must be less than %v", *s.exclusiveMax)
+		}
+		if s.multipleOf != nil {
+			if q := n.num / *s.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				add("must be a multiple of %v", *s.multipleOf)
+			}
+		}
+	}
+
+	for _, child := range s.allOf {
+		child.validate(n, path, depth, issues)
+	}
+	if len(s.anyOf) > 0 && s.matching(s.anyOf, n, path, depth) == 0 {
+		add("does not match any of the allowed schemas")
+	}
+	if len(s.oneOf) > 0 {
+		if matched := s.matching(s.oneOf, n, path, depth); matched != 1 {
+			add("matches %d of the schemas in oneOf instead of exactly one", matched)
+		}
+	}
+	if s.not != nil && s.matching([]*Schema{s.not}, n, path, depth) == 1 {
+		add("must not match the schema in not")
+	}
+}
+
+// validateObject checks the object keywords
+func (s *Schema) validateObject(n *valueNode, path string, depth int, issues *[]ValidationIssue) {
+	if s.minProps != nil && len(n.keys) < *s.minProps {
+		*issues = append(*issues, ValidationIssue{Line: n.line, Path: path, Message: fmt.Sprintf("must have at least %d properties", *s.minProps)})
+	}
+	if s.maxProps != nil && len(n.keys) > *s.maxProps {
+		*issues = append(*issues, ValidationIssue{Line: n.line, Path: path, Message: fmt.Sprintf("must have at most %d properties", *s.maxProps)})
+	}
+	for _, name := range s.required {
+		if _, ok := n.fields[name]; !ok {
+			*issues = append(*issues, ValidationIssue{Line: n.line, Path: path, Message: fmt.Sprintf("missing required property %q", name)})
+		}
+	}
+	for _, name := range n.keys {
+		child := n.fields[name]
+		childPath := path + "/" + escapePointer(name)
+		if prop, ok := s.properties[name]; ok {
+			prop.validate(child, childPath, depth, issues)
+			continue
+		}
+		if s.additional == nil {
+			continue
+		}
+		if s.additional.allow != nil && !*s.additional.allow {
+			*issues = append(*issues, ValidationIssue{Line: child.line, Path: childPath, Message: "property is not allowed"})
+			continue
+		}
+		s.additional.validate(child, childPath, depth, issues)
+	}
+}
+
+// matching returns how many of schemas n matches
+func (s *Schema) matching(schemas []*Schema, n *valueNode, path string, depth int) int {
+	matched := 0
+	for _, child := range schemas {
+		var childIssues []ValidationIssue
+		child.validate(n, path, depth, &childIssues)
+		if len(childIssues) == 0 {
+			matched++
+		}
+	}
+	return matched
+}
+
+// valueNode is a decoded JSON or YAML value with the line it starts on
+type valueNode struct {
+	// kind is the JSON type: object, array, string, number, boolean or
+	// null
+	kind string
+	line int
+
+	str     string
+	num     float64
+	boolean bool
+	keys    []string
+	fields  map[string]*valueNode
+	items   []*valueNode
+}
+
+// hasType reports whether n is one of the JSON Schema types
+func (n *valueNode) hasType(types []string) bool {
+	for _, t := range types {
+		if t == n.kind || t == "integer" && n.kind == "number" && n.num == math.Trunc(n.num) {
+			return true
+		}
+	}
+	return false
+}
+
+// plain returns n as decoded by encoding/json, with float64 numbers
+func (n *valueNode) plain() any {
+	switch n.kind {
+	case "object":
+		m := make(map[string]any, len(n.fields))
+		for k, v := range n.fields {
+			m[k] = v.plain()
+		}
+		return m
+	case "array":
+		list := make([]any, len(n.items))
+		for i, item := range n.items {
+			list[i] = item.plain()
+		}
+		return list
+	case "string":
+		return n.str
+	case "number":
+		return n.num
+	case "boolean":
+		return n.boolean
+	default:
+		return nil
+	}
+}
+
+// decodeJSONNode decodes a JSON value keeping the line of every value
+func decodeJSONNode(value []byte) (*valueNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	line := func() int {
+		return bytes.Count(value[:dec.InputOffset()], []byte("\n")) + 1
+	}
+
+	var decode func() (*valueNode, error)
+	decode = func() (*valueNode, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, jsonLineError(value, err)
+		}
+		n := &valueNode{line: line()}
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '{' {
+				n.kind, n.fields = "object", make(map[string]*valueNode)
+				for dec.More() {
+					keyTok, err := dec.Token()
+					if err != nil {
+						return nil, jsonLineError(value, err)
+					}
+					key, _ := keyTok.(string)
+					child, err := decode()
+					if err != nil {
+						return nil, err
+					}
+					if _, dup := n.fields[key]; !dup {
+						n.keys = append(n.keys, key)
+					}
+					n.fields[key] = child
+				}
+			} else {
+				n.kind = "array"
+				for dec.More() {
+					child, err := decode()
+					if err != nil {
+						return nil, err
+					}
+					n.items = append(n.items, child)
+				}
+			}
+			// The closing delimiter
+			if _, err := dec.Token(); err != nil {
+				return nil, jsonLineError(value, err)
+			}
+		case string:
+			n.kind, n.str = "string", t
+		case json.Number:
+			n.kind = "number"
+			if n.num, err = t.Float64(); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n.line, err)
+			}
+		case bool:
+			n.kind, n.boolean = "boolean", t
+		default:
+			n.kind = "null"
+		}
+		return n, nil
+	}
+
+	n, err := decode()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("line %d: unexpected data after the value", line())
+	}
+	return n, nil
+}
+
+// jsonLineError adds the line to JSON syntax errors
+func jsonLineError(value []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line := bytes.Count(value[:min(int(syntaxErr.Offset), len(value))], []byte("\n")) + 1
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("line %d: unexpected end of JSON input", bytes.Count(value, []byte("\n"))+1)
+	}
+	return err
+}
+
+// decodeYAMLNode converts the first document of a YAML value
+func decodeYAMLNode(value []byte) (*valueNode, error) {
+	docs, err := yamlDocuments(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 || len(docs[0].Content) == 0 {
+		return &valueNode{kind: "null", line: 1}, nil
+	}
+	return yamlValueNode(docs[0].Content[0], 0)
+}
+
+// yamlValueNode converts a YAML node, following aliases at most
+// maxRefDepth deep
+func yamlValueNode(node *yaml.Node, depth int) (*valueNode, error) {
+	if depth > maxRefDepth {
+		return nil, fmt.Errorf("line %d: aliases nested too deep", node.Line)
+	}
+
+	n := &valueNode{line: node.Line}
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValueNode(node.Alias, depth+1)
+	case yaml.MappingNode:
+		n.kind, n.fields = "object", make(map[string]*valueNode)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			child, err := yamlValueNode(node.Content[i+1], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := n.fields[key]; !dup {
+				n.keys = append(n.keys, key)
+			}
+			n.fields[key] = child
+		}
+	case yaml.SequenceNode:
+		n.kind = "array"
+		for _, item := range node.Content {
+			child, err := yamlValueNode(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, child)
+		}
+	default:
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		switch t := normalizeSchemaValue(v).(type) {
+		case nil:
+			n.kind = "null"
+		case bool:
+			n.kind, n.boolean = "boolean", t
+		case float64:
+			n.kind, n.num = "number", t
+		case string:
+			n.kind, n.str = "string", t
+		default:
+			// Timestamps and other tagged scalars are checked as written
+			n.kind, n.str = "string", node.Value
+		}
+	}
+	return n, nil
+}
+
+// normalizeSchemaValue converts numbers to float64 and maps to
+// map[string]any, as encoding/json decodes them
+func normalizeSchemaValue(v any) any {
+	if n, ok := schemaNumber(v); ok {
+		return n
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, item := range t {
+			m[k] = normalizeSchemaValue(item)
+		}
+		return m
+	case []any:
+		list := make([]any, len(t))
+		for i, item := range t {
+			list[i] = normalizeSchemaValue(item)
+		}
+		return list
+	}
+	return v
+}
+
+// schemaNumber returns v as a float64 if it is a number
+func schemaNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// schemaValueText formats a value of a schema for messages
+func schemaValueText(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// escapePointer escapes a property name for a JSON pointer
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
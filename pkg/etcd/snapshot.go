@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrSnapshotHashMismatch is returned when the sha256 trailer of a snapshot
+// does not match its contents
+var ErrSnapshotHashMismatch = errors.New("snapshot hash mismatch")
+
+// ErrSnapshotNoHash is returned when a snapshot has no sha256 trailer
+var ErrSnapshotNoHash = errors.New("snapshot has no integrity hash")
+
+// SnapshotProgressFunc is called while a snapshot is streamed with the
+// number of bytes received so far
+type SnapshotProgressFunc func(written int64)
+
+// SnapshotInfo describes a saved and verified snapshot
+type SnapshotInfo struct {
+	// Path is the final location of the snapshot file
+	Path string
+
+	// Size is the size of the file in bytes, including the hash trailer
+	Size int64
+
+	// Hash is the hex encoded sha256 trailer of the snapshot
+	Hash string
+}
+
+// Snapshot streams a snapshot of the backend database of one of the
+// configured endpoints to w and verifies its sha256 trailer. The stream is
+// not bounded by the request timeout; cancel ctx to abort it. It returns the
+// number of bytes written.
+func (c *Client) Snapshot(ctx context.Context, w io.Writer, progress SnapshotProgressFunc) (int64, error) {
+	rc, err := c.client.Snapshot(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	verifier := newSnapshotVerifier()
+	dst := io.MultiWriter(w, verifier)
+	if progress != nil {
+		dst = &progressWriter{w: dst, progress: progress}
+	}
+
+	n, err := io.Copy(dst, rc)
+	if err != nil {
+		return n, fmt.Errorf("failed to stream snapshot: %w", err)
+	}
+
+	if err := verifier.Verify(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// SaveSnapshot streams a snapshot into path. The data is written to a
+// temporary file in the same directory, synced, verified and then renamed,
+// so path is either the complete snapshot or left untouched.
+func (c *Client) SaveSnapshot(ctx context.Context, path string, progress SnapshotProgressFunc) (*SnapshotInfo, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".part-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary snapshot file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Remove the temporary file on any failure below
+	success := false
+	defer func() {
+		if !success {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	size, err := c.Snapshot(ctx, tmp, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync snapshot file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close snapshot file: %w", err)
+	}
+
+	info, err := VerifySnapshot(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("failed to move snapshot into place: %w", err)
+	}
+	success = true
+
+	info.Path = path
+	info.Size = size
+	return info, nil
+}
+
+// VerifySnapshot checks the sha256 trailer of a snapshot file
+func VerifySnapshot(path string) (*SnapshotInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	verifier := newSnapshotVerifier()
+	size, err := io.Copy(verifier, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	if err := verifier.Verify(); err != nil {
+		return nil, err
+	}
+
+	return &SnapshotInfo{
+		Path: path,
+		Size: size,
+		Hash: hex.EncodeToString(verifier.tail),
+	}, nil
+}
+
+// snapshotVerifier hashes everything written to it except the last
+// sha256.Size bytes, which etcd appends as the hash of the database
+type snapshotVerifier struct {
+	hash hash.Hash
+	tail []byte
+	size int64
+}
+
+func newSnapshotVerifier() *snapshotVerifier {
+	return &snapshotVerifier{hash: sha256.New()}
+}
+
+// Write implements io.Writer
+func (v *snapshotVerifier) Write(p []byte) (int, error) {
+	v.size += int64(len(p))
+
+	buf := append(v.tail, p...)
+	if len(buf) > sha256.Size {
+		cut := len(buf) - sha256.Size
+		v.hash.Write(buf[:cut])
+		buf = buf[cut:]
+	}
+	v.tail = append(v.tail[:0:0], buf...)
+	return len(p), nil
+}
+
+// Verify compares the trailer with the hash of the preceding data
+func (v *snapshotVerifier) Verify() error {
+	// The database is a multiple of the 512 byte page size; only then a
+	// trailer is present
+	if v.size%512 != sha256.Size {
+		return ErrSnapshotNoHash
+	}
+	if !bytes.Equal(v.hash.Sum(nil), v.tail) {
+		return ErrSnapshotHashMismatch
+	}
+	return nil
+}
+
+// progressWriter reports the number of bytes written through it
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	progress SnapshotProgressFunc
+}
+
+// Write implements io.Writer
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.written)
+	return n, err
+}
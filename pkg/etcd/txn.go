@@ -9,6 +9,10 @@ import (
 
 // CompareAndSwap performs an atomic compare-and-swap operation
 func (c *Client) CompareAndSwap(ctx context.Context, key, oldValue, newValue string) (bool, error) {
+	if err := c.checkPut(ctx, key); err != nil {
+		return false, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -30,6 +34,10 @@ func (c *Client) CompareAndSwap(ctx context.Context, key, oldValue, newValue str
 
 // CreateIfNotExists creates a key only if it doesn't exist
 func (c *Client) CreateIfNotExists(ctx context.Context, key, value string) (bool, error) {
+	if err := c.checkPut(ctx, key); err != nil {
+		return false, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -51,6 +59,10 @@ func (c *Client) CreateIfNotExists(ctx context.Context, key, value string) (bool
 
 // UpdateIfExists updates a key only if it exists
 func (c *Client) UpdateIfExists(ctx context.Context, key, value string) (bool, error) {
+	if err := c.checkPut(ctx, key); err != nil {
+		return false, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -69,3 +81,311 @@ func (c *Client) UpdateIfExists(ctx context.Context, key, value string) (bool, e
 
 	return resp.Succeeded, nil
 }
+
+// PutIfModRevision stores value at key only if the key was last modified
+// at modRevision, as read before editing; 0 requires that the key does not
+// exist. The value is attached to leaseID, 0 for none. When the key was
+// changed it returns false and the key as it is now, nil if it was deleted.
+func (c *Client) PutIfModRevision(ctx context.Context, key, value string, modRevision, leaseID int64) (bool, *KeyValue, error) {
+	res, err := c.NewTxn().
+		If(CompareModRevision(key, CompareEqual, modRevision)).
+		Then(OpPutWithLease(key, value, leaseID)).
+		Else(OpGet(key)).
+		Commit(ctx)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to put key %s: %w", key, err)
+	}
+	if res.Succeeded {
+		return true, nil, nil
+	}
+
+	var current *KeyValue
+	if kvs := res.Results[0].KVs; len(kvs) > 0 {
+		current = kvs[0]
+	}
+	return false, current, nil
+}
+
+// DefaultMaxTxnOps is etcd's default limit on operations per transaction
+// (--max-txn-ops). Split larger updates into several transactions.
+const DefaultMaxTxnOps = 128
+
+// CompareOp is the comparison operator of a transaction condition
+type CompareOp string
+
+const (
+	CompareEqual    CompareOp = "="
+	CompareNotEqual CompareOp = "!="
+	CompareGreater  CompareOp = ">"
+	CompareLess     CompareOp = "<"
+)
+
+// valid reports whether the operator is understood by etcd
+func (op CompareOp) valid() bool {
+	switch op {
+	case CompareEqual, CompareNotEqual, CompareGreater, CompareLess:
+		return true
+	}
+	return false
+}
+
+// Cmp is a condition evaluated by a transaction
+type Cmp struct {
+	cmp clientv3.Cmp
+	err error
+}
+
+// compare builds a condition on target, rejecting unknown operators instead
+// of letting clientv3 panic
+func compare(target clientv3.Cmp, field, key string, op CompareOp, v interface{}) Cmp {
+	if !op.valid() {
+		return Cmp{err: fmt.Errorf("invalid compare operator %q on %s of key %s", op, field, key)}
+	}
+	return Cmp{cmp: clientv3.Compare(target, string(op), v)}
+}
+
+// CompareValue compares the value of key
+func CompareValue(key string, op CompareOp, value string) Cmp {
+	return compare(clientv3.Value(key), "value", key, op, value)
+}
+
+// CompareVersion compares the version of key. Version 0 means the key does
+// not exist.
+func CompareVersion(key string, op CompareOp, version int64) Cmp {
+	return compare(clientv3.Version(key), "version", key, op, version)
+}
+
+// CompareCreateRevision compares the revision key was created at
+func CompareCreateRevision(key string, op CompareOp, revision int64) Cmp {
+	return compare(clientv3.CreateRevision(key), "create revision", key, op, revision)
+}
+
+// CompareModRevision compares the revision key was last modified at
+func CompareModRevision(key string, op CompareOp, revision int64) Cmp {
+	return compare(clientv3.ModRevision(key), "mod revision", key, op, revision)
+}
+
+// CompareLease compares the lease attached to key (0 for no lease)
+func CompareLease(key string, op CompareOp, leaseID int64) Cmp {
+	return compare(clientv3.LeaseValue(key), "lease", key, op, clientv3.LeaseID(leaseID))
+}
+
+// WithPrefix applies the condition to every key with the condition's key as
+// prefix
+func (c Cmp) WithPrefix() Cmp {
+	if c.err == nil {
+		c.cmp = c.cmp.WithPrefix()
+	}
+	return c
+}
+
+// OpType is the kind of operation in a transaction branch
+type OpType int
+
+const (
+	OpTypeGet OpType = iota
+	OpTypePut
+	OpTypeDelete
+)
+
+// String returns the name of the operation type
+func (t OpType) String() string {
+	switch t {
+	case OpTypeGet:
+		return "get"
+	case OpTypePut:
+		return "put"
+	case OpTypeDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("OpType(%d)", int(t))
+}
+
+// Op is an operation executed in a transaction branch
+type Op struct {
+	Type   OpType
+	Key    string
+	prefix bool
+	op     clientv3.Op
+}
+
+// OpGet reads key
+func OpGet(key string) Op {
+	return Op{Type: OpTypeGet, Key: key, op: clientv3.OpGet(key)}
+}
+
+// OpGetPrefix reads every key with the given prefix
+func OpGetPrefix(prefix string) Op {
+	return Op{Type: OpTypeGet, Key: prefix, prefix: true, op: clientv3.OpGet(prefix, clientv3.WithPrefix())}
+}
+
+// OpPut stores value at key
+func OpPut(key, value string) Op {
+	return Op{Type: OpTypePut, Key: key, op: clientv3.OpPut(key, value)}
+}
+
+// OpPutWithLease stores value at key attached to a lease
+func OpPutWithLease(key, value string, leaseID int64) Op {
+	return Op{Type: OpTypePut, Key: key, op: clientv3.OpPut(key, value, clientv3.WithLease(clientv3.LeaseID(leaseID)))}
+}
+
+// OpDelete removes key
+func OpDelete(key string) Op {
+	return Op{Type: OpTypeDelete, Key: key, op: clientv3.OpDelete(key)}
+}
+
+// OpDeletePrefix removes every key with the given prefix
+func OpDeletePrefix(prefix string) Op {
+	return Op{Type: OpTypeDelete, Key: prefix, prefix: true, op: clientv3.OpDelete(prefix, clientv3.WithPrefix())}
+}
+
+// OpResult is the outcome of a single operation of the executed branch
+type OpResult struct {
+	Type OpType
+	Key  string
+
+	// KVs holds the keys read by a get
+	KVs []*KeyValue
+
+	// Count is the number of keys matched by a get
+	Count int64
+
+	// Deleted is the number of keys removed by a delete
+	Deleted int64
+}
+
+// TxnResult is the outcome of a committed transaction
+type TxnResult struct {
+	// Succeeded is true if all conditions held and the Then branch ran
+	Succeeded bool
+
+	// Revision is the store revision after the transaction
+	Revision int64
+
+	// Results holds one entry per operation of the executed branch, in order
+	Results []*OpResult
+}
+
+// Txn builds a transaction: If all conditions hold, the Then operations
+// run, otherwise the Else operations. Nothing is sent until Commit.
+type Txn struct {
+	client  *Client
+	cmps    []Cmp
+	thenOps []Op
+	elseOps []Op
+}
+
+// NewTxn starts a new transaction
+func (c *Client) NewTxn() *Txn {
+	return &Txn{client: c}
+}
+
+// If adds conditions; all of them must hold for the Then branch to run
+func (t *Txn) If(cmps ...Cmp) *Txn {
+	t.cmps = append(t.cmps, cmps...)
+	return t
+}
+
+// Then adds operations run when all conditions hold
+func (t *Txn) Then(ops ...Op) *Txn {
+	t.thenOps = append(t.thenOps, ops...)
+	return t
+}
+
+// Else adds operations run when any condition fails
+func (t *Txn) Else(ops ...Op) *Txn {
+	t.elseOps = append(t.elseOps, ops...)
+	return t
+}
+
+// Ops returns the size of the largest part (conditions, Then or Else), which
+// is what etcd checks against its max-txn-ops limit
+func (t *Txn) Ops() int {
+	return max(len(t.cmps), len(t.thenOps), len(t.elseOps))
+}
+
+// Commit sends the transaction and returns the results of the executed
+// branch. Nothing is sent if the client's guard refuses an operation of
+// either branch.
+func (t *Txn) Commit(ctx context.Context) (*TxnResult, error) {
+	cmps := make([]clientv3.Cmp, 0, len(t.cmps))
+	for _, cmp := range t.cmps {
+		if cmp.err != nil {
+			return nil, cmp.err
+		}
+		cmps = append(cmps, cmp.cmp)
+	}
+	for _, ops := range [][]Op{t.thenOps, t.elseOps} {
+		for _, op := range ops {
+			if err := t.client.checkOp(ctx, op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.client.timeout)
+	defer cancel()
+
+	resp, err := t.client.client.Txn(ctx).
+		If(cmps...).
+		Then(clientOps(t.thenOps)...).
+		Else(clientOps(t.elseOps)...).
+		Commit()
+	if err != nil {
+		return nil, fmt.Errorf("transaction failed: %w", err)
+	}
+
+	ops := t.thenOps
+	if !resp.Succeeded {
+		ops = t.elseOps
+	}
+
+	result := &TxnResult{
+		Succeeded: resp.Succeeded,
+		Revision:  resp.Header.Revision,
+		Results:   make([]*OpResult, 0, len(resp.Responses)),
+	}
+
+	for i, r := range resp.Responses {
+		res := &OpResult{}
+		if i < len(ops) {
+			res.Type = ops[i].Type
+			res.Key = ops[i].Key
+		}
+		if rr := r.GetResponseRange(); rr != nil {
+			res.Count = rr.Count
+			res.KVs = make([]*KeyValue, 0, len(rr.Kvs))
+			for _, kv := range rr.Kvs {
+				res.KVs = append(res.KVs, newKeyValue(kv))
+			}
+		}
+		if dr := r.GetResponseDeleteRange(); dr != nil {
+			res.Deleted = dr.Deleted
+		}
+		result.Results = append(result.Results, res)
+	}
+
+	return result, nil
+}
+
+// checkOp returns why the client's guard refuses op
+func (c *Client) checkOp(ctx context.Context, op Op) error {
+	switch {
+	case op.Type == OpTypePut:
+		return c.checkPut(ctx, op.Key)
+	case op.Type == OpTypeDelete && op.prefix:
+		return c.checkDeletePrefix(ctx, op.Key)
+	case op.Type == OpTypeDelete:
+		return c.checkDelete(ctx, op.Key)
+	}
+	return nil
+}
+
+// clientOps unwraps ops for clientv3
+func clientOps(ops []Op) []clientv3.Op {
+	out := make([]clientv3.Op, 0, len(ops))
+	for _, op := range ops {
+		out = append(out, op.op)
+	}
+	return out
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidValue is matched by the errors returned for values that fail
+// validation
+var ErrInvalidValue = errors.New("value failed validation")
+
+// ValidationRule requires the values of the keys it matches to be valid in
+// a format and, if a schema is set, to match it
+type ValidationRule struct {
+	// Prefix matches keys starting with it; either Prefix or Glob is set
+	Prefix string
+
+	// Glob matches whole keys with path.Match patterns, where * does not
+	// match /
+	Glob string
+
+	// Format is the name of a value decoder, e.g. "json". It may be empty
+	// with a schema, which then accepts JSON and YAML.
+	Format string
+
+	// Schema is checked against JSON and YAML values
+	Schema *Schema
+}
+
+// Matches reports whether the rule applies to key
+func (r *ValidationRule) Matches(key string) bool {
+	if r.Glob != "" {
+		ok, _ := path.Match(r.Glob, key)
+		return ok
+	}
+	return strings.HasPrefix(key, r.Prefix)
+}
+
+// ValidationIssue is one problem of a value
+type ValidationIssue struct {
+	// Line is the line of the value the problem is on, 0 if unknown
+	Line int
+
+	// Path is the JSON pointer of the offending part for schema problems
+	Path string
+
+	Message string
+}
+
+// String formats the issue as "line 3: /port: must be at least 1"
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", i.Line)
+	}
+	if i.Path != "" {
+		b.WriteString(i.Path + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ValidationError lists the problems of the value of a key. It matches
+// ErrInvalidValue.
+type ValidationError struct {
+	Key    string
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Key, e.Issues[0])
+	if len(e.Issues) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Issues)-1)
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrInvalidValue) true
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
+
+// Validator checks values against the rules matching their keys. A nil
+// Validator accepts everything.
+type Validator struct {
+	rules   []ValidationRule
+	formats []ValueDecoder
+}
+
+// NewValidator checks the rules and returns a validator applying them
+func NewValidator(rules []ValidationRule) (*Validator, error) {
+	v := &Validator{rules: rules, formats: make([]ValueDecoder, len(rules))}
+	for i, r := range rules {
+		if (r.Prefix == "") == (r.Glob == "") {
+			return nil, fmt.Errorf("validation rule %d: set either a prefix or a glob", i+1)
+		}
+		if _, err := path.Match(r.Glob, ""); err != nil {
+			return nil, fmt.Errorf("validation rule %d: invalid glob %q: %w", i+1, r.Glob, err)
+		}
+
+		switch {
+		case r.Format != "":
+			if v.formats[i] = ValueDecoderByName(r.Format); v.formats[i] == nil {
+				return nil, fmt.Errorf("validation rule %d: unknown format %q", i+1, r.Format)
+			}
+			if r.Schema != nil && r.Format != "json" && r.Format != "yaml" {
+				return nil, fmt.Errorf("validation rule %d: a schema needs the json or yaml format, not %s", i+1, r.Format)
+			}
+		case r.Schema == nil:
+			return nil, fmt.Errorf("validation rule %d: set a format, a schema or both", i+1)
+		}
+	}
+	return v, nil
+}
+
+// Rules returns the rules matching key
+func (v *Validator) Rules(key string) []ValidationRule {
+	if v == nil {
+		return nil
+	}
+	var out []ValidationRule
+	for _, r := range v.rules {
+		if r.Matches(key) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Validate checks value against every rule matching key and returns a
+// *ValidationError listing all problems, or nil
+func (v *Validator) Validate(key string, value []byte) error {
+	if v == nil {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	for i := range v.rules {
+		if v.rules[i].Matches(key) {
+			issues = append(issues, v.check(i, value)...)
+		}
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	return &ValidationError{Key: key, Issues: issues}
+}
+
+// check returns the problems of value under rule i
+func (v *Validator) check(i int, value []byte) []ValidationIssue {
+	r, format := v.rules[i], v.formats[i]
+	if format == nil {
+		// A schema alone accepts JSON and YAML
+		format = yamlDecoder{}
+		if (jsonDecoder{}).Detect(value) {
+			format = jsonDecoder{}
+		}
+	}
+
+	if !IsPrintable(value) {
+		return []ValidationIssue{{Message: fmt.Sprintf("binary value is not valid %s", strings.ToUpper(format.Name()))}}
+	}
+	if _, err := format.Pretty(value); err != nil {
+		return []ValidationIssue{lineIssue(fmt.Sprintf("not valid %s", strings.ToUpper(format.Name())), err)}
+	}
+	if r.Schema == nil {
+		return nil
+	}
+
+	var (
+		node *valueNode
+		err  error
+	)
+	if format.Name() == "json" {
+		node, err = decodeJSONNode(value)
+	} else {
+		node, err = decodeYAMLNode(value)
+	}
+	if err != nil {
+		return []ValidationIssue{lineIssue("cannot be checked against the schema", err)}
+	}
+
+	var issues []ValidationIssue
+	r.Schema.validate(node, "", 0, &issues)
+	return issues
+}
+
+// errorLine matches the line decoders put at the start of their errors
+var errorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// lineIssue turns a decoder error into an issue, moving its line into
+// Line
+func lineIssue(what string, err error) ValidationIssue {
+	msg := err.Error()
+	issue := ValidationIssue{Message: what + ": " + msg}
+	if m := errorLine.FindStringSubmatch(msg); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		issue.Message = what + ": " + msg[len(m[0]):]
+	}
+	return issue
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// IsPrintable reports whether value is valid UTF-8 text without control
+// characters other than tab, newline and carriage return
+func IsPrintable(value []byte) bool {
+	for len(value) > 0 {
+		r, size := utf8.DecodeRune(value)
+		if r == utf8.RuneError && size <= 1 {
+			return false
+		}
+		if r != '\t' && r != '\n' && r != '\r' && !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+		value = value[size:]
+	}
+	return true
+}
+
+// IsBinary returns true if the value is not printable text
+func (kv *KeyValue) IsBinary() bool {
+	return !IsPrintable(kv.Value)
+}
+
+// IsBinary returns true if the new value of the event is not printable text
+func (e *WatchEvent) IsBinary() bool {
+	return !IsPrintable(e.Value)
+}
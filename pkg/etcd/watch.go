@@ -2,82 +2,251 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+// WatchProgressInterval is how often a watch asks the server for a progress
+// notification while no events arrive
+const WatchProgressInterval = 10 * time.Second
+
+const (
+	watchRetryMin = 500 * time.Millisecond
+	watchRetryMax = 10 * time.Second
+)
+
 // EventType represents the type of watch event
 type EventType int
 
 const (
 	EventTypePut EventType = iota
 	EventTypeDelete
+
+	// EventTypeCompacted reports that the revisions up to CompactRevision
+	// were compacted before they could be delivered; the watch resumes from
+	// CompactRevision
+	EventTypeCompacted
+
+	// EventTypeProgress reports that the watch is alive and has seen every
+	// change up to Revision
+	EventTypeProgress
 )
 
+// String returns the name of the event type
+func (t EventType) String() string {
+	switch t {
+	case EventTypePut:
+		return "put"
+	case EventTypeDelete:
+		return "delete"
+	case EventTypeCompacted:
+		return "compacted"
+	case EventTypeProgress:
+		return "progress"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
 // WatchEvent represents a change event from etcd
 type WatchEvent struct {
 	Type           EventType
 	Key            string
-	Value          string
-	PrevValue      string
+	Value          []byte
+	PrevValue      []byte
 	CreateRevision int64
 	ModRevision    int64
 	Version        int64
+
+	// Revision is the store revision of the response carrying the event
+	Revision int64
+
+	// CompactRevision is set for EventTypeCompacted
+	CompactRevision int64
 }
 
 // WatchCallback is called when a watch event occurs
 type WatchCallback func(*WatchEvent)
 
-// Watch starts watching a key or prefix for changes
+// Watch starts watching a key for changes. It blocks until ctx is
+// cancelled, resuming after transient failures.
 func (c *Client) Watch(ctx context.Context, key string, callback WatchCallback) error {
-	watchChan := c.client.Watch(ctx, key)
-	return c.processWatchEvents(watchChan, callback)
+	return c.watch(ctx, key, 0, callback)
 }
 
 // WatchPrefix starts watching all keys with a given prefix
 func (c *Client) WatchPrefix(ctx context.Context, prefix string, callback WatchCallback) error {
-	watchChan := c.client.Watch(ctx, prefix, clientv3.WithPrefix())
-	return c.processWatchEvents(watchChan, callback)
+	return c.watch(ctx, prefix, 0, callback, clientv3.WithPrefix())
 }
 
 // WatchFromRevision starts watching from a specific revision
 func (c *Client) WatchFromRevision(ctx context.Context, key string, revision int64, callback WatchCallback) error {
-	watchChan := c.client.Watch(ctx, key, clientv3.WithRev(revision))
-	return c.processWatchEvents(watchChan, callback)
+	return c.watch(ctx, key, revision, callback)
+}
+
+// WatchPrefixFromRevision starts watching all keys with a given prefix from
+// a specific revision
+func (c *Client) WatchPrefixFromRevision(ctx context.Context, prefix string, revision int64, callback WatchCallback) error {
+	return c.watch(ctx, prefix, revision, callback, clientv3.WithPrefix())
 }
 
-// processWatchEvents processes events from a watch channel
-func (c *Client) processWatchEvents(watchChan clientv3.WatchChan, callback WatchCallback) error {
+// watch runs a watch with previous values and progress notifications, and
+// re-creates it from the next unseen revision whenever the stream fails.
+// It returns nil when ctx is cancelled or the client is closed.
+func (c *Client) watch(ctx context.Context, key string, revision int64, callback WatchCallback, opts ...clientv3.OpOption) error {
+	retry := watchRetryMin
+
+	for {
+		// Require a leader so a partitioned member fails the stream instead
+		// of silently delivering nothing
+		watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+
+		watchOpts := append([]clientv3.OpOption{
+			clientv3.WithPrevKV(),
+			clientv3.WithProgressNotify(),
+			clientv3.WithCreatedNotify(),
+		}, opts...)
+		if revision > 0 {
+			watchOpts = append(watchOpts, clientv3.WithRev(revision))
+		}
+
+		go c.requestProgress(watchCtx)
+
+		next, delivered, err := processWatchEvents(c.client.Watch(watchCtx, key, watchOpts...), revision, callback)
+		cancel()
+
+		if ctx.Err() != nil || c.client.Ctx().Err() != nil {
+			return nil
+		}
+		if delivered {
+			retry = watchRetryMin
+		}
+		revision = next
+
+		var compacted *compactedError
+		switch {
+		case errors.As(err, &compacted):
+			// Continue from the oldest revision that is still available
+			callback(&WatchEvent{
+				Type:            EventTypeCompacted,
+				Key:             key,
+				Revision:        compacted.revision,
+				CompactRevision: compacted.revision,
+			})
+			revision = compacted.revision
+			continue
+		case errors.Is(err, rpctypes.ErrPermissionDenied), errors.Is(err, rpctypes.ErrFutureRev):
+			return fmt.Errorf("watch error: %w", err)
+		}
+
+		// Transient failure or closed stream: back off and resume
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retry):
+		}
+		retry = min(retry*2, watchRetryMax)
+	}
+}
+
+// requestProgress asks for progress notifications until ctx is done
+func (c *Client) requestProgress(ctx context.Context) {
+	ticker := time.NewTicker(WatchProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = c.client.RequestProgress(ctx)
+		}
+	}
+}
+
+// compactedError reports that the requested revision was compacted
+type compactedError struct {
+	revision int64
+}
+
+func (e *compactedError) Error() string {
+	return fmt.Sprintf("required revision has been compacted (compact revision %d)", e.revision)
+}
+
+// processWatchEvents processes events from a watch channel. It returns the
+// revision to resume from, whether anything was received and the error that
+// ended the stream.
+func processWatchEvents(watchChan clientv3.WatchChan, revision int64, callback WatchCallback) (int64, bool, error) {
+	delivered := false
+
 	for watchResp := range watchChan {
-		if watchResp.Err() != nil {
-			return fmt.Errorf("watch error: %w", watchResp.Err())
+		if watchResp.CompactRevision != 0 {
+			return revision, delivered, &compactedError{revision: watchResp.CompactRevision}
+		}
+		if err := watchResp.Err(); err != nil {
+			return revision, delivered, err
+		}
+		delivered = true
+
+		// A watch created without a start revision begins after the header
+		// revision
+		if watchResp.Created {
+			if revision == 0 {
+				revision = watchResp.Header.Revision + 1
+			}
+			continue
+		}
+
+		// Progress is only reported once every change up to the header
+		// revision has been delivered
+		if watchResp.IsProgressNotify() {
+			if next := watchResp.Header.Revision + 1; next > revision {
+				revision = next
+			}
+			callback(&WatchEvent{
+				Type:     EventTypeProgress,
+				Revision: watchResp.Header.Revision,
+			})
+			continue
 		}
 
+		// All keys of a transaction share a revision, so the resume revision
+		// only moves on once the whole response is delivered
+		next := revision
 		for _, event := range watchResp.Events {
+			// The client may replay already delivered revisions when it
+			// reconnects on its own
+			if event.Kv.ModRevision < revision {
+				continue
+			}
+
 			watchEvent := &WatchEvent{
 				Key:            string(event.Kv.Key),
-				Value:          string(event.Kv.Value),
+				Value:          event.Kv.Value,
 				CreateRevision: event.Kv.CreateRevision,
 				ModRevision:    event.Kv.ModRevision,
 				Version:        event.Kv.Version,
+				Revision:       watchResp.Header.Revision,
 			}
 
 			switch event.Type {
 			case clientv3.EventTypePut:
 				watchEvent.Type = EventTypePut
-				if event.PrevKv != nil {
-					watchEvent.PrevValue = string(event.PrevKv.Value)
-				}
 			case clientv3.EventTypeDelete:
 				watchEvent.Type = EventTypeDelete
-				if event.PrevKv != nil {
-					watchEvent.PrevValue = string(event.PrevKv.Value)
-				}
+			}
+			if event.PrevKv != nil {
+				watchEvent.PrevValue = event.PrevKv.Value
 			}
 
 			callback(watchEvent)
+			next = max(next, event.Kv.ModRevision+1)
 		}
+		revision = next
 	}
-	return nil
+
+	return revision, delivered, nil
 }